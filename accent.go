@@ -0,0 +1,43 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+const (
+	// AccentMinChroma is the LAB chroma (0-100 scale) an accent color has at least
+	AccentMinChroma = 20.0
+	// AccentMinDeltaE is the CIEDE2000 delta E (0-100 scale) an accent color differs from the dominant color at least
+	AccentMinDeltaE = 20.0
+	// AccentMinShare is the share (0-1) of the pixels an accent color has at least
+	AccentMinShare = 0.05
+)
+
+// AccentColor picks the accent color of a palette sorted by prominence (e.g. Result.Colors), the first color being
+// the dominant color. Of the other colors with at least AccentMinChroma, AccentMinDeltaE from the dominant color and
+// AccentMinShare of the pixels, the one with the highest chroma is the accent color. It returns false if no color
+// qualifies.
+func AccentColor(palette []ColorItem) (ColorItem, bool) {
+	if len(palette) < 2 {
+		return ColorItem{}, false
+	}
+	total := 0
+	for _, c := range palette {
+		total += c.Cnt
+	}
+
+	var accent ColorItem
+	found, best := false, 0.0
+	for _, c := range palette[1:] {
+		if total == 0 || float64(c.Cnt)/float64(total) < AccentMinShare {
+			continue
+		}
+		if distanceCIEDE2000(c, palette[0])*100 < AccentMinDeltaE {
+			continue
+		}
+		if ch := chroma(c); ch >= AccentMinChroma && ch > best {
+			accent, found, best = c, true, ch
+		}
+	}
+	return accent, found
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// AdaptationMethod is the cone response model of a chromatic adaptation transform
+type AdaptationMethod int
+
+const (
+	// AdaptBradford uses the Bradford cone responses, as ICC profiles do (default)
+	AdaptBradford AdaptationMethod = iota
+	// AdaptCAT16 uses the CAT16 cone responses of CAM16, with full adaptation
+	AdaptCAT16
+)
+
+func (m AdaptationMethod) String() string {
+	switch m {
+	case AdaptBradford:
+		return "bradford"
+	case AdaptCAT16:
+		return "cat16"
+	}
+	return "unknown"
+}
+
+// XYZ coordinates (Y=1) of the CIE standard illuminants (2° observer), the white points for AdaptColor
+var (
+	IlluminantA   = [3]float64{1.09850, 1.0, 0.35585}
+	IlluminantB   = [3]float64{0.99072, 1.0, 0.85223}
+	IlluminantC   = [3]float64{0.98074, 1.0, 1.18232}
+	IlluminantD50 = whiteD50
+	IlluminantD55 = [3]float64{0.95682, 1.0, 0.92149}
+	IlluminantD65 = whiteD65
+	IlluminantD75 = [3]float64{0.94972, 1.0, 1.22638}
+	IlluminantF2  = [3]float64{0.99186, 1.0, 0.67393}
+	IlluminantF7  = [3]float64{0.95041, 1.0, 1.08747}
+	IlluminantF11 = [3]float64{1.00962, 1.0, 0.64350}
+)
+
+// AdaptationMatrix returns the matrix adapting XYZ colors seen under the white point from to the white point to
+// (von Kries scaling of the cone responses of the method)
+func AdaptationMatrix(from, to [3]float64, method AdaptationMethod) [3][3]float64 {
+	cone := bradford
+	if method == AdaptCAT16 {
+		cone = m16
+	}
+	src, dst := mulVector(cone, from), mulVector(cone, to)
+	var scale [3][3]float64
+	for i := 0; i < 3; i++ {
+		scale[i][i] = dst[i] / src[i]
+	}
+	return mulMatrix(invertMatrix(cone), mulMatrix(scale, cone))
+}
+
+// AdaptColor returns the color as it appears under the white point to when seen under the white point from, e.g.
+// from IlluminantA (tungsten) to IlluminantD65 to take out a tungsten color cast. Colors outside of sRGB are clamped.
+func AdaptColor(c ColorItem, from, to [3]float64, method AdaptationMethod) ColorItem {
+	return adaptColor(c, AdaptationMatrix(from, to, method))
+}
+
+// AdaptColors adapts the colors as AdaptColor, e.g. to compare palettes of images taken under different light
+func AdaptColors(colors []ColorItem, from, to [3]float64, method AdaptationMethod) []ColorItem {
+	m := AdaptationMatrix(from, to, method)
+	adapted := make([]ColorItem, len(colors))
+	for i, c := range colors {
+		adapted[i] = adaptColor(c, m)
+	}
+	return adapted
+}
+
+// adaptColor converts the color with the adaptation matrix, keeping its count and shares
+func adaptColor(c ColorItem, m [3][3]float64) ColorItem {
+	x, y, z := c.toColorful().Xyz()
+	xyz := mulVector(m, [3]float64{x, y, z})
+	rgb := colorful.Xyz(xyz[0], xyz[1], xyz[2]).Clamped()
+	adapted := newColorItem16(uint32(math.Round(rgb.R*0xffff)), uint32(math.Round(rgb.G*0xffff)), uint32(math.Round(rgb.B*0xffff)), c.Cnt)
+	adapted.Percentage, adapted.ShareOfTotal = c.Percentage, c.ShareOfTotal
+	adapted.Name, adapted.NameDeltaE = c.Name, c.NameDeltaE
+	return adapted
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAdaptationMatrix(t *testing.T) {
+	// the Bradford matrix from D65 to D50 as published by Lindbloom
+	want := [3][3]float64{
+		{1.0478112, 0.0228866, -0.0501270},
+		{0.0295424, 0.9904844, -0.0170491},
+		{-0.0092345, 0.0150436, 0.7521316},
+	}
+	got := AdaptationMatrix(IlluminantD65, IlluminantD50, AdaptBradford)
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(got[i][j]-want[i][j]) > 1e-3 {
+				t.Fatalf("Expected %v, got %v", want, got)
+			}
+		}
+	}
+
+	for _, method := range []AdaptationMethod{AdaptBradford, AdaptCAT16} {
+		// the source white point becomes the destination white point
+		white := mulVector(AdaptationMatrix(IlluminantA, IlluminantD65, method), IlluminantA)
+		for i := range white {
+			if math.Abs(white[i]-IlluminantD65[i]) > 1e-9 {
+				t.Errorf("%s: expected %v, got %v", method, IlluminantD65, white)
+				break
+			}
+		}
+		same := AdaptationMatrix(IlluminantF2, IlluminantF2, method)
+		for i := range same {
+			for j := range same[i] {
+				want := 0.0
+				if i == j {
+					want = 1
+				}
+				if math.Abs(same[i][j]-want) > 1e-9 {
+					t.Fatalf("%s: expected the identity, got %v", method, same)
+				}
+			}
+		}
+	}
+}
+
+func TestAdaptColor(t *testing.T) {
+	c := newColorItem16(0xc000, 0x8000, 0x4000, 7)
+	c.Percentage, c.Name = 70, "peru"
+	for _, method := range []AdaptationMethod{AdaptBradford, AdaptCAT16} {
+		adapted := AdaptColor(c, IlluminantA, IlluminantD65, method)
+		// taking out the tungsten cast makes the color bluer
+		if adapted.Color16.B <= c.Color16.B || adapted.Color16.R >= c.Color16.R {
+			t.Errorf("%s: expected a cooler color than %v, got %v", method, c.Color16, adapted.Color16)
+		}
+		if adapted.Cnt != 7 || adapted.Percentage != 70 || adapted.Name != "peru" {
+			t.Errorf("%s: expected the count, share and name to be kept, got %+v", method, adapted)
+		}
+		back := AdaptColor(adapted, IlluminantD65, IlluminantA, method)
+		if back.Color != c.Color {
+			t.Errorf("%s: expected %v back, got %v", method, c.Color, back.Color)
+		}
+		if all := AdaptColors([]ColorItem{c, c}, IlluminantA, IlluminantD65, method); len(all) != 2 || all[1] != adapted {
+			t.Errorf("%s: expected AdaptColors to adapt as AdaptColor, got %+v", method, all)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"math"
+	"time"
+)
+
+// minFrameDelay is the shortest frame delay in 1/100 s, browsers show frames with a shorter delay this long
+const minFrameDelay = 10
+
+// AnimationFrame is a fully rendered frame of an animation (e.g. a decoded APNG) and how long it is shown
+type AnimationFrame struct {
+	Image image.Image
+	Delay time.Duration
+}
+
+// KmeansAnimation finds the colors of each frame, and of all frames combined weighted by how long they are shown
+func KmeansAnimation(frames []AnimationFrame, opts Options) (FramesResult, error) {
+	if len(frames) == 0 {
+		return FramesResult{}, fmt.Errorf("Failed, animation without frames")
+	}
+	images := make([]image.Image, len(frames))
+	indices := make([]int, len(frames))
+	weights := make([]int, len(frames))
+	for i, f := range frames {
+		images[i], indices[i] = f.Image, i
+		weights[i] = frameWeight(int(math.Round(f.Delay.Seconds() * 100)))
+	}
+	return kmeansFrames(images, indices, weights, opts)
+}
+
+// KmeansGIF finds the colors of the frames of the animated GIF picked by sampling, and of those frames combined
+// weighted by their delay. The frames are rendered onto the canvas following their disposal methods.
+func KmeansGIF(g *gif.GIF, sampling FrameSampling, opts Options) (FramesResult, error) {
+	if len(g.Image) == 0 {
+		return FramesResult{}, fmt.Errorf("Failed, GIF without frames")
+	}
+	indices := sampling.indices(len(g.Image))
+	images := renderGIFFrames(g, indices)
+	weights := make([]int, len(indices))
+	for i, idx := range indices {
+		delay := 0
+		if idx < len(g.Delay) {
+			delay = g.Delay[idx]
+		}
+		weights[i] = frameWeight(delay)
+	}
+	return kmeansFrames(images, indices, weights, opts)
+}
+
+// frameWeight returns the weight of a frame with the delay (in 1/100 s)
+func frameWeight(delay int) int {
+	return max(delay, minFrameDelay)
+}
+
+// renderGIFFrames composes the frames at the indices (sorted) onto the canvas, as a viewer shows them
+func renderGIFFrames(g *gif.GIF, indices []int) []image.Image {
+	canvasRect := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if canvasRect.Empty() {
+		for _, frame := range g.Image {
+			canvasRect = canvasRect.Union(frame.Bounds())
+		}
+	}
+	canvas := image.NewRGBA(canvasRect)
+
+	var images []image.Image
+	next := 0
+	for i, frame := range g.Image {
+		if next == len(indices) {
+			break
+		}
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvasRect)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if indices[next] == i {
+			rendered := image.NewRGBA(canvasRect)
+			copy(rendered.Pix, canvas.Pix)
+			images = append(images, rendered)
+			next++
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return images
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/draw"
+)
+
+// DefaultBackgroundTolerance is a delta E tolerance suitable for off-white or slightly uneven studio backgrounds
+const DefaultBackgroundTolerance = 8.0
+
+// floodFillBackground removes the background connected to the border of the image, i.e. the pixels within tolerance
+// (CIEDE2000 delta E, 0-100 scale) of the median border color. Nothing is done unless at least half of the border
+// pixels match, as then there is no solid background. It returns the number of pixels removed.
+func floodFillBackground(img draw.Image, tolerance float64) int {
+	rect := img.Bounds()
+	if rect.Empty() {
+		return 0
+	}
+
+	var border []image.Point
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		border = append(border, image.Point{X: x, Y: rect.Min.Y})
+		if rect.Dy() > 1 {
+			border = append(border, image.Point{X: x, Y: rect.Max.Y - 1})
+		}
+	}
+	for y := rect.Min.Y + 1; y < rect.Max.Y-1; y++ {
+		border = append(border, image.Point{X: rect.Min.X, Y: y})
+		if rect.Dx() > 1 {
+			border = append(border, image.Point{X: rect.Max.X - 1, Y: y})
+		}
+	}
+
+	var borderColors []ColorItem
+	for _, p := range border {
+		if c, ignore := createColor(img.At(p.X, p.Y)); !ignore {
+			c.Cnt = 1
+			borderColors = append(borderColors, c)
+		}
+	}
+	if len(borderColors) == 0 {
+		return 0
+	}
+	reference := median(borderColors, false)
+
+	matches := make(map[uint64]bool)
+	isBackground := func(p image.Point) bool {
+		c, ignore := createColor(img.At(p.X, p.Y))
+		if ignore {
+			return false
+		}
+		key := c.key()
+		match, ok := matches[key]
+		if !ok {
+			match = distanceCIEDE2000(c, reference)*100 <= tolerance
+			matches[key] = match
+		}
+		return match
+	}
+
+	var pointsToProcess []image.Point
+	for _, p := range border {
+		if isBackground(p) {
+			pointsToProcess = append(pointsToProcess, p)
+		}
+	}
+	if 2*len(pointsToProcess) < len(borderColors) {
+		return 0
+	}
+
+	removed := 0
+	var p image.Point
+	for len(pointsToProcess) > 0 {
+		p, pointsToProcess = pointsToProcess[len(pointsToProcess)-1], pointsToProcess[:len(pointsToProcess)-1]
+		if !isBackground(p) {
+			// already marked
+			continue
+		}
+		markPixel(p.X, p.Y, &img)
+		removed++
+
+		for _, n := range []image.Point{{X: p.X - 1, Y: p.Y}, {X: p.X + 1, Y: p.Y}, {X: p.X, Y: p.Y - 1}, {X: p.X, Y: p.Y + 1}} {
+			if n.In(rect) && isBackground(n) {
+				pointsToProcess = append(pointsToProcess, n)
+			}
+		}
+	}
+	return removed
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// BandDirection selects if ColorBands splits the image into bands of columns or of rows
+type BandDirection int
+
+const (
+	// BandColumns splits the image into vertical bands, left to right
+	BandColumns BandDirection = iota
+
+	// BandRows splits the image into horizontal bands, top to bottom
+	BandRows
+)
+
+// ColorBands returns the dominant color (see DominantColor) of each of n bands of the image, e.g. for the "color
+// barcode" of a photo, n = 0 making a band of each column (or row). The bands are processed as Options.Region of
+// the image at their original size and are not cropped (Size, Samples and Crop are not used), so the masks apply
+// per band and every pixel counts. Bands without any usable pixels get a ColorItem with a zero Cnt.
+func ColorBands(img image.Image, n int, dir BandDirection, opts Options) ([]ColorItem, error) {
+	b := img.Bounds()
+	length := b.Dx()
+	if dir == BandRows {
+		length = b.Dy()
+	}
+	if n <= 0 || n > length {
+		n = length
+	}
+	opts.Size = OriginalSize
+	opts.Samples = 0
+	opts.Crop = CropNone
+
+	colors := make([]ColorItem, n)
+	for i := range colors {
+		start, end := b.Min.X+i*length/n, b.Min.X+(i+1)*length/n
+		opts.Region = image.Rect(start, b.Min.Y, end, b.Max.Y)
+		if dir == BandRows {
+			start, end = b.Min.Y+i*length/n, b.Min.Y+(i+1)*length/n
+			opts.Region = image.Rect(b.Min.X, start, b.Max.X, end)
+		}
+		c, err := DominantColor(img, opts)
+		if err != nil && !errors.Is(err, ErrNoPixelsFound) {
+			return nil, err
+		}
+		colors[i] = c
+	}
+	return colors, nil
+}
+
+// BandStrip draws the colors of ColorBands as a strip one pixel high (BandColumns) or wide (BandRows), to be scaled
+// to the size needed. Bands without pixels are transparent.
+func BandStrip(colors []ColorItem, dir BandDirection) *image.RGBA {
+	r := image.Rect(0, 0, len(colors), 1)
+	if dir == BandRows {
+		r = image.Rect(0, 0, 1, len(colors))
+	}
+	strip := image.NewRGBA(r)
+	for i, c := range colors {
+		if c.Cnt == 0 {
+			continue
+		}
+		x, y := i, 0
+		if dir == BandRows {
+			x, y = 0, i
+		}
+		strip.SetRGBA(x, y, color.RGBA{R: uint8(c.Color.R), G: uint8(c.Color.G), B: uint8(c.Color.B), A: 0xff})
+	}
+	return strip
+}
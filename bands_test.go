@@ -0,0 +1,39 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestColorBandsNotCropped checks the whole band is used: its outer 60% is red, the center 40% (which a center crop
+// would keep most of) blue
+func TestColorBandsNotCropped(t *testing.T) {
+	const bands, width = 4, 10
+	img := image.NewRGBA(image.Rect(0, 0, bands*width, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < bands*width; x++ {
+			c := color.RGBA{R: 0xff, A: 0xff}
+			if x%width >= 3 && x%width < 7 {
+				c = color.RGBA{B: 0xff, A: 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	opts := DefaultOptions()
+	opts.Masks = nil
+	opts.Crop = CropCenter
+	colors, err := ColorBands(img, bands, BandColumns, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range colors {
+		if c.Color.R != 0xff || c.Color.B != 0 || c.Cnt != 6*20 {
+			t.Errorf("Expected band %d to be red, got %v with %d pixels", i, c.Color, c.Cnt)
+		}
+	}
+}
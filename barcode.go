@@ -0,0 +1,109 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// MovieBarcode builds the "movie barcode" of a video: a column per sampled frame in the dominant color of the frame
+// (see DominantColor), or with Bands set in the colors of that many horizontal bands of the frame (see ColorBands).
+// Like FrameStream the frames are added one at a time. Create it with NewMovieBarcode.
+type MovieBarcode struct {
+	opts Options
+
+	// Every is the sampling step: every Every:th frame is analyzed, starting with the first one.
+	// Values below 1 analyze all frames.
+	Every int
+
+	// Bands if set splits each frame into this many horizontal bands, the columns then show how the colors are
+	// distributed from top to bottom
+	Bands int
+
+	columns [][]ColorItem
+	frames  int
+}
+
+// NewMovieBarcode creates a barcode analyzing every Nth frame added with opts
+func NewMovieBarcode(opts Options, every int) *MovieBarcode {
+	return &MovieBarcode{opts: opts, Every: every}
+}
+
+// AddFrame adds the next frame of the video, analyzing it if it is sampled
+func (b *MovieBarcode) AddFrame(img image.Image) error {
+	defer func() { b.frames++ }()
+	if b.frames%max(b.Every, 1) != 0 {
+		return nil
+	}
+
+	if b.Bands > 0 {
+		colors, err := ColorBands(img, b.Bands, BandRows, b.opts)
+		if err != nil {
+			return err
+		}
+		b.columns = append(b.columns, colors)
+		return nil
+	}
+	c, err := DominantColor(img, b.opts)
+	if err != nil && !errors.Is(err, ErrNoPixelsFound) {
+		return err
+	}
+	// a frame without pixels (e.g. masked completely) gets a transparent column
+	b.columns = append(b.columns, []ColorItem{c})
+	return nil
+}
+
+// Frames returns the number of frames added
+func (b *MovieBarcode) Frames() int {
+	return b.frames
+}
+
+// Image draws the barcode with the size, 0 keeping one pixel per analyzed frame (width) or band (height).
+// Each pixel is the average of the columns and bands it covers, columns of frames without pixels are transparent.
+func (b *MovieBarcode) Image(width, height int) *image.RGBA {
+	cols := len(b.columns)
+	rows := 1
+	for _, column := range b.columns {
+		rows = max(rows, len(column))
+	}
+	if width <= 0 {
+		width = cols
+	}
+	if height <= 0 {
+		height = rows
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if cols == 0 {
+		return img
+	}
+
+	for x := 0; x < width; x++ {
+		c0 := x * cols / width
+		c1 := max((x+1)*cols/width, c0+1)
+		for y := 0; y < height; y++ {
+			r0 := y * rows / height
+			r1 := max((y+1)*rows/height, r0+1)
+
+			var r, g, bl, n uint64
+			for _, column := range b.columns[c0:c1] {
+				for _, c := range column[min(r0, len(column)):min(r1, len(column))] {
+					if c.Cnt == 0 {
+						continue
+					}
+					r += uint64(c.Color.R)
+					g += uint64(c.Color.G)
+					bl += uint64(c.Color.B)
+					n++
+				}
+			}
+			if n > 0 {
+				img.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 0xff})
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"context"
+	"crypto/sha256"
+	"image"
+	"os"
+	"runtime"
+	"sync"
+)
+
+func init() {
+	registerBackend("batch")
+}
+
+// BatchResult is the outcome for one file (or image) of a batch
+type BatchResult struct {
+	Path string
+	Result
+
+	// Err is set if the file could not be read, decoded or processed
+	Err error
+
+	// Duplicate is set if the file has the same content as an earlier file in the batch, whose result is reused
+	Duplicate bool
+}
+
+// KmeansBatch finds the colors of the image files, in the same order as paths.
+// Files with identical content (e.g. repeated placeholder images) are only decoded and processed once.
+func KmeansBatch(paths []string, opts Options) []BatchResult {
+	results := make([]BatchResult, len(paths))
+	seen := make(map[[sha256.Size]byte]int)
+
+	for i, path := range paths {
+		results[i].Path = path
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		hash := sha256.Sum256(data)
+		if first, ok := seen[hash]; ok {
+			results[i].Result = results[first].Result
+			results[i].Err = results[first].Err
+			results[i].Duplicate = true
+			continue
+		}
+		seen[hash] = i
+
+		results[i].Result, results[i].Err = KmeansFromBytes(data, opts)
+	}
+	return results
+}
+
+// KmeansBatchImages finds the colors of the images concurrently, one worker per CPU, returning the results in the
+// same order as images. Once ctx is done the images not yet processed get its error.
+func KmeansBatchImages(ctx context.Context, images []image.Image, opts Options) []BatchResult {
+	results := make([]BatchResult, len(images))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(images)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Result, results[i].Err = KmeansWithOptions(images[i], opts)
+			}
+		}()
+	}
+	for i := range images {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
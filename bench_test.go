@@ -0,0 +1,67 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// benchImage returns a photo-like image: smooth gradients, a subject in the center and some noise
+func benchImage(w, h int) *image.RGBA {
+	rnd := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: uint8(80 + 100*x/w), G: uint8(120 + 80*y/h), B: 200, A: 0xff}
+			if dx, dy := x-w/2, y-h/2; dx*dx+dy*dy < w*h/16 {
+				c = color.RGBA{R: uint8(200 - 60*y/h), G: uint8(60 + 40*x/w), B: 40, A: 0xff}
+			}
+			n := uint8(rnd.Intn(8))
+			c.R, c.G, c.B = c.R+n, c.G+n, c.B+n
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// benchSizes are a web image (1 MP) and a camera photo (12 MP)
+var benchSizes = []struct {
+	name string
+	w, h int
+}{
+	{"1MP", 1200, 900},
+	{"12MP", 4000, 3000},
+}
+
+// benchmarkSizes runs f on the images of benchSizes
+func benchmarkSizes(b *testing.B, f func(img image.Image) error) {
+	for _, size := range benchSizes {
+		img := benchImage(size.w, size.h)
+		b.Run(size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := f(img); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkKmeansWithOptions(b *testing.B) {
+	benchmarkSizes(b, func(img image.Image) error {
+		_, err := KmeansWithOptions(img, DefaultOptions())
+		return err
+	})
+}
+
+func BenchmarkDominantColor(b *testing.B) {
+	benchmarkSizes(b, func(img image.Image) error {
+		_, err := DominantColor(img)
+		return err
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// BorderBackground detects the background color from the border of the image, so no background color has to be
+// assumed: the pixels within Thickness of the border are clustered and the largest cluster is the background,
+// if it covers at least half of the border. All pixels within Tolerance of it are removed. The border is sampled
+// on the image before it is cropped (see Crop), as the center crop would drop the actual border.
+type BorderBackground struct {
+	// Thickness is the share (0-0.5) of the smaller side of the image sampled at each border, e.g. 0.05 for 5%
+	Thickness float64
+
+	// Tolerance is the largest CIEDE2000 delta E (0-100 scale) from the background color that is removed
+	Tolerance float64
+}
+
+// DefaultBorderBackground samples the outer 5% of the image
+var DefaultBorderBackground = BorderBackground{Thickness: 0.05, Tolerance: 10}
+
+// borderBackgroundK is the number of clusters the border pixels are clustered into
+const borderBackgroundK = 3
+
+// borderSampleSize is the smaller side of the grid the border of a larger image is sampled on
+const borderSampleSize = 512
+
+// detect returns the background color sampled at the border of src, false if the border has no dominant color
+func (bb BorderBackground) detect(src image.Image) (ColorItem, bool) {
+	b := src.Bounds()
+	if b.Empty() {
+		return ColorItem{}, false
+	}
+	thickness := max(1, int(math.Round(bb.Thickness*float64(min(b.Dx(), b.Dy())))))
+	inner := b.Inset(thickness)
+	stride := max(1, min(b.Dx(), b.Dy())/borderSampleSize)
+
+	var border []ColorItem
+	for y := b.Min.Y; y < b.Max.Y; y += stride {
+		for x := b.Min.X; x < b.Max.X; x += stride {
+			if x >= inner.Min.X && x < inner.Max.X && y >= inner.Min.Y && y < inner.Max.Y {
+				continue
+			}
+			if c, ignore := createColor(src.At(x, y)); !ignore {
+				c.Cnt = 1
+				border = append(border, c)
+			}
+		}
+	}
+	if len(border) == 0 {
+		return ColorItem{}, false
+	}
+
+	clusters, err := kmeansColors(borderBackgroundK, mergeColors([][]ColorItem{border}), ArgumentLAB|ArgumentCountWeighted|ArgumentDeterministic)
+	if err != nil || 2*clusters[0].Cnt < len(border) {
+		return ColorItem{}, false
+	}
+	return clusters[0], true
+}
+
+// apply removes the background detected at the border of src from img, returning the number of pixels removed
+func (bb BorderBackground) apply(src image.Image, img draw.Image) int {
+	background, ok := bb.detect(src)
+	if !ok {
+		return 0
+	}
+
+	b := img.Bounds()
+	removed := 0
+	matches := make(map[uint64]bool)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c, ignore := createColor(img.At(x, y))
+			if ignore {
+				continue
+			}
+			key := c.key()
+			match, ok := matches[key]
+			if !ok {
+				match = distanceCIEDE2000(c, background)*100 <= bb.Tolerance
+				matches[key] = match
+			}
+			if match {
+				markPixel(x, y, &img)
+				removed++
+			}
+		}
+	}
+	return removed
+}
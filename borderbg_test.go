@@ -0,0 +1,41 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestBorderBackgroundSamplesUncroppedBorder checks the background is sampled at the border of the image and not
+// at the border of the center crop, which here only holds the subject
+func TestBorderBackgroundSamplesUncroppedBorder(t *testing.T) {
+	background := color.RGBA{R: 200, G: 180, B: 120, A: 255}
+	subject := color.RGBA{R: 20, G: 90, B: 40, A: 255}
+	img := framedImage(200, background, subject, subject)
+	opts := DefaultOptions()
+	opts.Arguments = ArgumentDeterministic
+	opts.BorderBackground = &DefaultBorderBackground
+	res, err := KmeansWithOptions(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := res.Stats.MaskedPixels["border background"]; n != 0 {
+		t.Fatalf("expected the subject to be kept, %d pixels removed", n)
+	}
+	if c := res.Colors[0].Color; c.R != uint32(subject.R) || c.G != uint32(subject.G) || c.B != uint32(subject.B) {
+		t.Fatalf("expected the subject %v, got %v", subject, res.Colors)
+	}
+
+	// without the crop the frame is removed
+	opts.Arguments |= ArgumentNoCropping
+	res, err = KmeansWithOptions(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stats.MaskedPixels["border background"] == 0 {
+		t.Fatal("expected the frame to be removed")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"time"
+)
+
+// MinAutoSize is the smallest size the budget mode will resize to
+const MinAutoSize = 16
+
+// Estimate contains the expected cost of processing an image with the settings chosen by AutoOptions
+type Estimate struct {
+	Decode, Resize, Cluster time.Duration
+}
+
+// Total is the expected total processing time
+func (e Estimate) Total() time.Duration {
+	return e.Decode + e.Resize + e.Cluster
+}
+
+// AutoOptions inspects the encoded image (byte size and dimensions, without decoding the pixels) and returns opts with
+// the resize target picked so the expected total processing time stays within budget. opts.Size is used as upper limit.
+// If resizing alone would take more than half of the budget (large images, short budgets) or opts.Samples is set,
+// opts.Samples is picked instead (at most opts.Samples if set), sampling costing only the pixels picked.
+// The cost model is calibrated on this host the first time it is needed, unless Calibrate has been called.
+func AutoOptions(data []byte, budget time.Duration, opts Options) (Options, Estimate, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return opts, Estimate{}, err
+	}
+	return autoOptions(currentCalibration(), len(data), cfg.Width, cfg.Height, budget, opts)
+}
+
+// KmeansWithBudget decodes the image and finds its colors, with settings picked by AutoOptions
+func KmeansWithBudget(data []byte, budget time.Duration, opts Options) (Result, error) {
+	opts, _, err := AutoOptions(data, budget, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	return KmeansFromBytes(data, opts)
+}
+
+// autoOptions picks the largest size (at most opts.Size) or number of samples that fits the budget
+func autoOptions(cal Calibration, numBytes, width, height int, budget time.Duration, opts Options) (Options, Estimate, error) {
+	if width <= 0 || height <= 0 {
+		return opts, Estimate{}, fmt.Errorf("Failed, invalid image dimensions %dx%d", width, height)
+	}
+
+	// the processing is done on the center crop
+	w, h := float64(width), float64(height)
+	if !IsBitSet(opts.arguments(), ArgumentNoCropping) {
+		w, h = w/2, h/2
+	}
+
+	perPixel := nsPer(cal.ClusterPixelsPerSec) * float64(opts.k()) / DefaultK * cal.distanceFactor(opts.arguments())
+	// resizing reads every pixel of the crop, sampling only the ones picked
+	perSample := nsPer(cal.ResizePixelsPerSec)
+
+	est := Estimate{
+		Decode: time.Duration(float64(width*height)*nsPer(cal.DecodePixelsPerSec) + float64(numBytes)*nsPer(cal.DecodeBytesPerSec)),
+	}
+	remaining := float64(budget - est.Decode)
+	resize := w * h * nsPer(cal.ResizePixelsPerSec)
+
+	maxSize := float64(opts.Size)
+	if maxSize == 0 || maxSize > w {
+		maxSize = w
+	}
+	// processed pixels are size * size * h/w
+	maxPixels := math.Floor(maxSize * maxSize * h / w)
+
+	if opts.Samples > 0 || resize > remaining/2 {
+		// resizing would take most of the budget, pick about as many pixels as can be clustered
+		samples := maxPixels
+		if opts.Samples > 0 {
+			samples = math.Min(samples, float64(opts.Samples))
+		}
+		if perPixel+perSample > 0 {
+			samples = math.Min(samples, math.Floor(math.Max(remaining, 0)/(perPixel+perSample)))
+		}
+		samples = math.Max(samples, math.Min(MinAutoSize*MinAutoSize, maxPixels))
+		est.Resize = time.Duration(samples * perSample)
+		est.Cluster = time.Duration(samples * perPixel)
+		opts.Samples = int(samples)
+		return opts, est, nil
+	}
+
+	// solve size * size * h/w * perPixel = remaining for size, without a cost (no calibration) the largest size fits
+	size := maxSize
+	if perPixel > 0 {
+		size = math.Min(size, math.Floor(math.Sqrt(math.Max(remaining-resize, 0)/perPixel*w/h)))
+	}
+	size = math.Max(size, math.Min(MinAutoSize, maxSize))
+
+	if size < w {
+		est.Resize = time.Duration(resize)
+	}
+	est.Cluster = time.Duration(size * size * h / w * perPixel)
+	opts.Size = uint(size)
+	return opts, est, nil
+}
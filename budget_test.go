@@ -0,0 +1,56 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"testing"
+	"time"
+)
+
+// testCalibration is a host clustering a million and resizing a hundred million pixels per second
+var testCalibration = Calibration{ClusterPixelsPerSec: 1e6, ResizePixelsPerSec: 1e8, RGBDistanceOpsPerSec: 1e8}
+
+func TestAutoOptionsWithoutCalibration(t *testing.T) {
+	opts, est, err := autoOptions(Calibration{}, 1000, 800, 600, 0, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Size != DefaultSize || opts.Samples != 0 || est.Total() != 0 {
+		t.Errorf("Expected the default size without a cost, got size %d, %d samples, estimate %v", opts.Size, opts.Samples, est)
+	}
+}
+
+func TestAutoOptionsPicksSizeOrSamples(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Size = OriginalSize
+
+	// a small image is resized to fit the budget
+	small, est, err := autoOptions(testCalibration, 1000, 1000, 1000, 20*time.Millisecond, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Samples != 0 || small.Size < MinAutoSize || small.Size >= 500 || est.Total() > 20*time.Millisecond {
+		t.Errorf("Expected a resize within the budget, got size %d, %d samples, estimate %v", small.Size, small.Samples, est.Total())
+	}
+
+	// resizing the crop of a 50 MP photo alone takes 125ms, so it is sampled
+	large, est, err := autoOptions(testCalibration, 1000, 10000, 5000, 20*time.Millisecond, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if large.Samples < MinAutoSize*MinAutoSize || est.Total() > 20*time.Millisecond {
+		t.Errorf("Expected samples within the budget, got %d samples, estimate %v", large.Samples, est.Total())
+	}
+
+	// samples set are the upper limit
+	opts.Samples = 1000
+	capped, _, err := autoOptions(testCalibration, 1000, 1000, 1000, time.Second, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capped.Samples != 1000 {
+		t.Errorf("Expected the 1000 samples set, got %d", capped.Samples)
+	}
+}
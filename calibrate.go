@@ -0,0 +1,195 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Calibration contains the measured throughput of this host, used by the budget/auto modes (see AutoOptions)
+type Calibration struct {
+	// RGBDistanceOpsPerSec, LABDistanceOpsPerSec etc are distance calculations per second
+	RGBDistanceOpsPerSec       float64
+	LABDistanceOpsPerSec       float64
+	FixedLABDistanceOpsPerSec  float64
+	CIEDE2000DistanceOpsPerSec float64
+	CAM16UCSDistanceOpsPerSec  float64
+
+	// ResizePixelsPerSec is the number of source pixels cropped and resized per second
+	ResizePixelsPerSec float64
+
+	// ClusterPixelsPerSec is the number of processed pixels masked and clustered per second (k=DefaultK, RGB)
+	ClusterPixelsPerSec float64
+
+	// DecodePixelsPerSec and DecodeBytesPerSec together make up the decoding throughput,
+	// zero means that part is not significant
+	DecodePixelsPerSec float64
+	DecodeBytesPerSec  float64
+}
+
+var (
+	calibration   Calibration
+	calibrated    bool
+	calibrationMu sync.Mutex
+)
+
+// Calibrate micro-benchmarks the host (distance calculations, resizing, clustering and decoding) and stores the result,
+// so the budget/auto modes adapt to small containers as well as big servers. It takes in the order of 100ms.
+// If ctx is done before the benchmark finishes, the previous calibration is kept and ctx.Err() is returned.
+func Calibrate(ctx context.Context) (Calibration, error) {
+	cal, err := measureCalibration(ctx)
+	if err != nil {
+		return Calibration{}, err
+	}
+	calibrationMu.Lock()
+	calibration = cal
+	calibrated = true
+	calibrationMu.Unlock()
+	return cal, nil
+}
+
+// currentCalibration returns the stored calibration, calibrating on first use
+func currentCalibration() Calibration {
+	calibrationMu.Lock()
+	defer calibrationMu.Unlock()
+	if !calibrated {
+		cal, err := measureCalibration(context.Background())
+		if err == nil {
+			calibration = cal
+			calibrated = true
+		}
+	}
+	return calibration
+}
+
+// distanceFactor is how much slower the distance selected by arguments is, compared to RGB
+func (c Calibration) distanceFactor(arguments int) float64 {
+	rate := c.RGBDistanceOpsPerSec
+	switch {
+	case IsBitSet(arguments, ArgumentCAM16UCS):
+		rate = c.CAM16UCSDistanceOpsPerSec
+	case IsBitSet(arguments, ArgumentCIEDE2000):
+		rate = c.CIEDE2000DistanceOpsPerSec
+	case IsBitSet(arguments, ArgumentFixedPointLAB) && (IsBitSet(arguments, ArgumentLAB) || IsBitSet(arguments, ArgumentLCh)):
+		rate = c.FixedLABDistanceOpsPerSec
+	case IsBitSet(arguments, ArgumentLAB), IsBitSet(arguments, ArgumentLCh):
+		rate = c.LABDistanceOpsPerSec
+	}
+	if rate <= 0 || c.RGBDistanceOpsPerSec <= 0 {
+		return 1
+	}
+	return c.RGBDistanceOpsPerSec / rate
+}
+
+// nsPer converts a rate (per second) to nanoseconds per item, zero if the rate is unknown
+func nsPer(rate float64) float64 {
+	if rate <= 0 {
+		return 0
+	}
+	return 1e9 / rate
+}
+
+// measureCalibration runs the benchmarks on synthetic images
+func measureCalibration(ctx context.Context) (Calibration, error) {
+	const srcSize, processedSize, distanceOps = 256, 64, 20000
+
+	// a smooth and a noisy image of the same size separate the per pixel and per byte cost of decoding
+	rnd := rand.New(rand.NewSource(1))
+	smooth := image.NewRGBA(image.Rect(0, 0, srcSize, srcSize))
+	noisy := image.NewRGBA(image.Rect(0, 0, srcSize, srcSize))
+	for y := 0; y < srcSize; y++ {
+		for x := 0; x < srcSize; x++ {
+			smooth.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+			noisy.Set(x, y, color.RGBA{R: uint8(x/64*60 + rnd.Intn(40)), G: uint8(y/64*50 + rnd.Intn(40)), B: uint8(rnd.Intn(256)), A: 255})
+		}
+	}
+
+	var cal Calibration
+
+	colors := make([]ColorItem, 64)
+	for i := range colors {
+		colors[i] = newColorItem16(uint32(rnd.Intn(0x10000)), uint32(rnd.Intn(0x10000)), uint32(rnd.Intn(0x10000)), 1)
+	}
+	for _, m := range []struct {
+		arguments int
+		rate      *float64
+	}{
+		{ArgumentDefault, &cal.RGBDistanceOpsPerSec},
+		{ArgumentLAB, &cal.LABDistanceOpsPerSec},
+		{ArgumentLAB | ArgumentFixedPointLAB, &cal.FixedLABDistanceOpsPerSec},
+		{ArgumentCIEDE2000, &cal.CIEDE2000DistanceOpsPerSec},
+		{ArgumentCAM16UCS, &cal.CAM16UCSDistanceOpsPerSec},
+	} {
+		if err := ctx.Err(); err != nil {
+			return Calibration{}, err
+		}
+		start := time.Now()
+		for i := 0; i < distanceOps; i++ {
+			distance(m.arguments, colors[i%len(colors)], colors[(i*7+1)%len(colors)])
+		}
+		*m.rate = distanceOps / time.Since(start).Seconds()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Calibration{}, err
+	}
+	smoothBytes, smoothNs := measureDecode(smooth)
+	noisyBytes, noisyNs := measureDecode(noisy)
+	perByte := 0.0
+	if noisyBytes > smoothBytes {
+		perByte = math.Max(0, (noisyNs-smoothNs)/float64(noisyBytes-smoothBytes))
+	}
+	perPixel := math.Max(0, smoothNs-perByte*float64(smoothBytes)) / (srcSize * srcSize)
+	cal.DecodeBytesPerSec = rateOf(perByte)
+	cal.DecodePixelsPerSec = rateOf(perPixel)
+
+	if err := ctx.Err(); err != nil {
+		return Calibration{}, err
+	}
+	opts := Options{K: DefaultK, Crop: CropNone, Size: processedSize}
+	start := time.Now()
+	img, _ := opts.prepare(noisy)
+	cal.ResizePixelsPerSec = srcSize * srcSize / time.Since(start).Seconds()
+
+	if err := ctx.Err(); err != nil {
+		return Calibration{}, err
+	}
+	start = time.Now()
+	allColors, _ := extractColorsAsArray(img)
+	kmeansColors(DefaultK, allColors, opts.arguments())
+	cal.ClusterPixelsPerSec = processedSize * processedSize / time.Since(start).Seconds()
+
+	return cal, nil
+}
+
+// rateOf converts nanoseconds per item to a rate (per second), zero if not significant
+func rateOf(ns float64) float64 {
+	if ns <= 0 {
+		return 0
+	}
+	return 1e9 / ns
+}
+
+// measureDecode encodes the image as JPEG and returns the encoded size and the fastest of a few decodes (ns)
+func measureDecode(img image.Image) (int, float64) {
+	var encoded bytes.Buffer
+	jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 90})
+
+	best := math.MaxFloat64
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		jpeg.Decode(bytes.NewReader(encoded.Bytes()))
+		best = math.Min(best, float64(time.Since(start).Nanoseconds()))
+	}
+	return encoded.Len(), best
+}
@@ -0,0 +1,177 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Surround is the relative luminance of the surround of the viewed image, as defined by CIECAM02/CAM16
+type Surround int
+
+const (
+	// SurroundAverage e.g. viewing surface colors (default)
+	SurroundAverage Surround = iota
+	// SurroundDim e.g. viewing a television
+	SurroundDim
+	// SurroundDark e.g. viewing a projector in a dark room
+	SurroundDark
+)
+
+// ViewingConditions defines the conditions used by the CAM16-UCS distance (see ArgumentCAM16UCS)
+type ViewingConditions struct {
+	// WhitePoint is the XYZ of the adopted white, scaled so Y=100
+	WhitePoint [3]float64
+
+	// AdaptingLuminance is the luminance of the adapting field in cd/m2 (La)
+	AdaptingLuminance float64
+
+	// BackgroundLuminance is the relative luminance of the background, 0-100 (Yb)
+	BackgroundLuminance float64
+
+	Surround Surround
+
+	// DiscountIlluminant assumes full adaptation to the white point
+	DiscountIlluminant bool
+}
+
+// cam16Env contains the values derived from the viewing conditions, shared by all conversions
+type cam16Env struct {
+	n, aw, nbb, ncb, c, nc, fl, flRoot, z float64
+	rgbD                                  [3]float64
+}
+
+// m16 converts XYZ to the CAM16 cone responses
+var m16 = [3][3]float64{
+	{0.401288, 0.650173, -0.051461},
+	{-0.250268, 1.204414, 0.045854},
+	{-0.002079, 0.048952, 0.953127},
+}
+
+var currentCAM16 atomic.Pointer[cam16Env]
+
+func init() {
+	SetViewingConditions(DefaultViewingConditions())
+}
+
+// DefaultViewingConditions returns the typical conditions for viewing sRGB content:
+// D65 white, adapting luminance 200/pi * 20% (~11.7 cd/m2), background 20 and average surround
+func DefaultViewingConditions() ViewingConditions {
+	return ViewingConditions{
+		WhitePoint:          [3]float64{95.047, 100.0, 108.883},
+		AdaptingLuminance:   200 / math.Pi * 0.2,
+		BackgroundLuminance: 20,
+		Surround:            SurroundAverage,
+	}
+}
+
+// SetViewingConditions sets the viewing conditions used by ArgumentCAM16UCS (process wide)
+func SetViewingConditions(vc ViewingConditions) {
+	currentCAM16.Store(newCAM16Env(vc))
+}
+
+// newCAM16Env calculates the values derived from the viewing conditions
+func newCAM16Env(vc ViewingConditions) *cam16Env {
+	f, c, nc := 1.0, 0.69, 1.0
+	switch vc.Surround {
+	case SurroundDim:
+		f, c, nc = 0.9, 0.59, 0.9
+	case SurroundDark:
+		f, c, nc = 0.8, 0.525, 0.8
+	}
+
+	la := vc.AdaptingLuminance
+	yw := vc.WhitePoint[1]
+	rgbW := mulVector(m16, vc.WhitePoint)
+
+	d := 1.0
+	if !vc.DiscountIlluminant {
+		d = math.Max(0, math.Min(1, f*(1-(1/3.6)*math.Exp((-la-42)/92))))
+	}
+
+	env := &cam16Env{c: c, nc: nc}
+	for i := 0; i < 3; i++ {
+		env.rgbD[i] = d*yw/rgbW[i] + 1 - d
+	}
+
+	k := 1 / (5*la + 1)
+	k4 := k * k * k * k
+	env.fl = k4*la + 0.1*(1-k4)*(1-k4)*math.Cbrt(5*la)
+	env.flRoot = math.Pow(env.fl, 0.25)
+	env.n = vc.BackgroundLuminance / yw
+	env.z = 1.48 + math.Sqrt(env.n)
+	env.nbb = 0.725 / math.Pow(env.n, 0.2)
+	env.ncb = env.nbb
+
+	var rgbAW [3]float64
+	for i := 0; i < 3; i++ {
+		rgbAW[i] = env.adapt(env.rgbD[i] * rgbW[i])
+	}
+	env.aw = (2*rgbAW[0] + rgbAW[1] + 0.05*rgbAW[2]) * env.nbb
+	return env
+}
+
+// adapt applies the post-adaptation non-linear response compression
+func (env *cam16Env) adapt(v float64) float64 {
+	x := math.Pow(env.fl*math.Abs(v)/100, 0.42)
+	return math.Copysign(400*x/(x+27.13), v)
+}
+
+// ucs converts XYZ (Y=100) to CAM16-UCS J', a', b'
+func (env *cam16Env) ucs(xyz [3]float64) [3]float64 {
+	rgb := mulVector(m16, xyz)
+	var ra [3]float64
+	for i := 0; i < 3; i++ {
+		ra[i] = env.adapt(env.rgbD[i] * rgb[i])
+	}
+
+	a := ra[0] - 12*ra[1]/11 + ra[2]/11
+	b := (ra[0] + ra[1] - 2*ra[2]) / 9
+	h := math.Atan2(b, a)
+
+	aChannel := (2*ra[0] + ra[1] + 0.05*ra[2]) * env.nbb
+	j := 100 * math.Pow(math.Max(aChannel, 0)/env.aw, env.c*env.z)
+
+	et := 0.25 * (math.Cos(h+2) + 3.8)
+	t := 0.0
+	if denominator := ra[0] + ra[1] + 21*ra[2]/20; denominator != 0 {
+		t = 50000.0 / 13 * env.nc * env.ncb * et * math.Hypot(a, b) / denominator
+	}
+	chroma := math.Pow(math.Max(t, 0), 0.9) * math.Sqrt(j/100) * math.Pow(1.64-math.Pow(0.29, env.n), 0.73)
+	m := chroma * env.flRoot
+
+	jPrime := 1.7 * j / (1 + 0.007*j)
+	mPrime := math.Log1p(0.0228*m) / 0.0228
+	return [3]float64{jPrime, mPrime * math.Cos(h), mPrime * math.Sin(h)}
+}
+
+// distanceCAM16UCS returns the Euclidean distance in CAM16-UCS using the current viewing conditions
+func distanceCAM16UCS(c ColorItem, p ColorItem) float64 {
+	env := currentCAM16.Load()
+	u1 := env.ucs(c.xyz100())
+	u2 := env.ucs(p.xyz100())
+	return math.Sqrt(sq(u1[0]-u2[0]) + sq(u1[1]-u2[1]) + sq(u1[2]-u2[2]))
+}
+
+// xyz100 returns the D65 XYZ of the color, scaled so white has Y=100
+func (c *ColorItem) xyz100() [3]float64 {
+	x, y, z := c.toColorful().Xyz()
+	return [3]float64{x * 100, y * 100, z * 100}
+}
+
+// mulVector multiplies a 3x3 matrix with a vector
+func mulVector(m [3][3]float64, v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+// sq returns v squared
+func sq(v float64) float64 {
+	return v * v
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"image"
+	"sort"
+	"sync"
+)
+
+// CapabilitySet describes what this build of the package supports
+type CapabilitySet struct {
+	// Algorithms are the clustering and seeding algorithms, see Algorithms
+	Algorithms []string
+
+	// ColorSpaces are the spaces distances can be measured in, see Metrics
+	ColorSpaces []string
+
+	// Profiles are the input color profiles that can be converted to sRGB
+	Profiles []string
+
+	// Decoders are the image formats with a decoder registered with the image package
+	Decoders []string
+
+	// Backends are the optional components compiled in (selected by build tags)
+	Backends []string
+
+	// Arguments maps the names of the Argument* constants to their values
+	Arguments map[string]int
+}
+
+var (
+	backends   []string
+	backendsMu sync.Mutex
+)
+
+// registerBackend is called from init of files guarded by build tags to announce an optional component
+func registerBackend(name string) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends = append(backends, name)
+}
+
+// formatMagic contains the magic bytes of formats that might have a decoder registered
+var formatMagic = map[string]string{
+	"bmp":  "BM",
+	"gif":  "GIF89a",
+	"jpeg": "\xff\xd8",
+	"png":  "\x89PNG\r\n\x1a\n",
+	"tiff": "II*\x00",
+	"webp": "RIFF\x00\x00\x00\x00WEBPVP8 ",
+}
+
+// Capabilities reports the algorithms, color spaces, decoders and optional backends supported by this binary,
+// so services and CLIs can adapt flags and validation to it
+func Capabilities() CapabilitySet {
+	backendsMu.Lock()
+	b := append([]string{}, backends...)
+	backendsMu.Unlock()
+	sort.Strings(b)
+
+	return CapabilitySet{
+		Algorithms:  Algorithms(),
+		ColorSpaces: Metrics(),
+		Profiles:    []string{ProfileSRGB.Name, ProfileAdobeRGB.Name, ProfileDisplayP3.Name, ProfileRec2020.Name, "icc"},
+		Decoders:    registeredDecoders(),
+		Backends:    b,
+		Arguments: map[string]int{
+			"ArgumentSeedRandom":           ArgumentSeedRandom,
+			"ArgumentAverageMean":          ArgumentAverageMean,
+			"ArgumentNoCropping":           ArgumentNoCropping,
+			"ArgumentLAB":                  ArgumentLAB,
+			"ArgumentCIEDE2000":            ArgumentCIEDE2000,
+			"ArgumentDebugImage":           ArgumentDebugImage,
+			"ArgumentLCh":                  ArgumentLCh,
+			"ArgumentCAM16UCS":             ArgumentCAM16UCS,
+			"ArgumentDeterministic":        ArgumentDeterministic,
+			"ArgumentOrientationInvariant": ArgumentOrientationInvariant,
+			"ArgumentCountWeighted":        ArgumentCountWeighted,
+			"ArgumentSaliency":             ArgumentSaliency,
+			"ArgumentEdgeForeground":       ArgumentEdgeForeground,
+			"ArgumentExcludeCodes":         ArgumentExcludeCodes,
+			"ArgumentPortable":             ArgumentPortable,
+			"ArgumentFixedPointLAB":        ArgumentFixedPointLAB,
+		},
+	}
+}
+
+// registeredDecoders probes the image package with the magic bytes of known formats,
+// image.ErrFormat means no decoder is registered for it
+func registeredDecoders() []string {
+	var decoders []string
+	for name, magic := range formatMagic {
+		data := append([]byte(magic), make([]byte, 64)...)
+		if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != image.ErrFormat {
+			decoders = append(decoders, name)
+		}
+	}
+	sort.Strings(decoders)
+	return decoders
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"math"
+)
+
+// CardColors are the background colors for placing an image on top of, derived from its dominant color
+type CardColors struct {
+	// Light is a light tint for light themes, Dark a deep shade for dark themes
+	Light, Dark ColorItem
+}
+
+// the lightness (LCh, 0-1) and largest chroma of the card background variants, the chroma is also at most
+// cardChromaFactor of the chroma of the dominant color
+const (
+	cardLightL       = 0.9
+	cardLightChroma  = 0.15
+	cardDarkL        = 0.2
+	cardDarkChroma   = 0.2
+	cardChromaFactor = 0.6
+)
+
+// CardBackground returns the card background colors of the image, like artwork cards of streaming services:
+// the hue of the dominant color (found with DefaultOptions) with a fixed lightness and reduced chroma,
+// so the image stands out and text on the card stays readable
+func CardBackground(img image.Image) (CardColors, error) {
+	res, err := KmeansWithOptions(img, DefaultOptions())
+	if err != nil {
+		return CardColors{}, err
+	}
+	return cardColors(res.Colors[0]), nil
+}
+
+// cardColors derives the card background colors from the dominant color
+func cardColors(dominant ColorItem) CardColors {
+	v := dominant.toLCh()
+	c := v.c * cardChromaFactor
+	return CardColors{
+		Light: colorItemFromLCh(lch{l: cardLightL, c: math.Min(c, cardLightChroma), h: v.h}, dominant.Cnt),
+		Dark:  colorItemFromLCh(lch{l: cardDarkL, c: math.Min(c, cardDarkChroma), h: v.h}, dominant.Cnt),
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// ChromaKey removes a green/blue screen background by hue, so it handles the lighting variations of real screens
+type ChromaKey struct {
+	// Key is the (8 bit) color of the screen
+	Key ColorRGB
+
+	// Tolerance is the largest hue difference in degrees (LCh) from the key color that is removed
+	Tolerance float64
+
+	// MinChroma is the share (0-1) of the chroma of the key color a pixel needs to be removed,
+	// (almost) gray pixels are never removed as their hue is not reliable
+	MinChroma float64
+
+	// Spill enables removing the tint of the screen reflected on the edges of the subject
+	Spill bool
+}
+
+var (
+	// ChromaKeyGreen is a chroma key for a typical green screen
+	ChromaKeyGreen = ChromaKey{Key: ColorRGB{R: 0x00, G: 0xb1, B: 0x40}, Tolerance: 30, MinChroma: 0.3, Spill: true}
+	// ChromaKeyBlue is a chroma key for a typical blue screen
+	ChromaKeyBlue = ChromaKey{Key: ColorRGB{R: 0x00, G: 0x47, B: 0xbb}, Tolerance: 30, MinChroma: 0.3, Spill: true}
+)
+
+// spillRadius is the distance in pixels from a removed pixel where the spill is suppressed
+const spillRadius = 2
+
+// apply makes the pixels matching the key transparent and suppresses the spill next to them.
+// It returns the number of pixels removed.
+func (k ChromaKey) apply(img draw.Image) int {
+	keyItem := ColorItem{Color: k.Key}
+	key := keyItem.toLCh()
+
+	b := img.Bounds()
+	keyed := make(map[[2]int]bool)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c, ignore := createColor(img.At(x, y))
+			if ignore {
+				continue
+			}
+			v := c.toLCh()
+			if v.c < k.MinChroma*key.c || hueDifference(v.h, key.h) > k.Tolerance {
+				continue
+			}
+			markPixel(x, y, &img)
+			keyed[[2]int{x, y}] = true
+		}
+	}
+
+	if k.Spill && len(keyed) > 0 {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if isPixelTransparent(x, y, &img) || !nearKeyed(keyed, x, y) {
+					continue
+				}
+				img.Set(x, y, k.despill(img.At(x, y)))
+			}
+		}
+	}
+	return len(keyed)
+}
+
+// despill limits the dominant channel of the key color to the larger of the other two channels
+func (k ChromaKey) despill(c color.Color) color.Color {
+	r, g, b, a := c.RGBA()
+	switch {
+	case k.Key.G >= k.Key.R && k.Key.G >= k.Key.B:
+		g = min(g, max(r, b))
+	case k.Key.B >= k.Key.R:
+		b = min(b, max(r, g))
+	default:
+		r = min(r, max(g, b))
+	}
+	return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+}
+
+// nearKeyed checks if a keyed pixel is within spillRadius
+func nearKeyed(keyed map[[2]int]bool, x, y int) bool {
+	for dy := -spillRadius; dy <= spillRadius; dy++ {
+		for dx := -spillRadius; dx <= spillRadius; dx++ {
+			if keyed[[2]int{x + dx, y + dy}] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hueDifference returns the difference between two hues in degrees (0-180)
+func hueDifference(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
@@ -0,0 +1,134 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+const (
+	// codeMaxChroma is the LAB chroma (0-100 scale) below which a pixel counts as black, white or gray
+	codeMaxChroma = 12.0
+	// codeMinContrast is the standard deviation of L (0-100 scale) a tile of a code has at least
+	codeMinContrast = 25.0
+	// codeMinTransitions is the share of neighboring pixel pairs crossing the mean lightness in a tile of a code,
+	// horizontally or vertically (barcodes only change in one direction)
+	codeMinTransitions = 0.2
+)
+
+// excludeCodes removes the parts of the image that look like a QR code or barcode: tiles with nearly all pixels
+// without chroma, high contrast and frequent changes between dark and light. Pixels without chroma in the
+// neighboring tiles are removed as well, as the code rarely lines up with the tiles.
+// It returns the number of pixels made transparent.
+func excludeCodes(img draw.Image) int {
+	b := img.Bounds()
+	tile := max(4, min(b.Dx(), b.Dy())/10)
+
+	var codeTiles []image.Rectangle
+	for ty := b.Min.Y; ty < b.Max.Y; ty += tile {
+		for tx := b.Min.X; tx < b.Max.X; tx += tile {
+			r := image.Rect(tx, ty, tx+tile, ty+tile).Intersect(b)
+			if isCodeTile(img, r) {
+				codeTiles = append(codeTiles, r)
+			}
+		}
+	}
+
+	removed := 0
+	for _, r := range codeTiles {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if !isPixelTransparent(x, y, &img) {
+					markPixel(x, y, &img)
+					removed++
+				}
+			}
+		}
+	}
+	for _, r := range codeTiles {
+		around := r.Inset(-tile).Intersect(b)
+		for y := around.Min.Y; y < around.Max.Y; y++ {
+			for x := around.Min.X; x < around.Max.X; x++ {
+				c, ignore := createColor(img.At(x, y))
+				if !ignore && chroma(c) < codeMaxChroma {
+					markPixel(x, y, &img)
+					removed++
+				}
+			}
+		}
+	}
+	return removed
+}
+
+// chroma returns the LAB chroma (0-100 scale) of the color
+func chroma(c ColorItem) float64 {
+	_, a, b := c.toColorful().Lab()
+	return math.Hypot(a*100, b*100)
+}
+
+// isCodeTile checks if the part r of the image looks like a part of a QR code or barcode
+func isCodeTile(img image.Image, r image.Rectangle) bool {
+	w, h := r.Dx(), r.Dy()
+	lightness := make([]float64, w*h)
+	opaque := make([]bool, w*h)
+	n, gray := 0, 0
+	var sum, sum2 float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c, ignore := createColor(img.At(r.Min.X+x, r.Min.Y+y))
+			if ignore {
+				continue
+			}
+			l, _, _ := c.toColorful().Lab()
+			l *= 100
+			if chroma(c) < codeMaxChroma {
+				gray++
+			}
+			lightness[y*w+x] = l
+			opaque[y*w+x] = true
+			sum += l
+			sum2 += l * l
+			n++
+		}
+	}
+	// most of the tile has to be there and be without color
+	if n < w*h/2 || float64(gray) < 0.9*float64(n) {
+		return false
+	}
+	meanL := sum / float64(n)
+	if math.Sqrt(math.Max(sum2/float64(n)-meanL*meanL, 0)) < codeMinContrast {
+		return false
+	}
+
+	// pairs and transitions, horizontally [0] and vertically [1]
+	var pairs, transitions [2]int
+	count := func(dir, i, j int) {
+		if !opaque[i] || !opaque[j] {
+			return
+		}
+		pairs[dir]++
+		if (lightness[i] >= meanL) != (lightness[j] >= meanL) {
+			transitions[dir]++
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x > 0 {
+				count(0, y*w+x-1, y*w+x)
+			}
+			if y > 0 {
+				count(1, (y-1)*w+x, y*w+x)
+			}
+		}
+	}
+	for dir := range pairs {
+		if pairs[dir] > 0 && float64(transitions[dir]) >= codeMinTransitions*float64(pairs[dir]) {
+			return true
+		}
+	}
+	return false
+}
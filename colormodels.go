@@ -0,0 +1,73 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+)
+
+// LAB is a CIE L*a*b* color (D65 white point), on the 0-100 scale used for the LAB distances and bins
+type LAB struct {
+	L, A, B float64
+}
+
+// HSL is a color as hue (degrees, 0-360), saturation and lightness (0-1)
+type HSL struct {
+	H, S, L float64
+}
+
+// ColorModels is a color in several color models at once, converted by the library the same way it converts
+// the colors when clustering
+type ColorModels struct {
+	// RGB has 8 bits per channel (0-0xff)
+	RGB ColorRGB
+
+	// Hex is the color as CSS hex string, e.g. "#1A6B3C"
+	Hex string
+
+	LAB LAB
+	HSL HSL
+}
+
+// LAB returns the color as CIE L*a*b*, from the 16 bit color like the LAB distances (ArgumentLAB)
+func (c *ColorItem) LAB() LAB {
+	l, a, b := c.toColorful().Lab()
+	return LAB{L: l * 100, A: a * 100, B: b * 100}
+}
+
+// HSL returns the color as hue, saturation and lightness, from the 16 bit color
+func (c *ColorItem) HSL() HSL {
+	h, s, l := c.toColorful().Hsl()
+	return HSL{H: h, S: s, L: l}
+}
+
+// Models returns the color in all color models
+func (c *ColorItem) Models() ColorModels {
+	return ColorModels{RGB: c.Color, Hex: "#" + c.AsString(), LAB: c.LAB(), HSL: c.HSL()}
+}
+
+// ColorModels returns the colors in all color models, in the order of Colors
+func (r Result) ColorModels() []ColorModels {
+	models := make([]ColorModels, len(r.Colors))
+	for i := range r.Colors {
+		models[i] = r.Colors[i].Models()
+	}
+	return models
+}
+
+// Palette converts the colors into a color.Palette in the same order (most dominant first for the colors of a
+// result), e.g. for image.NewPaletted, draw.FloydSteinberg or GIF encoding
+func Palette(colors []ColorItem) color.Palette {
+	palette := make(color.Palette, len(colors))
+	for i, c := range colors {
+		palette[i] = color.RGBA{R: uint8(c.Color.R), G: uint8(c.Color.G), B: uint8(c.Color.B), A: 0xff}
+	}
+	return palette
+}
+
+// Palette returns the colors as color.Palette, most dominant first
+func (r Result) Palette() color.Palette {
+	return Palette(r.Colors)
+}
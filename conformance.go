@@ -0,0 +1,169 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+func init() {
+	registerBackend("reference-vectors")
+}
+
+// ReferenceVector is an input of the extraction with its expected colors, for verifying that an implementation in
+// another language gives the same colors as this one. The options are the plain k-means: no cropping, resizing or
+// masks, the median of each cluster in the color space given.
+type ReferenceVector struct {
+	Name string `json:"name"`
+
+	// Width and Height are the size of the image, Pixels its rows of 3 byte RGB pixels (PixelFormatRGB), base64
+	// encoded in JSON
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Pixels []byte `json:"pixels"`
+
+	// K is the number of colors asked for, Space the color space of the clustering (see SpaceMode.String)
+	K     int    `json:"k"`
+	Space string `json:"space"`
+
+	// Expected are the colors, most frequent first
+	Expected []ReferenceColor `json:"expected"`
+
+	// DeltaE is the largest CIEDE2000 delta E (0-100 scale) of a color to the expected one, Percentage the largest
+	// difference of its percentage
+	DeltaE     float64 `json:"deltaE"`
+	Percentage float64 `json:"percentage"`
+}
+
+// ReferenceColor is an expected color of a ReferenceVector
+type ReferenceColor struct {
+	// Hex is the color as RRGGBB
+	Hex        string  `json:"hex"`
+	Percentage float64 `json:"percentage"`
+}
+
+// ReferenceVectorsVersion is the version of the reference vectors, it changes when a vector is added or changed
+const ReferenceVectorsVersion = 1
+
+// referenceDeltaE and referencePercentage are the tolerances of the reference vectors
+const (
+	referenceDeltaE     = 1.0
+	referencePercentage = 0.5
+)
+
+// ReferenceVectors returns the reference vectors: solid and noisy bands of colors that any k-means implementation
+// clusters the same way, whatever its seeding
+func ReferenceVectors() []ReferenceVector {
+	return []ReferenceVector{
+		referenceBands("two-colors", 2, SpaceRGB, 0, 16, []string{"C83214", "1E50B4"}, []int{8, 8},
+			[]ReferenceColor{{"C83214", 50}, {"1E50B4", 50}}),
+		referenceBands("fewer-colors-than-k", 5, SpaceRGB, 0, 16, []string{"C83214", "1E50B4"}, []int{12, 4},
+			[]ReferenceColor{{"C83214", 75}, {"1E50B4", 25}}),
+		referenceBands("noisy-bands", 3, SpaceRGB, 6, 20, []string{"C83214", "1E50B4", "F0DC3C"}, []int{10, 6, 4},
+			[]ReferenceColor{{"C83214", 50}, {"1F50B4", 30}, {"EEDC3B", 20}}),
+		referenceBands("unequal-shares", 3, SpaceRGB, 4, 10, []string{"3C963C", "F0F0F0", "141414"}, []int{14, 4, 2},
+			[]ReferenceColor{{"3C963D", 70}, {"F0F0F1", 20}, {"131515", 10}}),
+		referenceBands("gray-levels", 4, SpaceRGB, 4, 10, []string{"202020", "606060", "A0A0A0", "E0E0E0"},
+			[]int{8, 6, 4, 2}, []ReferenceColor{{"202021", 40}, {"606061", 30}, {"A0A0A1", 20}, {"DFE1E1", 10}}),
+		referenceBands("lab-space", 3, SpaceLAB, 4, 20, []string{"B43C78", "3CB4A0", "503C28"}, []int{9, 7, 4},
+			[]ReferenceColor{{"B43C79", 45}, {"3CB4A0", 35}, {"503C29", 20}}),
+	}
+}
+
+// referenceBands creates a vector of horizontal bands of the colors (RRGGBB), with the given number of rows each,
+// adding a deterministic noise of up to ±noise to each channel
+func referenceBands(name string, k int, space SpaceMode, noise, width int, bands []string, rows []int, expected []ReferenceColor) ReferenceVector {
+	v := ReferenceVector{Name: name, Width: width, K: k, Space: space.String(), Expected: expected,
+		DeltaE: referenceDeltaE, Percentage: referencePercentage}
+	state := uint32(2463534242)
+	for i, n := range rows {
+		v.Height += n
+		rgb, _ := strconv.ParseUint(bands[i], 16, 32)
+		for p := 0; p < n*width; p++ {
+			for shift := 16; shift >= 0; shift -= 8 {
+				c := int(rgb>>shift) & 0xff
+				if noise > 0 {
+					// xorshift32, so the noise is the same everywhere
+					state ^= state << 13
+					state ^= state >> 17
+					state ^= state << 5
+					c += int(state%uint32(2*noise+1)) - noise
+				}
+				v.Pixels = append(v.Pixels, uint8(min(max(c, 0), 255)))
+			}
+		}
+	}
+	return v
+}
+
+// Options returns the options giving the expected colors of the vector
+func (v ReferenceVector) Options() (Options, error) {
+	space, err := ParseSpaceMode(v.Space)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{K: v.K, Space: space, Crop: CropNone, Size: OriginalSize}, nil
+}
+
+// Verify returns an error if the colors (e.g. Result.Colors) are not the expected colors of the vector, within its
+// tolerances
+func (v ReferenceVector) Verify(colors []ColorItem) error {
+	if len(colors) != len(v.Expected) {
+		return fmt.Errorf("Failed, %s: expected %d colors, got %d", v.Name, len(v.Expected), len(colors))
+	}
+	for i, e := range v.Expected {
+		rgb, err := strconv.ParseUint(e.Hex, 16, 32)
+		if err != nil || len(e.Hex) != 6 {
+			return fmt.Errorf("Failed, %s: invalid expected color %q", v.Name, e.Hex)
+		}
+		expected := ColorItem{Color: ColorRGB{R: uint32(rgb >> 16), G: uint32(rgb>>8) & 0xff, B: uint32(rgb) & 0xff}}
+		if d := distanceCIEDE2000(colors[i], expected) * 100; d > v.DeltaE {
+			return fmt.Errorf("Failed, %s: color %d is #%s, delta E %.2f from the expected #%s", v.Name, i+1,
+				colors[i].AsString(), d, e.Hex)
+		}
+		if d := colors[i].Percentage - e.Percentage; d > v.Percentage || -d > v.Percentage {
+			return fmt.Errorf("Failed, %s: color %d is %.2f%%, expected %.2f%%", v.Name, i+1, colors[i].Percentage,
+				e.Percentage)
+		}
+	}
+	return nil
+}
+
+// VerifyReferenceVectors runs the reference vectors through KmeansRaw and returns an error for the first vector
+// not giving the expected colors
+func VerifyReferenceVectors() error {
+	for _, v := range ReferenceVectors() {
+		opts, err := v.Options()
+		if err != nil {
+			return err
+		}
+		res, err := KmeansRaw(v.Pixels, v.Width, v.Height, v.Width*3, PixelFormatRGB, opts)
+		if err != nil {
+			return fmt.Errorf("Failed, %s: %v", v.Name, err)
+		}
+		if err := v.Verify(res.Colors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteReferenceVectors writes the reference vectors as JSON, for the test suites of other implementations
+func WriteReferenceVectors(w io.Writer) error {
+	data, err := json.MarshalIndent(struct {
+		Version int               `json:"version"`
+		Vectors []ReferenceVector `json:"vectors"`
+	}{ReferenceVectorsVersion, ReferenceVectors()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyReferenceVectors(t *testing.T) {
+	if err := VerifyReferenceVectors(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReferenceVectorVerifyRejects(t *testing.T) {
+	v := ReferenceVectors()[0]
+	colors := []ColorItem{
+		{Color: ColorRGB{R: 0xc8, G: 0x32, B: 0x14}, Percentage: 50},
+		{Color: ColorRGB{R: 0x1e, G: 0x50, B: 0xb4}, Percentage: 50},
+	}
+	if err := v.Verify(colors); err != nil {
+		t.Fatalf("Expected the expected colors to verify: %v", err)
+	}
+	for name, modify := range map[string]func(c []ColorItem) []ColorItem{
+		"missing color":  func(c []ColorItem) []ColorItem { return c[:1] },
+		"other color":    func(c []ColorItem) []ColorItem { c[1].Color.G = 0xa0; return c },
+		"other share":    func(c []ColorItem) []ColorItem { c[0].Percentage, c[1].Percentage = 60, 40; return c },
+		"swapped colors": func(c []ColorItem) []ColorItem { c[0], c[1] = c[1], c[0]; return c },
+	} {
+		if err := v.Verify(modify(append([]ColorItem{}, colors...))); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWriteReferenceVectors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReferenceVectors(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Version int               `json:"version"`
+		Vectors []ReferenceVector `json:"vectors"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Version != ReferenceVectorsVersion {
+		t.Errorf("Expected version %d, got %d", ReferenceVectorsVersion, decoded.Version)
+	}
+	if want := ReferenceVectors(); !reflect.DeepEqual(decoded.Vectors, want) {
+		t.Errorf("Expected the written vectors to decode to ReferenceVectors()")
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+// Convergence describes how the k-means clustering of a result went, e.g. to tell an unstable palette (cut short at
+// the maximum number of iterations, or with a large inertia) from a settled one
+type Convergence struct {
+	// Iterations is the number of k-means iterations run, 0 if there were no more colors than K to cluster
+	Iterations int
+
+	// Converged is set if the clustering stopped because no color changed cluster, not set if it was terminated at
+	// the maximum number of iterations
+	Converged bool
+
+	// Inertia is the sum of the squared distances of the clustered colors to their closest centroid, weighted by
+	// their counts, in the color space of the clustering (see ClusterSpread.AvgDistance). It is taken from the
+	// assignment of the last iteration, so if not Converged it is of the centroids before their last update.
+	Inertia float64
+}
+
+// inertia returns the sum of the squared distances of the colors to their closest centroid, weighted by their counts.
+// The k-means loop sums it while assigning the colors, this is for the few colors of flat art and gray levels.
+func inertia(colors, centroids []ColorItem, arguments int) float64 {
+	if len(centroids) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, c := range colors {
+		_, d := closest(arguments, c, centroids)
+		sum += float64(c.Cnt) * squaredDistance(arguments, d)
+	}
+	return sum
+}
+
+// squaredDistance squares a distance of the clustering, the RGB distance already being squared
+func squaredDistance(arguments int, d float64) float64 {
+	if arguments&spaceArguments == 0 {
+		return d
+	}
+	return d * d
+}
@@ -0,0 +1,37 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestInertiaOfLoopMatchesFinalCentroids(t *testing.T) {
+	img := framedImage(60, color.RGBA{R: 0xff, A: 0xff}, color.RGBA{G: 0xc0, A: 0xff}, color.RGBA{B: 0xff, A: 0xff})
+	for y := 0; y < 60; y++ {
+		for x := 0; x < 60; x += 7 {
+			img.Set(x, y, color.RGBA{R: uint8(4 * x), G: uint8(4 * y), B: 0x80, A: 0xff})
+		}
+	}
+	for _, arguments := range []int{ArgumentDefault, ArgumentLAB, ArgumentCIEDE2000} {
+		opts := DefaultOptions()
+		opts.K = 4
+		opts.Arguments = arguments | ArgumentDeterministic | ArgumentNoCropping
+		colors := opts.colors(img)
+		centroids, convergence, err := clusterColors(opts.k(), colors, opts.arguments())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !convergence.Converged {
+			t.Fatalf("Expected the clustering to converge for arguments %d", arguments)
+		}
+		want := inertia(colors, centroids, opts.arguments())
+		if want == 0 || math.Abs(convergence.Inertia-want) > want*1e-9 {
+			t.Errorf("Expected inertia %v for arguments %d, got %v", want, arguments, convergence.Inertia)
+		}
+	}
+}
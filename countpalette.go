@@ -0,0 +1,38 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// CountByPalette assigns each pixel of the image to the closest (CIEDE2000) color of the palette, e.g. a fixed
+// corporate palette, and returns the number of pixels per palette color. No clustering, cropping, resizing or
+// masking is done, pixels with alpha below DefaultAlphaThreshold are not counted.
+func CountByPalette(img image.Image, palette []color.Color) ([]int, error) {
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("Failed, empty palette")
+	}
+	references := make([]ColorItem, len(palette))
+	for i, c := range palette {
+		references[i], _ = createColor(c)
+	}
+
+	img, _ = applyAlphaThreshold(img, DefaultAlphaThreshold)
+	colors, _ := extractColorsAsArray(img)
+	counts := make([]int, len(palette))
+	for _, c := range colors {
+		closest, best := 0, distanceCIEDE2000(c, references[0])
+		for i := 1; i < len(references); i++ {
+			if d := distanceCIEDE2000(c, references[i]); d < best {
+				closest, best = i, d
+			}
+		}
+		counts[closest] += c.Cnt
+	}
+	return counts, nil
+}
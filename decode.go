@@ -0,0 +1,76 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// KmeansFromReader decodes the image and finds its colors. Any format registered with the image package is decoded,
+// JPEG and PNG always are, others by importing their decoder (e.g. image/gif or golang.org/x/image/webp).
+// The EXIF orientation is applied before the image is cropped, see ExifOrientation.
+func KmeansFromReader(r io.Reader, opts Options) (Result, error) {
+	if r == nil {
+		return Result{}, fmt.Errorf("Failed, no image to decode")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Result{}, fmt.Errorf("Failed reading image: %v", err)
+	}
+	return KmeansFromBytes(data, opts)
+}
+
+// KmeansFromFile decodes the image file and finds its colors, see KmeansFromReader
+func KmeansFromFile(path string, opts Options) (Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+	return KmeansFromReader(f, opts)
+}
+
+// KmeansFromBytes decodes the encoded image and finds its colors, see KmeansFromReader
+func KmeansFromBytes(data []byte, opts Options) (Result, error) {
+	img, err := decode(data)
+	if err != nil {
+		return Result{}, err
+	}
+	if opts.AdaptExifWhiteBalance && opts.WhiteBalance == nil {
+		if wb, ok := ExifWhiteBalance(data); ok && wb.exifAdaptable() {
+			opts.WhiteBalance = &wb
+		}
+	}
+	return KmeansWithOptions(img, opts)
+}
+
+// DominantColorFromBytes decodes the encoded image as KmeansFromBytes and returns its most prominent color, see
+// DominantColor
+func DominantColorFromBytes(data []byte, options ...Options) (ColorItem, error) {
+	img, err := decode(data)
+	if err != nil {
+		return ColorItem{}, err
+	}
+	return DominantColor(img, options...)
+}
+
+// decode decodes the image and applies its EXIF orientation, telling an unknown format apart from a broken image
+func decode(data []byte) (image.Image, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err == image.ErrFormat {
+		return nil, fmt.Errorf("Failed, unknown image format (is its decoder imported?): %v", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed decoding %s image: %v", format, err)
+	}
+	if o := ExifOrientation(data); o != OrientationNormal {
+		img = Orient(img, o)
+	}
+	return img, nil
+}
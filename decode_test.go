@@ -0,0 +1,147 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// exifRotate90 is TIFF data with the orientation tag set to OrientationRotate90
+var exifRotate90 = []byte{
+	'M', 'M', 0, 42, 0, 0, 0, 8, // header, first IFD at 8
+	0, 1, // 1 entry
+	0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(OrientationRotate90), 0, 0, // orientation, SHORT
+	0, 0, 0, 0, // no next IFD
+}
+
+// wideImage returns a 20x10 image, red on the left and blue on the right
+func wideImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			c := color.RGBA{R: 0xff, A: 0xff}
+			if x >= 10 {
+				c = color.RGBA{B: 0xff, A: 0xff}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestKmeansFromBytes(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Arguments = ArgumentDeterministic | ArgumentNoCropping
+	res, err := KmeansFromBytes(encodePNG(t, wideImage()), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := KmeansWithOptions(wideImage(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameColors(t, res.Colors, want.Colors)
+
+	path := filepath.Join(t.TempDir(), "wide.png")
+	if err := os.WriteFile(path, encodePNG(t, wideImage()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	res, err = KmeansFromFile(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameColors(t, res.Colors, want.Colors)
+}
+
+func TestDominantColorFromBytes(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Arguments = ArgumentNoCropping
+	got, err := DominantColorFromBytes(encodePNG(t, wideImage()), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := DominantColor(wideImage(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if _, err := DominantColorFromBytes([]byte("not an image")); err == nil || !strings.Contains(err.Error(), "unknown image format") {
+		t.Errorf("Expected an unknown format error, got %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := KmeansFromBytes([]byte("not an image"), DefaultOptions()); err == nil || !strings.Contains(err.Error(), "unknown image format") {
+		t.Errorf("Expected an unknown format error, got %v", err)
+	}
+	data := encodePNG(t, wideImage())
+	if _, err := KmeansFromBytes(data[:len(data)/2], DefaultOptions()); err == nil || !strings.Contains(err.Error(), "decoding png") {
+		t.Errorf("Expected a broken png error, got %v", err)
+	}
+	if _, err := KmeansFromReader(nil, DefaultOptions()); err == nil {
+		t.Errorf("Expected an error without reader")
+	}
+	if _, err := KmeansFromFile(filepath.Join(t.TempDir(), "missing.png"), DefaultOptions()); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestDecodeAppliesExifOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, wideImage(), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	// the APP1 segment goes right after the start of image marker
+	app1 := append([]byte("Exif\x00\x00"), exifRotate90...)
+	segment := append([]byte{0xff, 0xe1, 0, 0}, app1...)
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(app1)+2))
+	jpg := append(append([]byte{0xff, 0xd8}, segment...), buf.Bytes()[2:]...)
+
+	// the eXIf chunk goes after the IHDR chunk, which ends at 33
+	data := encodePNG(t, wideImage())
+	chunk := make([]byte, 8, 12+len(exifRotate90))
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(exifRotate90)))
+	copy(chunk[4:8], "eXIf")
+	chunk = append(chunk, exifRotate90...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	pngData := append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+
+	for name, data := range map[string][]byte{"jpeg": jpg, "png": pngData} {
+		if o := ExifOrientation(data); o != OrientationRotate90 {
+			t.Errorf("Expected the %s orientation %d, got %d", name, OrientationRotate90, o)
+		}
+		img, err := decode(data)
+		if err != nil {
+			t.Fatalf("Failed decoding %s: %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != 10 || b.Dy() != 20 {
+			t.Errorf("Expected the %s image to be rotated to 10x20, got %v", name, b)
+		}
+		// rotated clockwise the left (red) half is on top
+		if r, _, b, _ := img.At(img.Bounds().Min.X+5, img.Bounds().Min.Y+2).RGBA(); r < 0x8000 || b > 0x8000 {
+			t.Errorf("Expected red on top of the rotated %s image", name)
+		}
+	}
+}
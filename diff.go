@@ -0,0 +1,130 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// diffMatchDeltaE is the largest CIEDE2000 delta E (0-100 scale) between two colors considered the same color
+	diffMatchDeltaE = 15.0
+	// diffColorDeltaE is the delta E above which a matched color is reported as changed
+	diffColorDeltaE = 2.0
+	// diffSharePoints is the difference in percentage points above which a matched color is reported as changed
+	diffSharePoints = 5.0
+)
+
+// PaletteDiff lists how the prominent colors changed from one image to another
+type PaletteDiff struct {
+	Added, Removed, Changed []ColorChange
+}
+
+// ColorChange is a color that was added, removed or changed. Before is unset for added colors, After for removed ones.
+type ColorChange struct {
+	Before, After ColorItem
+
+	// BeforePercent and AfterPercent are the shares of the pixels (0-100)
+	BeforePercent, AfterPercent float64
+
+	// DeltaE is the CIEDE2000 difference (0-100 scale) between Before and After, for changed colors
+	DeltaE float64
+}
+
+// DiffSummary compares the prominent colors (found with DefaultOptions) of two images, e.g. an original and an
+// edited upload. The images do not need to be aligned or have the same size.
+func DiffSummary(a, b image.Image) (PaletteDiff, error) {
+	ra, err := KmeansWithOptions(a, DefaultOptions())
+	if err != nil {
+		return PaletteDiff{}, err
+	}
+	rb, err := KmeansWithOptions(b, DefaultOptions())
+	if err != nil {
+		return PaletteDiff{}, err
+	}
+	return DiffResults(ra, rb), nil
+}
+
+// DiffResults compares the colors of two results, matching each color with the closest one of the other result
+func DiffResults(a, b Result) PaletteDiff {
+	percentA, percentB := percentages(a.Colors), percentages(b.Colors)
+
+	type pair struct {
+		i, j int
+		d    float64
+	}
+	var pairs []pair
+	for i := range a.Colors {
+		for j := range b.Colors {
+			if d := distanceCIEDE2000(a.Colors[i], b.Colors[j]) * 100; d <= diffMatchDeltaE {
+				pairs = append(pairs, pair{i: i, j: j, d: d})
+			}
+		}
+	}
+	sort.Slice(pairs, func(x, y int) bool { return pairs[x].d < pairs[y].d })
+
+	var diff PaletteDiff
+	matchedA, matchedB := make(map[int]bool), make(map[int]bool)
+	for _, p := range pairs {
+		if matchedA[p.i] || matchedB[p.j] {
+			continue
+		}
+		matchedA[p.i], matchedB[p.j] = true, true
+		if p.d > diffColorDeltaE || math.Abs(percentA[p.i]-percentB[p.j]) > diffSharePoints {
+			diff.Changed = append(diff.Changed, ColorChange{
+				Before: a.Colors[p.i], After: b.Colors[p.j],
+				BeforePercent: percentA[p.i], AfterPercent: percentB[p.j],
+				DeltaE: p.d,
+			})
+		}
+	}
+	for i, c := range a.Colors {
+		if !matchedA[i] {
+			diff.Removed = append(diff.Removed, ColorChange{Before: c, BeforePercent: percentA[i]})
+		}
+	}
+	for j, c := range b.Colors {
+		if !matchedB[j] {
+			diff.Added = append(diff.Added, ColorChange{After: c, AfterPercent: percentB[j]})
+		}
+	}
+	return diff
+}
+
+// String formats the diff for changelogs, one line per color, e.g. "+ #1A6B3C 46%", "- #FFFFFF 20%" or
+// "~ #1A6B3C 46% -> #1E7040 40% (ΔE 2.4)". An empty string means no differences.
+func (d PaletteDiff) String() string {
+	var lines []string
+	for _, c := range d.Added {
+		lines = append(lines, fmt.Sprintf("+ #%s %.0f%%", c.After.AsString(), c.AfterPercent))
+	}
+	for _, c := range d.Removed {
+		lines = append(lines, fmt.Sprintf("- #%s %.0f%%", c.Before.AsString(), c.BeforePercent))
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, fmt.Sprintf("~ #%s %.0f%% -> #%s %.0f%% (ΔE %.1f)",
+			c.Before.AsString(), c.BeforePercent, c.After.AsString(), c.AfterPercent, c.DeltaE))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// percentages returns the share (0-100) of each color
+func percentages(colors []ColorItem) []float64 {
+	total := 0
+	for _, c := range colors {
+		total += c.Cnt
+	}
+	p := make([]float64, len(colors))
+	for i, c := range colors {
+		if total > 0 {
+			p[i] = float64(c.Cnt) / float64(total) * 100
+		}
+	}
+	return p
+}
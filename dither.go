@@ -0,0 +1,95 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// ditherMaxColors is the most colors in a 3x3 window of a dither pattern, error diffusion mixes up to 3 palette colors
+const ditherMaxColors = 3
+
+// ditherMinTransitions is the least number of the 12 neighbour pairs in a 3x3 window with different colors for the
+// window to count as dithered: a checkerboard has 12, a one pixel line 6 and the edge of a shape about 3
+const ditherMinTransitions = 8
+
+// DitheredProportion returns the share (0-1) of the pixels that are part of a dither pattern (ordered or error
+// diffusion), e.g. to check if Options.Undither is needed for screenshots scaled down with dithering
+func DitheredProportion(img image.Image) float64 {
+	b := img.Bounds()
+	if b.Empty() {
+		return 0
+	}
+	_, dithered := undither(img)
+	return float64(dithered) / float64(b.Dx()*b.Dy())
+}
+
+// undither returns a copy of the image where the pixels of dither patterns are replaced by the blend of the 4x4 pixels
+// around them, as they are perceived, and the number of pixels replaced. A pixel is part of a dither pattern if its
+// 3x3 window has two to ditherMaxColors colors alternating at least ditherMinTransitions times. The blend is of an
+// even sized window, so it is exact for the ordered (Bayer) patterns, which repeat every 2 or 4 pixels.
+func undither(img image.Image) (image.Image, int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	px := make([]color.RGBA64, w*h)
+	forEachPixel(img, func(x, y int, r, g, bl, a uint32) {
+		px[(y-b.Min.Y)*w+x-b.Min.X] = color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(bl), A: uint16(a)}
+	})
+
+	out := image.NewRGBA64(b)
+	draw.Draw(out, b, img, b.Min, draw.Src)
+	dithered := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !isDitherWindow(px, w, h, x, y) {
+				continue
+			}
+			var r, g, bl, a, n uint32
+			for yy := max(y-1, 0); yy <= min(y+2, h-1); yy++ {
+				for xx := max(x-1, 0); xx <= min(x+2, w-1); xx++ {
+					c := px[yy*w+xx]
+					r, g, bl, a, n = r+uint32(c.R), g+uint32(c.G), bl+uint32(c.B), a+uint32(c.A), n+1
+				}
+			}
+			out.SetRGBA64(b.Min.X+x, b.Min.Y+y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+			dithered++
+		}
+	}
+	return out, dithered
+}
+
+// isDitherWindow checks the 3x3 window around x, y of the w x h image, repeating the pixels at the edges
+func isDitherWindow(px []color.RGBA64, w, h, x, y int) bool {
+	at := func(x, y int) color.RGBA64 {
+		return px[min(max(y, 0), h-1)*w+min(max(x, 0), w-1)]
+	}
+	var colors [ditherMaxColors]color.RGBA64
+	numColors, transitions := 0, 0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			c := at(x+dx, y+dy)
+			known := false
+			for _, k := range colors[:numColors] {
+				known = known || k == c
+			}
+			if !known {
+				if numColors == ditherMaxColors {
+					return false
+				}
+				colors[numColors] = c
+				numColors++
+			}
+			if dx < 1 && c != at(x+dx+1, y+dy) {
+				transitions++
+			}
+			if dy < 1 && c != at(x+dx, y+dy+1) {
+				transitions++
+			}
+		}
+	}
+	return numColors >= 2 && transitions >= ditherMinTransitions
+}
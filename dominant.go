@@ -0,0 +1,177 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// dominantBits is the number of bits per channel of the histogram used by DominantColor
+const dominantBits = 4
+
+// DominantK and DominantMergeDeltaE are the settings of DominantColorClustered: enough clusters to separate the
+// subject, the background and their shades in most images, and a delta E merging the shades of one color
+const (
+	DominantK           = 6
+	DominantMergeDeltaE = 10.0
+)
+
+// dominantBin sums the pixels (16 bit channels) of a histogram bin
+type dominantBin struct {
+	cnt     int
+	r, g, b uint64
+}
+
+// DominantColor returns the most prominent color without running K-means: the mode of a coarse RGB histogram
+// (smoothed with the neighboring bins, so similar shades count as one color), averaged over the pixels of those bins.
+// The image is cropped and masked as in KmeansWithOptions with the options, DefaultOptions if none are given, but
+// point sampled to about Size instead of resized, so it is at least 10x faster. K, Seed, Average, Space, Resizer and
+// LabBinSize are not used.
+func DominantColor(orgimg image.Image, options ...Options) (ColorItem, error) {
+	opts, err := dominantOptions("DominantColor", options)
+	if err != nil {
+		return ColorItem{}, err
+	}
+	if err := opts.validate(orgimg); err != nil {
+		return ColorItem{}, err
+	}
+	// point sampling the region and crop to about the size replaces the (slow) resizing, the histogram does not need
+	// the exact size
+	if opts.Size != OriginalSize {
+		area, arguments := orgimg.Bounds(), opts.arguments()
+		if !opts.Region.Empty() {
+			area = opts.Region.Intersect(area)
+			arguments |= ArgumentNoCropping
+		}
+		area = cropBounds(arguments, area)
+		if stride := min(area.Dx(), area.Dy()) / int(opts.Size); stride > 1 {
+			orgimg = subsample(orgimg, area, stride)
+			opts.PixelMasks = scalePixelMasks(opts.PixelMasks, area.Min, stride)
+			opts.Region, opts.Crop, opts.Size = image.Rectangle{}, CropNone, OriginalSize
+		}
+	}
+	img, _ := opts.prepare(orgimg)
+
+	const side = 1 << dominantBits
+	const shift = 16 - dominantBits
+	var hist [side * side * side]dominantBin
+	index := func(r, g, b int) int { return (r*side+g)*side + b }
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c, ignore := createColor(img.At(x, y))
+			if ignore {
+				continue
+			}
+			bin := &hist[index(int(c.Color16.R>>shift), int(c.Color16.G>>shift), int(c.Color16.B>>shift))]
+			bin.cnt++
+			bin.r += uint64(c.Color16.R)
+			bin.g += uint64(c.Color16.G)
+			bin.b += uint64(c.Color16.B)
+		}
+	}
+
+	// the bins along each channel around the bin at r, g, b
+	neighbors := func(r, g, b int, f func(bin *dominantBin)) {
+		for dr := max(r-1, 0); dr <= min(r+1, side-1); dr++ {
+			for dg := max(g-1, 0); dg <= min(g+1, side-1); dg++ {
+				for db := max(b-1, 0); db <= min(b+1, side-1); db++ {
+					f(&hist[index(dr, dg, db)])
+				}
+			}
+		}
+	}
+
+	best, bestScore := -1, 0
+	for i := range hist {
+		if hist[i].cnt == 0 {
+			continue
+		}
+		score := 0
+		neighbors(i/(side*side), i/side%side, i%side, func(bin *dominantBin) { score += bin.cnt })
+		// ties go to the bin with most pixels itself
+		if score > bestScore || (score == bestScore && hist[i].cnt > hist[best].cnt) {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return ColorItem{}, ErrNoPixelsFound
+	}
+
+	var sum dominantBin
+	neighbors(best/(side*side), best/side%side, best%side, func(bin *dominantBin) {
+		sum.cnt += bin.cnt
+		sum.r += bin.r
+		sum.g += bin.g
+		sum.b += bin.b
+	})
+	n := uint64(sum.cnt)
+	return newColorItem16(uint32(sum.r/n), uint32(sum.g/n), uint32(sum.b/n), sum.cnt), nil
+}
+
+// DominantColorClustered returns the most prominent color found with K-means, for call sites only using the first
+// color of KmeansWithOptions: it clusters into DominantK colors, merges those closer than DominantMergeDeltaE so the
+// shades of a color count together and returns the most frequent one. The options are used as in DominantColor,
+// K, MergeDeltaE, MinPercentage, TopN and Sort are not. It is slower than DominantColor, but agrees with the palettes
+// of KmeansWithOptions.
+func DominantColorClustered(orgimg image.Image, options ...Options) (ColorItem, error) {
+	opts, err := dominantOptions("DominantColorClustered", options)
+	if err != nil {
+		return ColorItem{}, err
+	}
+	opts.K = DominantK
+	opts.MergeDeltaE = DominantMergeDeltaE
+	opts.MinPercentage, opts.TopN, opts.Sort = 0, 0, SortCount
+	res, err := KmeansWithOptions(orgimg, opts)
+	if err != nil {
+		return ColorItem{}, err
+	}
+	if len(res.Colors) == 0 {
+		return ColorItem{}, ErrNoPixelsFound
+	}
+	return res.Colors[0], nil
+}
+
+// dominantOptions returns the single optional Options of the function, DefaultOptions if none are given
+func dominantOptions(function string, options []Options) (Options, error) {
+	if len(options) > 1 {
+		return Options{}, fmt.Errorf("Failed, %s takes at most one Options: %d", function, len(options))
+	}
+	if len(options) == 1 {
+		return options[0], nil
+	}
+	return DefaultOptions(), nil
+}
+
+// subsample returns every stride:th pixel of every stride:th row of the area of the image, starting at its top left
+// pixel. The returned image starts at 0,0.
+func subsample(img image.Image, area image.Rectangle, stride int) image.Image {
+	r := image.Rect(0, 0, (area.Dx()+stride-1)/stride, (area.Dy()+stride-1)/stride)
+	out := image.NewRGBA64(r)
+	at := func(x, y int) color.RGBA64 { return color.RGBA64Model.Convert(img.At(x, y)).(color.RGBA64) }
+	if fast, ok := img.(image.RGBA64Image); ok {
+		// no color.Color allocated per pixel
+		at = fast.RGBA64At
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			out.SetRGBA64(x, y, at(area.Min.X+x*stride, area.Min.Y+y*stride))
+		}
+	}
+	return out
+}
+
+// scalePixelMasks returns the masks for the area starting at min subsampled with stride, so they get the original
+// coordinates
+func scalePixelMasks(masks []PixelMask, min image.Point, stride int) []PixelMask {
+	scaled := make([]PixelMask, len(masks))
+	for i, m := range masks {
+		scaled[i] = func(x, y int, c color.Color) bool { return m(min.X+x*stride, min.Y+y*stride, c) }
+	}
+	return scaled
+}
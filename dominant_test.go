@@ -0,0 +1,120 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// TestDominantColorClusteredMergesShades checks two shades of blue outweighing a larger red once merged
+func TestDominantColorClusteredMergesShades(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			c := color.RGBA{R: 220, G: 20, B: 20, A: 255}
+			switch {
+			case x < 30:
+				c = color.RGBA{R: 20, G: 40, B: 200, A: 255}
+			case x < 60:
+				c = color.RGBA{R: 30, G: 50, B: 215, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	opts := DefaultOptions()
+	opts.Arguments = ArgumentNoCropping | ArgumentDeterministic
+	opts.K = 3
+	res, err := KmeansWithOptions(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Colors[0].Color.R < 200 {
+		t.Fatalf("expected red first without merging, got %v", res.Colors[0].Color)
+	}
+	got, err := DominantColorClustered(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Color.B < 200 || got.Cnt <= res.Colors[0].Cnt {
+		t.Fatalf("expected the merged blues, got %v with %d pixels", got.Color, got.Cnt)
+	}
+	if _, err := DominantColorClustered(img, opts, opts); err == nil {
+		t.Fatal("expected an error for two Options")
+	}
+}
+
+func TestSubsampleOffsetBounds(t *testing.T) {
+	img := image.NewRGBA(image.Rect(7, 11, 50, 40))
+	for y := 11; y < 40; y++ {
+		for x := 7; x < 50; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 0xff})
+		}
+	}
+	area := image.Rect(9, 12, 48, 39)
+	out := subsample(img, area, 4)
+	if want := image.Rect(0, 0, 10, 7); out.Bounds() != want {
+		t.Fatalf("Expected bounds %v, got %v", want, out.Bounds())
+	}
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 10; x++ {
+			want := color.RGBA64Model.Convert(img.At(area.Min.X+4*x, area.Min.Y+4*y))
+			if got := out.At(x, y); got != want {
+				t.Fatalf("Expected %v at %d,%d, got %v", want, x, y, got)
+			}
+		}
+	}
+
+	// a pixel mask gets the original coordinates
+	var seen []image.Point
+	mask := func(x, y int, c color.Color) bool { seen = append(seen, image.Pt(x, y)); return false }
+	scalePixelMasks([]PixelMask{mask}, area.Min, 4)[0](1, 2, nil)
+	if len(seen) != 1 || seen[0] != image.Pt(13, 20) {
+		t.Errorf("Expected the mask at 13,20, got %v", seen)
+	}
+}
+
+func TestDominantColorOffsetImage(t *testing.T) {
+	img := benchImage(1200, 900)
+	offset := image.NewRGBA(image.Rect(333, 77, 333+1200, 77+900))
+	for y := 0; y < 900; y++ {
+		copy(offset.Pix[y*offset.Stride:(y+1)*offset.Stride], img.Pix[y*img.Stride:(y+1)*img.Stride])
+	}
+	want, err := DominantColor(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DominantColor(offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Expected the offset image to give %v, got %v", want, got)
+	}
+}
+
+// TestDominantColorSpeed checks DominantColor is at least 10x faster than KmeansWithOptions for a 1 MP image
+func TestDominantColorSpeed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("benchmarks in short mode")
+	}
+	img := benchImage(1200, 900)
+	kmeans := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			KmeansWithOptions(img, DefaultOptions())
+		}
+	})
+	dominant := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			DominantColor(img)
+		}
+	})
+	if ratio := float64(kmeans.NsPerOp()) / float64(dominant.NsPerOp()); ratio < 10 {
+		t.Errorf("Expected DominantColor at least 10x faster, got %.1fx (%v vs %v)", ratio,
+			time.Duration(dominant.NsPerOp()), time.Duration(kmeans.NsPerOp()))
+	}
+}
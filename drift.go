@@ -0,0 +1,86 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DriftError is returned by DriftMonitor.Check when the colors of an asset drifted beyond the threshold
+type DriftError struct {
+	ID string
+
+	// Drift is the largest CIEDE2000 delta E (0-100 scale) between a color and the closest color of the other palette
+	Drift, Threshold float64
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("Failed, colors of %q drifted delta E %.2f from the baseline (max %.2f)", e.ID, e.Drift, e.Threshold)
+}
+
+// DriftMonitor keeps a baseline palette per asset ID and reports assets whose colors drift from it,
+// e.g. to verify images were not corrupted or swapped. It is safe for concurrent use.
+type DriftMonitor struct {
+	// Threshold is the largest accepted drift (CIEDE2000 delta E, 0-100 scale)
+	Threshold float64
+
+	// OnDrift if set is called (in the goroutine calling Check) for each drift beyond the threshold
+	OnDrift func(err *DriftError)
+
+	mu        sync.Mutex
+	baselines map[string][]ColorItem
+}
+
+// NewDriftMonitor creates a monitor with the threshold and no baselines
+func NewDriftMonitor(threshold float64) *DriftMonitor {
+	return &DriftMonitor{Threshold: threshold, baselines: make(map[string][]ColorItem)}
+}
+
+// SetBaseline stores the baseline palette of the asset, e.g. loaded from a database
+func (m *DriftMonitor) SetBaseline(id string, colors []ColorItem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.baselines == nil {
+		m.baselines = make(map[string][]ColorItem)
+	}
+	m.baselines[id] = append([]ColorItem{}, colors...)
+}
+
+// Baseline returns the baseline palette of the asset, and if there is one
+func (m *DriftMonitor) Baseline(id string) ([]ColorItem, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	colors, ok := m.baselines[id]
+	return append([]ColorItem{}, colors...), ok
+}
+
+// Check compares the colors of a re-analyzed asset with its baseline and returns the drift.
+// The first check of an asset stores the colors as baseline. A *DriftError is returned (and OnDrift called)
+// if the drift is above the threshold, the baseline is kept as is.
+func (m *DriftMonitor) Check(id string, colors []ColorItem) (float64, error) {
+	m.mu.Lock()
+	baseline, ok := m.baselines[id]
+	if !ok {
+		if m.baselines == nil {
+			m.baselines = make(map[string][]ColorItem)
+		}
+		m.baselines[id] = append([]ColorItem{}, colors...)
+	}
+	m.mu.Unlock()
+	if !ok {
+		return 0, nil
+	}
+
+	drift := maxPaletteDistance(baseline, colors)
+	if drift <= m.Threshold {
+		return drift, nil
+	}
+	err := &DriftError{ID: id, Drift: drift, Threshold: m.Threshold}
+	if m.OnDrift != nil {
+		m.OnDrift(err)
+	}
+	return drift, err
+}
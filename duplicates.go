@@ -0,0 +1,82 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultDuplicateDistance is a PaletteDistance below which the palettes of two photos are near-duplicates, e.g.
+// the same product shot re-encoded or slightly cropped
+const DefaultDuplicateDistance = 3.0
+
+// DuplicateGroup is a group of palettes within the threshold of FindDuplicatePalettes
+type DuplicateGroup struct {
+	// IDs are the IDs of the palettes, sorted
+	IDs []string
+
+	// MaxDistance is the largest PaletteDistance between two palettes of the group, larger than the threshold
+	// if they are only linked through other palettes
+	MaxDistance float64
+}
+
+// FindDuplicatePalettes scans stored palettes (e.g. the colors of a result per SKU) and returns the groups of
+// palettes within threshold PaletteDistance of each other (DefaultDuplicateDistance if 0), e.g. near-duplicate product
+// shots under different SKUs. Palettes are grouped if they are linked by a chain of close pairs. It compares all
+// pairs, O(n²) distances for n palettes. The groups are sorted by their first ID, palettes without duplicates are
+// left out.
+func FindDuplicatePalettes(palettes map[string][]ColorItem, threshold float64) []DuplicateGroup {
+	if threshold <= 0 {
+		threshold = DefaultDuplicateDistance
+	}
+	ids := make([]string, 0, len(palettes))
+	for id := range palettes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// union-find of the palettes within the threshold
+	parent := make([]int, len(ids))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			if PaletteDistance(palettes[ids[i]], palettes[ids[j]]) <= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range ids {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	var groups []DuplicateGroup
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		g := DuplicateGroup{IDs: make([]string, len(group))}
+		for k, i := range group {
+			g.IDs[k] = ids[i]
+			for _, j := range group[k+1:] {
+				g.MaxDistance = math.Max(g.MaxDistance, PaletteDistance(palettes[ids[i]], palettes[ids[j]]))
+			}
+		}
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(x, y int) bool { return groups[x].IDs[0] < groups[y].IDs[0] })
+	return groups
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	registerBackend("html-palette")
+}
+
+// PaletteHTML formats the colors as an email safe HTML snippet: a table with one cell per color, using only
+// inline styles and the bgcolor attribute for clients ignoring styles. Each cell is labeled with the hex color and
+// its share of the pixels, in black or white, whichever has the higher contrast.
+func PaletteHTML(colors []ColorItem) string {
+	percent := percentages(colors)
+	var buff strings.Builder
+	buff.WriteString(`<table role="presentation" cellpadding="0" cellspacing="0" border="0" style="border-collapse:collapse;"><tr>`)
+	for i, c := range colors {
+		hex := "#" + c.AsString()
+		buff.WriteString(fmt.Sprintf(`<td bgcolor="%s" width="120" height="50" align="center" valign="middle" `+
+			`style="background-color:%s;width:120px;height:50px;text-align:center;font-family:Arial,sans-serif;font-size:13px;color:%s;">%s %.0f%%</td>`,
+			hex, hex, labelColor(c), hex, percent[i]))
+	}
+	buff.WriteString("</tr></table>")
+	return buff.String()
+}
+
+// PaletteText formats the colors as plain text, one line per color, e.g. "#1A6B3C 46%", as the fallback of PaletteHTML
+func PaletteText(colors []ColorItem) string {
+	percent := percentages(colors)
+	var lines []string
+	for i, c := range colors {
+		lines = append(lines, fmt.Sprintf("#%s %.0f%%", c.AsString(), percent[i]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// labelColor returns black or white as CSS color, whichever has the higher contrast on the color
+func labelColor(c ColorItem) string {
+	if blackLabel(c) {
+		return "#000000"
+	}
+	return "#FFFFFF"
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"strings"
+	"testing"
+)
+
+// emailColors are a dark and a light color, 75% and 25% of the pixels
+var emailColors = []ColorItem{
+	{Color: ColorRGB{R: 0x1a, G: 0x6b, B: 0x3c}, Cnt: 3},
+	{Color: ColorRGB{R: 0xf0, G: 0xe0, B: 0x50}, Cnt: 1},
+}
+
+func TestPaletteText(t *testing.T) {
+	if got, want := PaletteText(emailColors), "#1A6B3C 75%\n#F0E050 25%"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := PaletteText(nil); got != "" {
+		t.Errorf("Expected no text without colors, got %q", got)
+	}
+}
+
+func TestPaletteHTML(t *testing.T) {
+	html := PaletteHTML(emailColors)
+	if !strings.HasPrefix(html, `<table role="presentation"`) || !strings.HasSuffix(html, "</tr></table>") {
+		t.Errorf("Expected a single table row, got %q", html)
+	}
+	if n := strings.Count(html, "<td "); n != len(emailColors) {
+		t.Errorf("Expected %d cells, got %d", len(emailColors), n)
+	}
+	for _, want := range []string{
+		// the bgcolor attribute for clients ignoring styles, and the inline style
+		`bgcolor="#1A6B3C"`, `background-color:#1A6B3C;`,
+		// white on the dark color, black on the light one
+		`color:#FFFFFF;">#1A6B3C 75%</td>`, `color:#000000;">#F0E050 25%</td>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in %q", want, html)
+		}
+	}
+	if strings.Contains(html, "<style") || strings.Contains(html, "class=") {
+		t.Errorf("Expected only inline styles, got %q", html)
+	}
+}
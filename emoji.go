@@ -0,0 +1,65 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"strings"
+)
+
+// EmojiColor is an emoji and the (8 bit) color it is drawn in
+type EmojiColor struct {
+	Emoji string
+	Color ColorRGB
+}
+
+var (
+	// EmojiSquares are the colored square emojis (colors as drawn by Twemoji), the default of EmojiSummary
+	EmojiSquares = []EmojiColor{
+		{Emoji: "🟥", Color: ColorRGB{R: 221, G: 46, B: 68}},
+		{Emoji: "🟧", Color: ColorRGB{R: 244, G: 144, B: 12}},
+		{Emoji: "🟨", Color: ColorRGB{R: 253, G: 203, B: 88}},
+		{Emoji: "🟩", Color: ColorRGB{R: 120, G: 177, B: 89}},
+		{Emoji: "🟦", Color: ColorRGB{R: 85, G: 172, B: 238}},
+		{Emoji: "🟪", Color: ColorRGB{R: 170, G: 142, B: 214}},
+		{Emoji: "🟫", Color: ColorRGB{R: 193, G: 105, B: 79}},
+		{Emoji: "⬛", Color: ColorRGB{R: 49, G: 55, B: 61}},
+		{Emoji: "⬜", Color: ColorRGB{R: 230, G: 231, B: 232}},
+	}
+
+	// EmojiHearts are the colored heart emojis (colors as drawn by Twemoji)
+	EmojiHearts = []EmojiColor{
+		{Emoji: "❤️", Color: ColorRGB{R: 221, G: 46, B: 68}},
+		{Emoji: "🧡", Color: ColorRGB{R: 244, G: 144, B: 12}},
+		{Emoji: "💛", Color: ColorRGB{R: 253, G: 203, B: 88}},
+		{Emoji: "💚", Color: ColorRGB{R: 120, G: 177, B: 89}},
+		{Emoji: "💙", Color: ColorRGB{R: 93, G: 173, B: 236}},
+		{Emoji: "💜", Color: ColorRGB{R: 170, G: 142, B: 214}},
+		{Emoji: "🤎", Color: ColorRGB{R: 193, G: 105, B: 79}},
+		{Emoji: "🖤", Color: ColorRGB{R: 49, G: 55, B: 61}},
+		{Emoji: "🤍", Color: ColorRGB{R: 230, G: 231, B: 232}},
+	}
+)
+
+// EmojiSummary maps each color (e.g. Result.Colors, most prominent first) to the closest (CIEDE2000) emoji of the
+// table, e.g. "🟥🟦⬛" for chat bots summarizing images. A nil table uses EmojiSquares.
+func EmojiSummary(colors []ColorItem, table []EmojiColor) string {
+	if table == nil {
+		table = EmojiSquares
+	}
+	if len(table) == 0 {
+		return ""
+	}
+	var summary strings.Builder
+	for _, c := range colors {
+		closest, best := 0, distanceCIEDE2000(c, ColorItem{Color: table[0].Color})
+		for i := 1; i < len(table); i++ {
+			if d := distanceCIEDE2000(c, ColorItem{Color: table[i].Color}); d < best {
+				closest, best = i, d
+			}
+		}
+		summary.WriteString(table[closest].Emoji)
+	}
+	return summary.String()
+}
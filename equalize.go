@@ -0,0 +1,56 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+const (
+	// equalizeBits is the number of bits of the luma bins of EqualizeLuminance, 16 bins
+	equalizeBits = 4
+
+	// equalizeScale is the weight of the colors of a luma bin with as many pixels as the average bin
+	equalizeScale = 8
+
+	// equalizeMaxWeight bounds the weight of the colors of rare luma bins, 16 times the average bin
+	equalizeMaxWeight = 16 * equalizeScale
+)
+
+// luma returns the Rec. 709 luma (0-0xffff) of the 16 bit color, with integer math
+func luma(c ColorItem) uint32 {
+	c16 := c.color16()
+	return uint32((2126*uint64(c16.R) + 7152*uint64(c16.G) + 722*uint64(c16.B)) / 10000)
+}
+
+// equalizeLuminance weights the counts of the colors by how rare their luma is, so each of the luma bins in use
+// counts about as much as the others: the highlights of a mostly dark image are then clustered as much as its
+// shadows. The weights are bounded by equalizeMaxWeight.
+func equalizeLuminance(colors []ColorItem) []ColorItem {
+	const bins = 1 << equalizeBits
+	var counts [bins]int
+	total, used := 0, 0
+	for _, c := range colors {
+		bin := luma(c) >> (16 - equalizeBits)
+		if counts[bin] == 0 {
+			used++
+		}
+		counts[bin] += c.Cnt
+		total += c.Cnt
+	}
+	if used == 0 {
+		return colors
+	}
+
+	var weights [bins]int
+	target := total / used
+	for i, cnt := range counts {
+		if cnt > 0 {
+			weights[i] = min(max((equalizeScale*target+cnt/2)/cnt, 1), equalizeMaxWeight)
+		}
+	}
+	weighted := make([]ColorItem, len(colors))
+	for i, c := range colors {
+		c.Cnt *= weights[luma(c)>>(16-equalizeBits)]
+		weighted[i] = c
+	}
+	return weighted
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// exifOrientationTag is the EXIF (TIFF) tag of the orientation
+const exifOrientationTag = 0x0112
+
+// ExifOrientation returns the orientation stored in the EXIF data of the encoded JPEG (APP1 segment) or PNG (eXIf
+// chunk), OrientationNormal if there is none. Camera images are often stored sideways with this tag telling viewers
+// how to rotate them; apply it with Orient before cropping, otherwise the wrong part of the image is the center.
+func ExifOrientation(data []byte) Orientation {
+	o := tiffOrientation(exifTIFF(data))
+	if o < OrientationNormal || o > OrientationRotate270 {
+		return OrientationNormal
+	}
+	return o
+}
+
+// exifTIFF returns the TIFF data of the EXIF data of the encoded JPEG or PNG, nil if there is none
+func exifTIFF(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return jpegExif(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return pngExif(data)
+	}
+	return nil
+}
+
+// jpegExif returns the TIFF data of the Exif APP1 segment of a JPEG, nil if there is none
+func jpegExif(data []byte) []byte {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		if marker == 0xda || marker == 0xd9 {
+			// start of scan, no more metadata
+			break
+		}
+		// the length includes its own 2 bytes
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+// pngExif returns the TIFF data of the eXIf chunk of a PNG, nil if there is none
+func pngExif(data []byte) []byte {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		typ := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) || typ == "IDAT" {
+			break
+		}
+		if typ == "eXIf" {
+			return data[pos+8 : pos+8+length]
+		}
+		pos += 12 + length
+	}
+	return nil
+}
+
+// tiffOrientation returns the orientation tag of the first IFD of the TIFF data, 0 if it is missing
+func tiffOrientation(tiff []byte) Orientation {
+	order, ifd := tiffHeader(tiff)
+	if entry := tiffEntry(tiff, order, ifd, exifOrientationTag); entry >= 0 {
+		// a SHORT, stored left-justified in the value field
+		return Orientation(order.Uint16(tiff[entry+8 : entry+10]))
+	}
+	return 0
+}
+
+// tiffHeader returns the byte order of the TIFF data and the offset of its first IFD, nil if it is no TIFF data
+func tiffHeader(tiff []byte) (binary.ByteOrder, int) {
+	if len(tiff) < 8 {
+		return nil, 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0
+	}
+	return order, int(order.Uint32(tiff[4:8]))
+}
+
+// tiffEntry returns the offset of the 12 byte entry of the tag in the IFD at offset ifd, -1 if it is missing
+func tiffEntry(tiff []byte, order binary.ByteOrder, ifd int, tag uint16) int {
+	if order == nil || ifd < 8 || ifd+2 > len(tiff) {
+		return -1
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return -1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == tag {
+			return entry
+		}
+	}
+	return -1
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"image/jpeg"
+	"testing"
+)
+
+// malformedExif are encoded images with broken metadata segments, none may make the parsers panic
+var malformedExif = map[string][]byte{
+	"zero segment length":  {0xff, 0xd8, 0xff, 0xe1, 0, 0, 0, 0},
+	"one byte segment":     {0xff, 0xd8, 0xff, 0xe1, 0, 1, 0, 0},
+	"segment past the end": {0xff, 0xd8, 0xff, 0xe1, 0xff, 0xff, 'E', 'x'},
+	"truncated tiff":       {0xff, 0xd8, 0xff, 0xe1, 0, 12, 'E', 'x', 'i', 'f', 0, 0, 'I', 'I', '*', 0},
+	"tiff ifd past the end": {0xff, 0xd8, 0xff, 0xe1, 0, 16, 'E', 'x', 'i', 'f', 0, 0,
+		'I', 'I', '*', 0, 0xf0, 0xff, 0xff, 0x7f},
+	"png chunk past the end": []byte("\x89PNG\r\n\x1a\n\x7f\xff\xff\xffeXIf"),
+}
+
+func TestExifMalformed(t *testing.T) {
+	for name, data := range malformedExif {
+		if o := ExifOrientation(data); o != OrientationNormal {
+			t.Errorf("%s: expected the normal orientation, got %d", name, o)
+		}
+		if wb, ok := ExifWhiteBalance(data); ok {
+			t.Errorf("%s: expected no white balance, got %+v", name, wb)
+		}
+		if _, err := KmeansFromBytes(data, DefaultOptions()); err == nil {
+			t.Errorf("%s: expected a decoding error", name)
+		}
+	}
+}
+
+func TestKmeansFromBytesTruncatedJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, wideImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for _, n := range []int{2, 4, 6, 20, len(data) / 2} {
+		if _, err := KmeansFromBytes(data[:n], DefaultOptions()); err == nil {
+			t.Errorf("Expected an error for the JPEG truncated to %d bytes", n)
+		}
+	}
+}
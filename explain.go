@@ -0,0 +1,300 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// clusterDetail contains what is known about the pixels of a cluster
+type clusterDetail struct {
+	// pixels is the number of pixels assigned to the cluster, centerPixels the ones in the center region
+	pixels, centerPixels int
+
+	// centerArea is the share of all pixels that are in the center region
+	centerArea float64
+
+	// avgDeltaE is the average CIEDE2000 distance (0-100 scale) of the pixels to the centroid
+	avgDeltaE float64
+
+	// variance is the average squared LAB distance (L 0-100 scale) of the pixels to the centroid
+	variance float64
+
+	// avgDistance and maxDistance are the average and largest distance of the pixels to the centroid, in the color
+	// space of the clustering
+	avgDistance, maxDistance float64
+
+	// bounds is the smallest rectangle containing the pixels and centerX, centerY their mean position, in the
+	// coordinates of the original image
+	bounds           image.Rectangle
+	centerX, centerY float64
+}
+
+// extentSum collects the positions of the pixels of a cluster, in the coordinates of the prepared image
+type extentSum struct {
+	bounds     image.Rectangle
+	sumX, sumY float64
+}
+
+// add adds a run of n pixels in a row starting at x, y
+func (e *extentSum) add(x, y, n int) {
+	e.bounds = e.bounds.Union(image.Rect(x, y, x+n, y+1))
+	e.sumX += float64(n) * (float64(x) + float64(n)/2)
+	e.sumY += float64(n) * (float64(y) + 0.5)
+}
+
+// describeClusters assigns each (non-masked) pixel to the closest centroid and collects details per cluster.
+// The center region is the middle half of the width and height. source is the rectangle of the original image the
+// image was prepared from, the positions are mapped to it.
+func describeClusters(img image.Image, centroids []ColorItem, arguments int, source image.Rectangle) []clusterDetail {
+	details := make([]clusterDetail, len(centroids))
+	extents := make([]extentSum, len(centroids))
+	sumDeltaE := make([]float64, len(centroids))
+	sumSquared := make([]float64, len(centroids))
+	sumDistance := make([]float64, len(centroids))
+	// the closest centroid of each color and the CIEDE2000, squared LAB and clustering distances to it
+	type assignment struct {
+		idx                       int
+		deltaE, squared, distance float64
+	}
+	assigned := make(map[uint64]assignment)
+	assign := func(c ColorItem) assignment {
+		key := c.key()
+		as, ok := assigned[key]
+		if !ok {
+			as.idx = findClosest(arguments, c, centroids)
+			as.deltaE = distanceCIEDE2000(c, centroids[as.idx]) * 100
+			as.squared = sq(distanceLAB(c, centroids[as.idx]) * 100)
+			as.distance = spaceDistance(arguments, c, centroids[as.idx])
+			assigned[key] = as
+		}
+		return as
+	}
+
+	b := img.Bounds()
+	center := image.Rect(b.Min.X+b.Dx()/4, b.Min.Y+b.Dy()/4, b.Max.X-b.Dx()/4, b.Max.Y-b.Dy()/4)
+	centerArea := float64(center.Dx()*center.Dy()) / float64(b.Dx()*b.Dy())
+
+	if p, ok := img.(*image.Paletted); ok {
+		// the pixels of a palette color all go to the same cluster
+		centerCounts := paletteCounts(p.SubImage(center).(*image.Paletted))
+		clusterOf := make([]int, len(p.Palette))
+		for i, cnt := range paletteCounts(p) {
+			clusterOf[i] = -1
+			c, ignore := createColor(p.Palette[i])
+			if ignore || cnt == 0 {
+				continue
+			}
+			as := assign(c)
+			clusterOf[i] = as.idx
+			details[as.idx].pixels += cnt
+			details[as.idx].centerPixels += centerCounts[i]
+			sumDeltaE[as.idx] += as.deltaE * float64(cnt)
+			sumSquared[as.idx] += as.squared * float64(cnt)
+			sumDistance[as.idx] += as.distance * float64(cnt)
+			details[as.idx].maxDistance = max(details[as.idx].maxDistance, as.distance)
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := p.PixOffset(b.Min.X, y)
+			for x, idx := range p.Pix[i : i+b.Dx()] {
+				if int(idx) < len(clusterOf) && clusterOf[idx] >= 0 {
+					extents[clusterOf[idx]].add(b.Min.X+x, y, 1)
+				}
+			}
+		}
+	} else {
+		// the pixels of a run of the same color all go to the same cluster
+		forEachRun(img, func(x, y, n int, r, g, bl, a uint32) {
+			if a == 0 {
+				return
+			}
+			as := assign(newColorItem16(r, g, bl, 0))
+			details[as.idx].pixels += n
+			if run := image.Rect(x, y, x+n, y+1).Intersect(center); !run.Empty() {
+				details[as.idx].centerPixels += run.Dx()
+			}
+			sumDeltaE[as.idx] += as.deltaE * float64(n)
+			sumSquared[as.idx] += as.squared * float64(n)
+			sumDistance[as.idx] += as.distance * float64(n)
+			details[as.idx].maxDistance = max(details[as.idx].maxDistance, as.distance)
+			extents[as.idx].add(x, y, n)
+		})
+	}
+
+	for i := range details {
+		details[i].centerArea = centerArea
+		if details[i].pixels > 0 {
+			details[i].avgDeltaE = sumDeltaE[i] / float64(details[i].pixels)
+			details[i].variance = sumSquared[i] / float64(details[i].pixels)
+			details[i].avgDistance = sumDistance[i] / float64(details[i].pixels)
+			e, n := extents[i], float64(details[i].pixels)
+			details[i].bounds = toSource(e.bounds, b, source)
+			details[i].centerX = float64(source.Min.X) + (e.sumX/n-float64(b.Min.X))*float64(source.Dx())/float64(b.Dx())
+			details[i].centerY = float64(source.Min.Y) + (e.sumY/n-float64(b.Min.Y))*float64(source.Dy())/float64(b.Dy())
+		}
+	}
+	return details
+}
+
+// toSource maps a rectangle of the prepared image with bounds b to the rectangle of the original image it covers,
+// source being the rectangle the prepared image was made of
+func toSource(r, b, source image.Rectangle) image.Rectangle {
+	// the minimum is rounded down and the maximum up, so all original pixels of the prepared ones are covered
+	minX := source.Min.X + (r.Min.X-b.Min.X)*source.Dx()/b.Dx()
+	minY := source.Min.Y + (r.Min.Y-b.Min.Y)*source.Dy()/b.Dy()
+	maxX := source.Min.X + ((r.Max.X-b.Min.X)*source.Dx()+b.Dx()-1)/b.Dx()
+	maxY := source.Min.Y + ((r.Max.Y-b.Min.Y)*source.Dy()+b.Dy()-1)/b.Dy()
+	return image.Rect(minX, minY, maxX, maxY)
+}
+
+// spaceDistance returns the distance of the colors in the color space of the clustering, taking the square root the
+// RGB distance skips
+func spaceDistance(arguments int, c, p ColorItem) float64 {
+	if arguments&spaceArguments == 0 {
+		return math.Sqrt(distanceRGB(c, p))
+	}
+	return distance(arguments, c, p)
+}
+
+// Explain describes each color in a human readable way, one line per color, e.g.
+// "Color #1 (#1A6B3C, 46%): concentrated in center region, survived white-background mask, tight cluster (avg ΔE 3.1)".
+// Without Options.Details only the background removal is described.
+func (r Result) Explain() string {
+	var lines []string
+	for i, c := range r.Colors {
+		var parts []string
+		if i < len(r.details) && r.details[i].pixels > 0 {
+			d := r.details[i]
+			parts = append(parts, d.spatialText())
+			parts = append(parts, maskText(r.prep))
+			parts = append(parts, d.tightnessText())
+		} else {
+			parts = append(parts, maskText(r.prep))
+		}
+		lines = append(lines, fmt.Sprintf("Color #%d (#%s, %.0f%%): %s", i+1, c.AsString(), c.Percentage, strings.Join(parts, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// spatialText describes where the pixels of the cluster are
+func (d clusterDetail) spatialText() string {
+	centerShare := float64(d.centerPixels) / float64(d.pixels)
+	switch {
+	case centerShare > d.centerArea*1.5:
+		return "concentrated in center region"
+	case centerShare < d.centerArea*0.5:
+		return "mostly near the edges"
+	}
+	return "spread over the image"
+}
+
+// tightnessText describes how close the pixels are to the centroid color
+func (d clusterDetail) tightnessText() string {
+	kind := "loose cluster"
+	switch {
+	case d.avgDeltaE < 5:
+		kind = "tight cluster"
+	case d.avgDeltaE < 12:
+		kind = "moderately spread cluster"
+	}
+	return fmt.Sprintf("%s (avg ΔE %.1f)", kind, d.avgDeltaE)
+}
+
+// maskText describes the background removal that was applied
+func maskText(prep preparation) string {
+	if prep.foreground {
+		return "inside detected foreground"
+	}
+	if prep.chromaKeyed > 0 {
+		return "survived chroma key"
+	}
+	if prep.borderBackground > 0 {
+		return "survived border background"
+	}
+	if prep.floodFilled {
+		return "survived background flood fill"
+	}
+	if prep.mask == nil {
+		return "no background mask applied"
+	}
+	return "survived " + maskName(*prep.mask) + " mask"
+}
+
+// maskName names the predefined masks
+func maskName(mask ColorBackgroundMask) string {
+	switch mask {
+	case MaskWhite:
+		return "white-background"
+	case MaskBlack:
+		return "black-background"
+	case MaskGreen:
+		return "green-background"
+	}
+	if mask.DeltaE > 0 {
+		switch mask.Reference {
+		case ColorRGB{R: 0xff, G: 0xff, B: 0xff}:
+			return fmt.Sprintf("white-background (ΔE %.1f)", mask.DeltaE)
+		case ColorRGB{}:
+			return fmt.Sprintf("black-background (ΔE %.1f)", mask.DeltaE)
+		}
+		return fmt.Sprintf("#%.2X%.2X%.2X-background (ΔE %.1f)", mask.Reference.R, mask.Reference.G, mask.Reference.B, mask.DeltaE)
+	}
+	return "custom background"
+}
+
+// ClusterSpread is how far the pixels of a color of a result are from it, e.g. to tell a gradient (a large spread)
+// from a solid color
+type ClusterSpread struct {
+	// AvgDistance and MaxDistance are the average and largest distance of the pixels to the color, in the color
+	// space of the clustering (see Options.Space): 16 bit RGB values, LAB and CIEDE2000 on the 0-1 scale or CAM16-UCS
+	AvgDistance, MaxDistance float64
+
+	// AvgDeltaE is the average CIEDE2000 delta E (0-100 scale) of the pixels to the color, whatever the color space
+	AvgDeltaE float64
+
+	// Variance is the average squared LAB distance (L 0-100 scale) of the pixels to the color
+	Variance float64
+}
+
+// ClusterSpreads returns the spread of the pixels of each color, in the order of Colors, if Options.Details is set.
+// It is zero for colors without pixel assignments, e.g. the aggregate colors of frames.
+func (r Result) ClusterSpreads() []ClusterSpread {
+	spreads := make([]ClusterSpread, len(r.Colors))
+	for i := range spreads {
+		if i < len(r.details) {
+			d := r.details[i]
+			spreads[i] = ClusterSpread{AvgDistance: d.avgDistance, MaxDistance: d.maxDistance, AvgDeltaE: d.avgDeltaE, Variance: d.variance}
+		}
+	}
+	return spreads
+}
+
+// ClusterExtent is where the pixels of a color of a result are, in the coordinates of the original image, e.g. to
+// tell the color of a product in the center from the one of a banner along the top
+type ClusterExtent struct {
+	// Bounds is the smallest rectangle containing the pixels of the color. For resized images it is rounded
+	// outwards to whole pixels of the original image.
+	Bounds image.Rectangle
+
+	// CenterX and CenterY are the mean position of the pixels of the color, the center of the pixel at x, y being
+	// x+0.5, y+0.5
+	CenterX, CenterY float64
+}
+
+// ClusterExtents returns the extent of the pixels of each color, in the order of Colors, if Options.Details is set.
+// It is zero for colors without pixel assignments, e.g. the aggregate colors of frames.
+func (r Result) ClusterExtents() []ClusterExtent {
+	extents := make([]ClusterExtent, len(r.Colors))
+	for i := range extents {
+		if i < len(r.details) && r.details[i].pixels > 0 {
+			d := r.details[i]
+			extents[i] = ClusterExtent{Bounds: d.bounds, CenterX: d.centerX, CenterY: d.centerY}
+		}
+	}
+	return extents
+}
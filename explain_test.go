@@ -0,0 +1,45 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDetailsOptIn(t *testing.T) {
+	img := framedImage(60, color.RGBA{R: 0xff, A: 0xff}, color.RGBA{G: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff})
+	opts := DefaultOptions()
+	opts.Arguments = ArgumentDeterministic | ArgumentNoCropping
+
+	res, err := KmeansWithOptions(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.details != nil {
+		t.Errorf("Expected no details without Options.Details")
+	}
+	if spreads := res.ClusterSpreads(); len(spreads) != len(res.Colors) || spreads[0] != (ClusterSpread{}) {
+		t.Errorf("Expected zero spreads, got %+v", spreads)
+	}
+
+	opts.Details = true
+	detailed, err := KmeansWithOptions(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameColors(t, detailed.Colors, res.Colors)
+	if detailed.Fingerprint != res.Fingerprint {
+		t.Errorf("Expected Details not to change the fingerprint")
+	}
+	extents := detailed.ClusterExtents()
+	if len(extents) != len(detailed.Colors) || extents[0].Bounds.Empty() {
+		t.Errorf("Expected extents, got %+v", extents)
+	}
+	if !strings.Contains(detailed.Explain(), "cluster (avg ΔE") {
+		t.Errorf("Expected the tightness to be explained, got %q", detailed.Explain())
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package export writes palettes as swatch files for design tools (GIMP .gpl, Adobe .ase and .aco), as JSON with a
+// stable schema (indented, or one line per palette as NDJSON) and as CSS custom properties. The metadata of a palette
+// (e.g. the source URL, asset ID and license from Options.Metadata) is written to all formats supporting it: JSON,
+// NDJSON, GPL and CSS.
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/cjkgg/prominentcolor"
+)
+
+// Palette is what the functions of the package write
+type Palette struct {
+	// Name names the palette in the formats supporting it
+	Name string
+
+	Colors []prominentcolor.ColorItem
+
+	// Metadata is written to the formats supporting it, sorted by key
+	Metadata map[string]string
+
+	// Fingerprint is the Result.Fingerprint, written to JSON
+	Fingerprint string
+}
+
+// FromResult creates the palette of a result, with its metadata and fingerprint
+func FromResult(name string, res prominentcolor.Result) Palette {
+	return Palette{Name: name, Colors: res.Colors, Metadata: res.Metadata, Fingerprint: res.Fingerprint}
+}
+
+// keys returns the metadata keys, sorted so the output is stable
+func (p Palette) keys() []string {
+	keys := make([]string, 0, len(p.Metadata))
+	for k := range p.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SchemaVersion is the version of the JSON schema, it only changes when the schema changes incompatibly
+const SchemaVersion = 1
+
+// Document is the JSON schema of a palette
+type Document struct {
+	Version     int               `json:"version"`
+	Name        string            `json:"name,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Colors      []Color           `json:"colors"`
+}
+
+// Color is a color of a Document, LAB and HSL as in prominentcolor.ColorModels rounded to 4 decimals. Name and
+// NameDeltaE are set if the colors were named (see prominentcolor.Options.ColorNames).
+type Color struct {
+	Hex        string     `json:"hex"`
+	RGB        [3]uint32  `json:"rgb"`
+	LAB        [3]float64 `json:"lab"`
+	HSL        [3]float64 `json:"hsl"`
+	Count      int        `json:"count"`
+	Percentage float64    `json:"percentage"`
+	Name       string     `json:"name,omitempty"`
+	NameDeltaE float64    `json:"nameDeltaE,omitempty"`
+}
+
+// NewDocument creates the JSON document of the palette
+func NewDocument(p Palette) Document {
+	doc := Document{Version: SchemaVersion, Name: p.Name, Metadata: p.Metadata, Fingerprint: p.Fingerprint, Colors: make([]Color, len(p.Colors))}
+	for i, c := range p.Colors {
+		m := c.Models()
+		doc.Colors[i] = Color{
+			Hex:        m.Hex,
+			RGB:        [3]uint32{m.RGB.R, m.RGB.G, m.RGB.B},
+			LAB:        [3]float64{round(m.LAB.L), round(m.LAB.A), round(m.LAB.B)},
+			HSL:        [3]float64{round(m.HSL.H), round(m.HSL.S), round(m.HSL.L)},
+			Count:      c.Cnt,
+			Percentage: round(c.Percentage),
+			Name:       c.Name,
+			NameDeltaE: round(c.NameDeltaE),
+		}
+	}
+	return doc
+}
+
+// JSON writes the palette as indented Document
+func JSON(w io.Writer, p Palette) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(NewDocument(p)); err != nil {
+		return fmt.Errorf("Failed writing JSON: %v", err)
+	}
+	return nil
+}
+
+// NDJSON writes the palette as Document on a single line (newline delimited JSON), so the palettes of a batch written
+// one after the other form a JSON Lines file
+func NDJSON(w io.Writer, p Palette) error {
+	if err := json.NewEncoder(w).Encode(NewDocument(p)); err != nil {
+		return fmt.Errorf("Failed writing NDJSON: %v", err)
+	}
+	return nil
+}
+
+// GPL writes the palette as GIMP palette (.gpl), the colors named by their hex value and the metadata as comments
+func GPL(w io.Writer, p Palette) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GIMP Palette\nName: %s\nColumns: %d\n#\n", oneLine(p.Name), len(p.Colors))
+	for _, k := range p.keys() {
+		fmt.Fprintf(&b, "# %s: %s\n", oneLine(k), oneLine(p.Metadata[k]))
+	}
+	for _, c := range p.Colors {
+		fmt.Fprintf(&b, "%3d %3d %3d\t#%s\n", c.Color.R, c.Color.G, c.Color.B, c.AsString())
+	}
+	return write(w, "GPL", []byte(b.String()))
+}
+
+// CSS writes the colors as CSS custom properties of :root, --<prefix>-1 being the most dominant color,
+// e.g. "--palette-1: #1A6B3C;", preceded by a comment with the name and metadata
+func CSS(w io.Writer, prefix string, p Palette) error {
+	var b strings.Builder
+	if p.Name != "" || len(p.Metadata) > 0 {
+		b.WriteString("/*\n")
+		if p.Name != "" {
+			fmt.Fprintf(&b, " * %s\n", cssComment(p.Name))
+		}
+		for _, k := range p.keys() {
+			fmt.Fprintf(&b, " * %s: %s\n", cssComment(k), cssComment(p.Metadata[k]))
+		}
+		b.WriteString(" */\n")
+	}
+	b.WriteString(":root {\n")
+	for i, c := range p.Colors {
+		fmt.Fprintf(&b, "  --%s-%d: #%s;\n", prefix, i+1, c.AsString())
+	}
+	b.WriteString("}\n")
+	return write(w, "CSS", []byte(b.String()))
+}
+
+// ASE block types and color type of Adobe Swatch Exchange files
+const (
+	aseGroupStart = 0xc001
+	aseGroupEnd   = 0xc002
+	aseColor      = 0x0001
+	aseGlobal     = 0
+)
+
+// ASE writes the palette as Adobe Swatch Exchange (.ase) with a group of the name, the colors named by their hex
+// value. The format has no place for the metadata.
+func ASE(w io.Writer, p Palette) error {
+	var buf bytes.Buffer
+	buf.WriteString("ASEF")
+	// version 1.0 and the number of blocks: the group start and end and a block per color
+	put(&buf, uint16(1), uint16(0), uint32(len(p.Colors)+2))
+
+	aseBlock(&buf, aseGroupStart, utf16String(p.Name))
+	for _, c := range p.Colors {
+		var block bytes.Buffer
+		block.Write(utf16String("#" + c.AsString()))
+		block.WriteString("RGB ")
+		put(&block, float32(c.Color.R)/0xff, float32(c.Color.G)/0xff, float32(c.Color.B)/0xff, uint16(aseGlobal))
+		aseBlock(&buf, aseColor, block.Bytes())
+	}
+	aseBlock(&buf, aseGroupEnd, nil)
+	return write(w, "ASE", buf.Bytes())
+}
+
+// aseBlock writes a block of an ASE file
+func aseBlock(buf *bytes.Buffer, blockType uint16, data []byte) {
+	put(buf, blockType, uint32(len(data)))
+	buf.Write(data)
+}
+
+// utf16String encodes the string as in ASE and ACO files: the number of UTF-16 code units including the
+// terminating zero, followed by the big endian code units
+func utf16String(s string) []byte {
+	units := append(utf16.Encode([]rune(s)), 0)
+	var buf bytes.Buffer
+	put(&buf, uint16(len(units)), units)
+	return buf.Bytes()
+}
+
+// acoRGB is the RGB color space of ACO files
+const acoRGB = 0
+
+// ACO writes the colors as Adobe Photoshop color swatches (.aco): a version 1 section for older readers, followed by
+// a version 2 section with the hex value as name of each color. The format has no place for the name and metadata.
+func ACO(w io.Writer, p Palette) error {
+	var buf bytes.Buffer
+	for version := uint16(1); version <= 2; version++ {
+		put(&buf, version, uint16(len(p.Colors)))
+		for _, c := range p.Colors {
+			// the channels are 16 bit, the fourth value is unused for RGB
+			put(&buf, uint16(acoRGB), uint16(c.Color.R*0x101), uint16(c.Color.G*0x101), uint16(c.Color.B*0x101), uint16(0))
+			if version == 2 {
+				// the name length is 32 bit in ACO files
+				put(&buf, uint16(0))
+				buf.Write(utf16String("#" + c.AsString()))
+			}
+		}
+	}
+	return write(w, "ACO", buf.Bytes())
+}
+
+// put writes the values big endian
+func put(buf *bytes.Buffer, values ...any) {
+	for _, v := range values {
+		// writing fixed size values to a bytes.Buffer does not fail
+		binary.Write(buf, binary.BigEndian, v)
+	}
+}
+
+// write writes the encoded palette
+func write(w io.Writer, format string, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("Failed writing %s: %v", format, err)
+	}
+	return nil
+}
+
+// oneLine replaces the line breaks of s, which would end a header line
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// cssComment makes s safe inside a CSS comment
+func cssComment(s string) string {
+	return strings.ReplaceAll(oneLine(s), "*/", "* /")
+}
+
+// round rounds to 4 decimals, so the JSON stays the same for insignificant differences
+func round(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
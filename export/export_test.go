@@ -0,0 +1,176 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cjkgg/prominentcolor"
+)
+
+func TestNDJSON(t *testing.T) {
+	palettes := []Palette{
+		{Name: "a", Colors: []prominentcolor.ColorItem{{Color: prominentcolor.ColorRGB{R: 0xff}, Cnt: 3}},
+			Metadata: map[string]string{"asset": "1", "license": "CC0"}, Fingerprint: "v1-0"},
+		{Name: "b", Colors: []prominentcolor.ColorItem{{Color: prominentcolor.ColorRGB{B: 0xff}, Cnt: 1}},
+			Metadata: map[string]string{"asset": "2"}},
+	}
+	var buf bytes.Buffer
+	for _, p := range palettes {
+		if err := NDJSON(&buf, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var docs []Document
+	for scanner.Scan() {
+		var doc Document
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			t.Fatalf("Failed parsing line %q: %v", scanner.Text(), err)
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) != len(palettes) {
+		t.Fatalf("Expected a line per palette, got %d", len(docs))
+	}
+	if docs[0].Metadata["license"] != "CC0" || docs[1].Metadata["asset"] != "2" || docs[0].Fingerprint != "v1-0" {
+		t.Errorf("Expected the metadata and fingerprint to be kept, got %+v", docs)
+	}
+	if docs[1].Colors[0].Hex != "#0000FF" || docs[0].Version != SchemaVersion {
+		t.Errorf("Expected the document of the palette, got %+v", docs[1])
+	}
+}
+
+// testPalette is a palette of red and a dark green, with metadata
+var testPalette = Palette{
+	Name: "Test\nPalette",
+	Colors: []prominentcolor.ColorItem{
+		{Color: prominentcolor.ColorRGB{R: 0xff}, Cnt: 3, Percentage: 75},
+		{Color: prominentcolor.ColorRGB{R: 0x1a, G: 0x6b, B: 0x3c}, Cnt: 1, Percentage: 25},
+	},
+	Metadata: map[string]string{"source": "a */ b", "asset": "42"},
+}
+
+// utf16Test is the ASE/ACO encoding of an ASCII string
+func utf16Test(s string) string {
+	var b strings.Builder
+	b.WriteString(string([]byte{0, byte(len(s) + 1)}))
+	for _, r := range s {
+		b.WriteString(string([]byte{0, byte(r)}))
+	}
+	b.WriteString("\x00\x00")
+	return b.String()
+}
+
+func TestGPL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := GPL(&buf, testPalette); err != nil {
+		t.Fatal(err)
+	}
+	want := "GIMP Palette\nName: Test Palette\nColumns: 2\n#\n# asset: 42\n# source: a */ b\n" +
+		"255   0   0\t#FF0000\n 26 107  60\t#1A6B3C\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+func TestCSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := CSS(&buf, "palette", testPalette); err != nil {
+		t.Fatal(err)
+	}
+	want := "/*\n * Test Palette\n * asset: 42\n * source: a * / b\n */\n" +
+		":root {\n  --palette-1: #FF0000;\n  --palette-2: #1A6B3C;\n}\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := CSS(&buf, "c", Palette{Colors: testPalette.Colors[:1]}); err != nil {
+		t.Fatal(err)
+	}
+	if want := ":root {\n  --c-1: #FF0000;\n}\n"; buf.String() != want {
+		t.Errorf("Expected no comment without name and metadata, got\n%s", buf.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := JSON(&buf, testPalette); err != nil {
+		t.Fatal(err)
+	}
+	var doc Document
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != SchemaVersion || doc.Name != testPalette.Name || doc.Metadata["asset"] != "42" || len(doc.Colors) != 2 {
+		t.Fatalf("Expected the document of the palette, got %+v", doc)
+	}
+	c := doc.Colors[1]
+	if c.Hex != "#1A6B3C" || c.RGB != [3]uint32{0x1a, 0x6b, 0x3c} || c.Count != 1 || c.Percentage != 25 {
+		t.Errorf("Expected the second color, got %+v", c)
+	}
+	if !strings.Contains(buf.String(), "\n  \"version\": 1,\n") {
+		t.Errorf("Expected indented JSON, got\n%s", buf.String())
+	}
+}
+
+func TestASE(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ASE(&buf, Palette{Name: "P", Colors: testPalette.Colors[:1]}); err != nil {
+		t.Fatal(err)
+	}
+	want := "ASEF\x00\x01\x00\x00\x00\x00\x00\x03" +
+		// group start with the name
+		"\xc0\x01\x00\x00\x00\x06" + utf16Test("P") +
+		// the color: name, color model, float32 1, 0, 0 and the global color type
+		"\x00\x01\x00\x00\x00\x24" + utf16Test("#FF0000") + "RGB " +
+		"\x3f\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+		"\xc0\x02\x00\x00\x00\x00"
+	if buf.String() != want {
+		t.Errorf("Expected\n% x\ngot\n% x", want, buf.Bytes())
+	}
+}
+
+func TestACO(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ACO(&buf, Palette{Colors: testPalette.Colors[:1]}); err != nil {
+		t.Fatal(err)
+	}
+	color := "\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00"
+	want := "\x00\x01\x00\x01" + color +
+		"\x00\x02\x00\x01" + color + "\x00\x00" + utf16Test("#FF0000")
+	if buf.String() != want {
+		t.Errorf("Expected\n% x\ngot\n% x", want, buf.Bytes())
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteError(t *testing.T) {
+	for name, encode := range map[string]func() error{
+		"GPL":    func() error { return GPL(failingWriter{}, testPalette) },
+		"CSS":    func() error { return CSS(failingWriter{}, "p", testPalette) },
+		"ASE":    func() error { return ASE(failingWriter{}, testPalette) },
+		"ACO":    func() error { return ACO(failingWriter{}, testPalette) },
+		"JSON":   func() error { return JSON(failingWriter{}, testPalette) },
+		"NDJSON": func() error { return NDJSON(failingWriter{}, testPalette) },
+	} {
+		if err := encode(); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: expected the write error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+// FeaturesPerColor is the number of values per color in the vector returned by Result.Features
+const FeaturesPerColor = 5
+
+// Features returns the colors as a flat feature vector with exactly k*FeaturesPerColor values, for use in ML pipelines.
+// For color i (most dominant first) the values at i*FeaturesPerColor are:
+//
+//	0: L (0-100)
+//	1: a (about -100 to 100)
+//	2: b (about -100 to 100)
+//	3: weight, the share of the pixels (0-1, summing to 1 over all colors)
+//	4: variance, the average squared LAB distance of the pixels to the color (0 for FramesResult.Aggregate and
+//	   without Options.Details)
+//
+// Results with fewer than k colors are padded with zeros, further colors are left out.
+func (r Result) Features(k int) []float32 {
+	if k < 0 {
+		k = 0
+	}
+	features := make([]float32, k*FeaturesPerColor)
+
+	total := 0
+	for _, c := range r.Colors {
+		total += c.Cnt
+	}
+
+	for i, c := range r.Colors {
+		if i == k {
+			break
+		}
+		l, a, b := c.toColorful().Lab()
+		v := features[i*FeaturesPerColor : (i+1)*FeaturesPerColor]
+		v[0], v[1], v[2] = float32(l*100), float32(a*100), float32(b*100)
+		if total > 0 {
+			v[3] = float32(c.Cnt) / float32(total)
+		}
+		if i < len(r.details) {
+			v[4] = float32(r.details[i].variance)
+		}
+	}
+	return features
+}
@@ -0,0 +1,48 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// AlgorithmVersion is increased whenever a change of the algorithms changes the colors found with the same options,
+// it is part of the fingerprints (see Options.Fingerprint)
+const AlgorithmVersion = 1
+
+// Fingerprint identifies the algorithm version and the options affecting the colors, e.g. "v1-9f86d081884c7d65",
+// so stored results can be invalidated when either changes: a result is stale if its Fingerprint differs from the
+// Fingerprint of the options now used. Options only affecting what else is returned (Metadata, MaskReport, LabelMap,
+// Details, DebugImage, ArgumentDebugImage) are left out. PixelMasks are only counted, as functions cannot be compared,
+// as are custom resizers. With the CAM16-UCS space the process wide viewing conditions (see SetViewingConditions) are
+// included.
+func (o Options) Fingerprint() string {
+	h := sha256.New()
+	arguments := o.arguments() &^ ArgumentDebugImage
+	fmt.Fprintf(h, "k=%d arguments=%d size=%d undither=%t samples=%d region=%v alpha=%d\n",
+		o.k(), arguments, o.Size, o.Undither, o.Samples, o.Region, o.AlphaThreshold)
+	if IsBitSet(arguments, ArgumentCAM16UCS) {
+		fmt.Fprintf(h, "cam16=%+v\n", *currentCAM16.Load())
+	}
+	fmt.Fprintf(h, "resizer=%T%+v\n", o.Resizer, o.Resizer)
+	fmt.Fprintf(h, "masks=%+v pixelmasks=%d safeareas=%v tolerance=%v\n", o.Masks, len(o.PixelMasks), o.SafeAreas, o.BackgroundTolerance)
+	fmt.Fprintf(h, "equalize=%t lowlight=%t labbins=%v flatart=%t\n", o.EqualizeLuminance, o.AutoLowLight, o.LabBinSize, o.ExactFlatArt)
+	if o.ChromaKey != nil {
+		fmt.Fprintf(h, "chromakey=%+v\n", *o.ChromaKey)
+	}
+	if o.BorderBackground != nil {
+		fmt.Fprintf(h, "border=%+v\n", *o.BorderBackground)
+	}
+	if o.Profile != nil {
+		fmt.Fprintf(h, "profile=%+v\n", *o.Profile)
+	}
+	if o.WhiteBalance != nil {
+		fmt.Fprintf(h, "whitebalance=%+v\n", *o.WhiteBalance)
+	}
+	fmt.Fprintf(h, "exifwb=%t merge=%v min=%v redistribute=%t sort=%d topn=%d names=%t\n",
+		o.AdaptExifWhiteBalance, o.MergeDeltaE, o.MinPercentage, o.RedistributeSmall, o.Sort, o.TopN, o.ColorNames)
+	return fmt.Sprintf("v%d-%x", AlgorithmVersion, h.Sum(nil)[:8])
+}
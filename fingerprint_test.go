@@ -0,0 +1,33 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import "testing"
+
+func TestFingerprintViewingConditions(t *testing.T) {
+	defer SetViewingConditions(DefaultViewingConditions())
+	cam16 := Options{Space: SpaceCAM16UCS}
+	lab := Options{Space: SpaceLAB}
+	before, beforeLAB := cam16.Fingerprint(), lab.Fingerprint()
+
+	vc := DefaultViewingConditions()
+	vc.Surround = SurroundDark
+	SetViewingConditions(vc)
+	if cam16.Fingerprint() == before {
+		t.Error("Expected other viewing conditions to change the CAM16-UCS fingerprint")
+	}
+	if lab.Fingerprint() != beforeLAB {
+		t.Error("Expected the viewing conditions not to change the LAB fingerprint")
+	}
+}
+
+func TestFingerprintIgnoresDebugImage(t *testing.T) {
+	opts := DefaultOptions()
+	debug := opts
+	debug.Arguments |= ArgumentDebugImage
+	if opts.Fingerprint() != debug.Fingerprint() {
+		t.Error("Expected ArgumentDebugImage not to change the fingerprint")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math"
+	"sync"
+)
+
+// fixedOne is 1.0 in the 16.16 fixed point numbers of the fixed point LAB conversion
+const fixedOne = 1 << 16
+
+// fixedTableShift is log2 of the number of input values between entries of the lookup tables, interpolated linearly.
+// The 16 bit colors are looked up in 16 bit steps, the XYZ values (with 24 fractional bits) in steps of 1/4096.
+const (
+	fixedLinearShift = 4
+	fixedLabFShift   = 12
+)
+
+// fixedXYZ converts linear RGB to XYZ relative to the D65 white point, the colorful matrix with 24 fractional bits
+var fixedXYZ = [3][3]int64{
+	{7279314, 6311898, 3185756},
+	{3567491, 11998539, 1211186},
+	{297859, 1836610, 14646256},
+}
+
+var (
+	fixedTablesOnce sync.Once
+	// fixedLinear maps 16 bit sRGB values to linear RGB with 24 fractional bits, fixedLabF applies the LAB f(t)
+	// function to those, giving 16.16 fixed point.
+	// Their entries are rounded to integers, so the last bit differences of the float math creating them (e.g. an
+	// assembly math.Pow) do not make results differ, TestFixedLABTables checks them.
+	fixedLinear [fixedOne>>fixedLinearShift + 1]int32
+	fixedLabF   [1<<(24-fixedLabFShift) + 2]int32
+)
+
+// fixedLAB is a LAB color in 16.16 fixed point, scaled as by colorful (L 0-1)
+type fixedLAB struct {
+	l, a, b int32
+}
+
+// initFixedTables fills the lookup tables, the fixedLabF table goes a bit beyond 1 as Z/Zn of white does
+func initFixedTables() {
+	for i := range fixedLinear {
+		v := float64(i<<fixedLinearShift) / 0xffff
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		fixedLinear[i] = int32(math.Round(v * (1 << 24)))
+	}
+	for i := range fixedLabF {
+		t := float64(i<<fixedLabFShift) / (1 << 24)
+		f := t/3*29/6*29/6 + 4.0/29
+		if t > 6.0/29*6.0/29*6.0/29 {
+			f = math.Cbrt(t)
+		}
+		fixedLabF[i] = int32(math.Round(f * fixedOne))
+	}
+}
+
+// lookupFixed interpolates the table at v, the entries being 1<<shift apart
+func lookupFixed(table []int32, v int64, shift uint) int64 {
+	v = max(v, 0)
+	i := min(v>>shift, int64(len(table)-2))
+	frac := v - i<<shift
+	return int64(table[i]) + (int64(table[i+1])-int64(table[i]))*frac>>shift
+}
+
+// toFixedLAB converts the (16 bit) color to LAB with integer math only
+func toFixedLAB(c ColorItem) fixedLAB {
+	fixedTablesOnce.Do(initFixedTables)
+	c16 := c.color16()
+	lin := [3]int64{
+		lookupFixed(fixedLinear[:], int64(c16.R), fixedLinearShift),
+		lookupFixed(fixedLinear[:], int64(c16.G), fixedLinearShift),
+		lookupFixed(fixedLinear[:], int64(c16.B), fixedLinearShift),
+	}
+	var f [3]int64
+	for i, row := range fixedXYZ {
+		// both have 24 fractional bits, so has the result
+		t := (row[0]*lin[0] + row[1]*lin[1] + row[2]*lin[2]) >> 24
+		f[i] = lookupFixed(fixedLabF[:], t, fixedLabFShift)
+	}
+	return fixedLAB{
+		l: int32((116*f[1] - 16*fixedOne) / 100),
+		a: int32(5 * (f[0] - f[1])),
+		b: int32(2 * (f[1] - f[2])),
+	}
+}
+
+// distanceFixedLAB is distanceLAB using the fixed point conversion, see ArgumentFixedPointLAB
+func distanceFixedLAB(c ColorItem, p ColorItem) float64 {
+	cl, pl := toFixedLAB(c), toFixedLAB(p)
+	dl, da, db := int64(cl.l-pl.l), int64(cl.a-pl.a), int64(cl.b-pl.b)
+	// the square root and division are exactly rounded everywhere, so the result is as portable as the tables
+	return math.Sqrt(float64(dl*dl+da*da+db*db)) / fixedOne
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// fixedLABColors returns colors spread over the RGB cube, with the extremes
+func fixedLABColors() []ColorItem {
+	var colors []ColorItem
+	for r := uint32(0); r <= 0xffff; r += 0xffff / 15 {
+		for g := uint32(0); g <= 0xffff; g += 0xffff / 15 {
+			for b := uint32(0); b <= 0xffff; b += 0xffff / 15 {
+				colors = append(colors, newColorItem16(r, g, b, 1))
+			}
+		}
+	}
+	return colors
+}
+
+func TestFixedLABErrorBound(t *testing.T) {
+	colors := fixedLABColors()
+	worst := 0.0
+	for i := 0; i < len(colors); i += 31 {
+		for j := range colors {
+			worst = max(worst, math.Abs(distanceFixedLAB(colors[i], colors[j])-distanceLAB(colors[i], colors[j])))
+		}
+	}
+	// the README promises less than 0.0005
+	if worst >= 0.0005 {
+		t.Errorf("Expected the fixed point distances to differ less than 0.0005, got %v", worst)
+	}
+	t.Logf("largest difference %v", worst)
+}
+
+// fixedTablesSum is the SHA-256 of the lookup tables, see TestFixedLABTables
+const fixedTablesSum = "0afd6e85314a086fa58e583e1415d22511a34ea8c045a310eeebae9ed9fae226"
+
+func TestFixedLABTables(t *testing.T) {
+	// the tables are computed with float math, rounded to integers; if that gave different tables on an
+	// architecture the fixed point results would differ there too
+	fixedTablesOnce.Do(initFixedTables)
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, fixedLinear)
+	binary.Write(h, binary.LittleEndian, fixedLabF)
+	if sum := fmt.Sprintf("%x", h.Sum(nil)); sum != fixedTablesSum {
+		t.Errorf("Expected the tables to have the SHA-256 %s, got %s", fixedTablesSum, sum)
+	}
+}
+
+func BenchmarkDistanceLAB(b *testing.B) {
+	benchmarkDistance(b, distanceLAB)
+}
+
+func BenchmarkDistanceFixedLAB(b *testing.B) {
+	benchmarkDistance(b, distanceFixedLAB)
+}
+
+// benchmarkDistance measures the distance between pairs of colors spread over the RGB cube
+func benchmarkDistance(b *testing.B, distance func(c, p ColorItem) float64) {
+	colors := fixedLABColors()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		distance(colors[i%len(colors)], colors[(i*7+1)%len(colors)])
+	}
+}
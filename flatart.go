@@ -0,0 +1,84 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"sort"
+)
+
+const (
+	// FlatArtMaxColors is the number of colors that have to cover FlatArtCoverage of the pixels of flat art
+	FlatArtMaxColors = 32
+
+	// FlatArtCoverage is the share (0-1) of the pixels that the FlatArtMaxColors most common colors of flat art cover,
+	// the rest are e.g. the anti-aliased edges between the colors
+	FlatArtCoverage = 0.99
+)
+
+// flatArtColors returns the unique colors of the image with their exact pixel counts, most common first, and checks
+// if the image is flat art (e.g. an illustration, logo or icon), where a few colors cover nearly all pixels
+func flatArtColors(img image.Image) ([]ColorItem, bool) {
+	colors, numPixels := extractColorsAsArray(img)
+	if numPixels == 0 || flatArtCoverage(colors, numPixels) < FlatArtCoverage {
+		return colors, false
+	}
+	sortCentroids(colors)
+	return colors, true
+}
+
+// flatArtCoverage returns the share (0-1) of the pixels covered by the FlatArtMaxColors most common of the colors
+func flatArtCoverage(colors []ColorItem, numPixels int) float64 {
+	// only the counts are sorted, sorting the colors breaks the many ties of photos by their hex string
+	counts := make([]int, len(colors))
+	for i, c := range colors {
+		counts[i] = c.Cnt
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(counts)))
+
+	covered := 0
+	for _, cnt := range counts[:min(len(counts), FlatArtMaxColors)] {
+		covered += cnt
+	}
+	return float64(covered) / float64(numPixels)
+}
+
+// classifySamples is the number of pixels Classify samples from larger images
+const classifySamples = 256 * 256
+
+// Classification tells if an image is a photo or a graphic, e.g. to route them to different processing
+type Classification struct {
+	// Graphic is set for graphics (illustrations, logos, icons, screenshots), and not set for photos
+	Graphic bool
+
+	// Confidence (0.5-1) is how sure the classification is, 0.5 for images at the boundary
+	Confidence float64
+
+	// Coverage is the share (0-1) of the pixels covered by the FlatArtMaxColors most common colors, at least
+	// FlatArtCoverage for graphics
+	Coverage float64
+}
+
+// Classify tells if the image is a photo or a graphic, using the flat art detection of Options.ExactFlatArt:
+// graphics have a few colors covering nearly all pixels. Larger images are sampled (ResizerSample), transparent
+// pixels are ignored. Graphics saved as lossy JPEG have many more colors and may be classified as photos.
+func Classify(img image.Image) Classification {
+	if b := img.Bounds(); b.Dx()*b.Dy() > classifySamples {
+		img = sampleResizer{samples: classifySamples}.Resize(img, 0, 0)
+	}
+	colors, numPixels := extractColorsAsArray(img)
+	if numPixels == 0 {
+		return Classification{Confidence: 0.5}
+	}
+
+	coverage := flatArtCoverage(colors, numPixels)
+	// the confidence falls to 0.5 as the uncovered share of the pixels approaches the one of the boundary
+	uncovered, boundary := 1-coverage, 1-FlatArtCoverage
+	return Classification{
+		Graphic:    coverage >= FlatArtCoverage,
+		Confidence: 1 - 0.5*min(uncovered, boundary)/max(uncovered, boundary),
+		Coverage:   coverage,
+	}
+}
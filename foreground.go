@@ -0,0 +1,125 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// edgeThreshold is the Sobel gradient magnitude (of L on a 0-100 scale) above which a pixel is an edge
+const edgeThreshold = 20.0
+
+// edgeForeground keeps only the foreground of the image, found by dilating the edge map and filling the enclosed
+// holes, the other pixels are made transparent. It returns false (leaving the image as is) if there are no edges,
+// and the number of pixels removed.
+func edgeForeground(img draw.Image) (bool, int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 3 || h < 3 {
+		return false, 0
+	}
+
+	lightness := make([]float64, w*h)
+	opaque := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c, ignore := createColor(img.At(b.Min.X+x, b.Min.Y+y))
+			if ignore {
+				continue
+			}
+			l, _, _ := c.toColorful().Lab()
+			lightness[y*w+x] = l * 100
+			opaque[y*w+x] = true
+		}
+	}
+
+	// Sobel, transparent neighbors count as the center pixel so the alpha border is no edge
+	at := func(x, y, center int) float64 {
+		x = min(max(x, 0), w-1)
+		y = min(max(y, 0), h-1)
+		if !opaque[y*w+x] {
+			return lightness[center]
+		}
+		return lightness[y*w+x]
+	}
+	edges := make([]bool, w*h)
+	numEdges := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if !opaque[i] {
+				continue
+			}
+			gx := at(x+1, y-1, i) + 2*at(x+1, y, i) + at(x+1, y+1, i) - at(x-1, y-1, i) - 2*at(x-1, y, i) - at(x-1, y+1, i)
+			gy := at(x-1, y+1, i) + 2*at(x, y+1, i) + at(x+1, y+1, i) - at(x-1, y-1, i) - 2*at(x, y-1, i) - at(x+1, y-1, i)
+			if math.Hypot(gx, gy) > edgeThreshold {
+				edges[i] = true
+				numEdges++
+			}
+		}
+	}
+	if numEdges == 0 {
+		return false, 0
+	}
+
+	radius := int(math.Max(1, float64(min(w, h))/40))
+	foreground := dilate(dilate(edges, w, h, radius, 1, 0), w, h, radius, 0, 1)
+
+	// everything not reachable from the border without crossing the dilated edges is enclosed by the subject
+	background := make([]bool, w*h)
+	var pointsToProcess []image.Point
+	for x := 0; x < w; x++ {
+		pointsToProcess = append(pointsToProcess, image.Point{X: x, Y: 0}, image.Point{X: x, Y: h - 1})
+	}
+	for y := 1; y < h-1; y++ {
+		pointsToProcess = append(pointsToProcess, image.Point{X: 0, Y: y}, image.Point{X: w - 1, Y: y})
+	}
+	var p image.Point
+	for len(pointsToProcess) > 0 {
+		p, pointsToProcess = pointsToProcess[len(pointsToProcess)-1], pointsToProcess[:len(pointsToProcess)-1]
+		i := p.Y*w + p.X
+		if background[i] || foreground[i] {
+			continue
+		}
+		background[i] = true
+		for _, n := range []image.Point{{X: p.X - 1, Y: p.Y}, {X: p.X + 1, Y: p.Y}, {X: p.X, Y: p.Y - 1}, {X: p.X, Y: p.Y + 1}} {
+			if n.X >= 0 && n.X < w && n.Y >= 0 && n.Y < h {
+				pointsToProcess = append(pointsToProcess, n)
+			}
+		}
+	}
+
+	removed := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if background[y*w+x] && opaque[y*w+x] {
+				markPixel(b.Min.X+x, b.Min.Y+y, &img)
+				removed++
+			}
+		}
+	}
+	return true, removed
+}
+
+// dilate sets every pixel within radius (in the direction dx, dy) of a set pixel
+func dilate(mask []bool, w, h, radius, dx, dy int) []bool {
+	out := make([]bool, len(mask))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !mask[y*w+x] {
+				continue
+			}
+			for r := -radius; r <= radius; r++ {
+				sx, sy := x+r*dx, y+r*dy
+				if sx >= 0 && sx < w && sy >= 0 && sy < h {
+					out[sy*w+sx] = true
+				}
+			}
+		}
+	}
+	return out
+}
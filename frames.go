@@ -0,0 +1,148 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+)
+
+// FrameSampleMode defines which frames of an animation are analyzed
+type FrameSampleMode int
+
+const (
+	// FrameSampleFirstMiddleLast analyzes the first, the middle and the last frame
+	FrameSampleFirstMiddleLast FrameSampleMode = iota
+	// FrameSampleEveryNth analyzes every Nth frame, starting with the first one
+	FrameSampleEveryNth
+)
+
+// FrameSampling configures which frames of an animation are analyzed
+type FrameSampling struct {
+	Mode FrameSampleMode
+
+	// N is the step used by FrameSampleEveryNth, values below 1 are treated as 1 (all frames)
+	N int
+}
+
+// FrameResult contains the colors found in a single frame
+type FrameResult struct {
+	// Index is the position of the frame in the animation (zero based)
+	Index int
+	Result
+}
+
+// FramesResult contains the colors per analyzed frame, and the colors of all analyzed frames combined
+type FramesResult struct {
+	Frames    []FrameResult
+	Aggregate Result
+}
+
+// indices returns the (sorted, unique) indices of the frames to analyze
+func (s FrameSampling) indices(numFrames int) []int {
+	if numFrames <= 0 {
+		return nil
+	}
+
+	var idx []int
+	switch s.Mode {
+	case FrameSampleEveryNth:
+		n := s.N
+		if n < 1 {
+			n = 1
+		}
+		for i := 0; i < numFrames; i += n {
+			idx = append(idx, i)
+		}
+	default:
+		for _, i := range []int{0, numFrames / 2, numFrames - 1} {
+			if len(idx) == 0 || idx[len(idx)-1] != i {
+				idx = append(idx, i)
+			}
+		}
+	}
+	return idx
+}
+
+// kmeansFrames finds the colors of each frame, and of all frames combined with the pixels of each frame counted
+// weights[i] times (nil counts all frames once, e.g. weighting by the frame duration).
+// Frames without any usable pixels get an empty result instead of failing the whole animation.
+func kmeansFrames(frames []image.Image, indices []int, weights []int, opts Options) (FramesResult, error) {
+	var res FramesResult
+	var histograms [][]ColorItem
+	skipped := 0
+	// clustered and masked are the pixels of all frames, for the shares of the aggregated colors
+	clustered, masked := 0, 0
+
+	for i, frame := range frames {
+		if err := opts.validate(frame); err != nil {
+			return FramesResult{}, err
+		}
+		img, prep := opts.prepare(frame)
+		if err := opts.debug(img); err != nil {
+			return FramesResult{}, err
+		}
+		allColors := opts.colors(img)
+		if weights == nil {
+			histograms = append(histograms, allColors)
+		} else {
+			weighted := make([]ColorItem, len(allColors))
+			for j, c := range allColors {
+				c.Cnt *= weights[i]
+				weighted[j] = c
+			}
+			histograms = append(histograms, weighted)
+		}
+		skipped += prep.skipped
+		clustered += prep.stats.ClusteredPixels
+		masked += prep.stats.maskedPixels()
+
+		fr := FrameResult{Index: indices[i]}
+		fr.SkippedPixels = prep.skipped
+		fr.Stats = prep.stats
+		fr.Metadata = opts.Metadata
+		fr.Fingerprint = opts.Fingerprint()
+		if len(allColors) > 0 {
+			centroids, err := kmeansColors(opts.k(), allColors, opts.arguments())
+			if err != nil {
+				return FramesResult{}, err
+			}
+			setShares(centroids, prep.stats.ClusteredPixels, prep.stats.maskedPixels())
+			fr.Colors = centroids
+			opts.finish(&fr.Result)
+		}
+		res.Frames = append(res.Frames, fr)
+	}
+
+	arguments := opts.arguments()
+	if weights != nil {
+		arguments |= ArgumentCountWeighted
+	}
+	centroids, err := kmeansColors(opts.k(), mergeColors(histograms), arguments)
+	if err != nil {
+		return FramesResult{}, err
+	}
+	setShares(centroids, clustered, masked)
+	res.Aggregate = Result{Colors: centroids, SkippedPixels: skipped, Metadata: opts.Metadata, Fingerprint: opts.Fingerprint()}
+	opts.finish(&res.Aggregate)
+	return res, nil
+}
+
+// mergeColors combines several color histograms into one, summing the count of identical colors
+func mergeColors(histograms [][]ColorItem) []ColorItem {
+	m := make(map[uint64]int)
+	var merged []ColorItem
+	for _, h := range histograms {
+		for _, c := range h {
+			key := c.key()
+			if idx, ok := m[key]; ok {
+				merged[idx].Cnt += c.Cnt
+				continue
+			}
+			m[key] = len(merged)
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+)
+
+func init() {
+	registerBackend("gif-encoder")
+}
+
+// GIFPaletteMode defines if a re-colored GIF gets one palette for all frames or one palette per frame
+type GIFPaletteMode int
+
+const (
+	// GIFPaletteGlobal uses a single palette for all frames (smallest file, no local color tables)
+	GIFPaletteGlobal GIFPaletteMode = iota
+	// GIFPalettePerFrame uses an optimized palette for each frame
+	GIFPalettePerFrame
+)
+
+// ReencodeGIF decodes the GIF from r, re-colors it (see RecolorGIF) and writes it to w
+func ReencodeGIF(r io.Reader, w io.Writer, numColors int, mode GIFPaletteMode, arguments int) error {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return err
+	}
+	recolored, err := RecolorGIF(g, numColors, mode, arguments)
+	if err != nil {
+		return err
+	}
+	return gif.EncodeAll(w, recolored)
+}
+
+// RecolorGIF quantizes the frames of the GIF to (at most) numColors colors using k-means,
+// arguments are the bits defining distance and centroid calculation, see constants Argument*.
+// Transparency is preserved, using one of the numColors entries when a frame contains transparent pixels.
+func RecolorGIF(g *gif.GIF, numColors int, mode GIFPaletteMode, arguments int) (*gif.GIF, error) {
+	if numColors < 1 || numColors > 256 {
+		return nil, fmt.Errorf("Failed, numColors must be between 1 and 256, got %d", numColors)
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("Failed, GIF without frames")
+	}
+
+	out := &gif.GIF{
+		Image:           make([]*image.Paletted, len(g.Image)),
+		Delay:           g.Delay,
+		LoopCount:       g.LoopCount,
+		Disposal:        g.Disposal,
+		Config:          g.Config,
+		BackgroundIndex: g.BackgroundIndex,
+	}
+
+	if mode == GIFPalettePerFrame {
+		for i, frame := range g.Image {
+			histogram, transparent := paletteHistogram(frame)
+			palette, err := quantizePalette(histogram, transparent, numColors, arguments)
+			if err != nil {
+				return nil, fmt.Errorf("Failed quantizing frame %d: %v", i, err)
+			}
+			out.Image[i] = remapPaletted(frame, palette, arguments)
+		}
+		out.Config.ColorModel = nil
+		return out, nil
+	}
+
+	var histograms [][]ColorItem
+	anyTransparent := false
+	for _, frame := range g.Image {
+		histogram, transparent := paletteHistogram(frame)
+		histograms = append(histograms, histogram)
+		anyTransparent = anyTransparent || transparent
+	}
+	palette, err := quantizePalette(mergeColors(histograms), anyTransparent, numColors, arguments)
+	if err != nil {
+		return nil, err
+	}
+	for i, frame := range g.Image {
+		out.Image[i] = remapPaletted(frame, palette, arguments)
+	}
+	// identical local palettes are written as the global color table only
+	out.Config.ColorModel = palette
+	if g.Config.ColorModel != nil {
+		if bg, ok := g.Config.ColorModel.(color.Palette); ok && int(g.BackgroundIndex) < len(bg) {
+			out.BackgroundIndex = uint8(palette.Index(bg[g.BackgroundIndex]))
+		}
+	}
+	return out, nil
+}
+
+// quantizePalette clusters the histogram into a palette, with a trailing transparent entry if needed
+func quantizePalette(histogram []ColorItem, transparent bool, numColors int, arguments int) (color.Palette, error) {
+	k := numColors
+	if transparent {
+		k--
+	}
+
+	var palette color.Palette
+	if k > 0 && len(histogram) > 0 {
+		centroids, err := kmeansColors(k, histogram, arguments)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range centroids {
+			// empty clusters do not represent any pixel
+			if c.Cnt == 0 {
+				continue
+			}
+			palette = append(palette, color.RGBA{R: uint8(c.Color.R), G: uint8(c.Color.G), B: uint8(c.Color.B), A: 0xff})
+		}
+	}
+	if transparent || len(palette) == 0 {
+		palette = append(palette, color.RGBA{})
+	}
+	return palette, nil
+}
+
+// remapPaletted creates a copy of the frame using the new palette, mapping each old palette entry to the closest new one
+func remapPaletted(frame *image.Paletted, palette color.Palette, arguments int) *image.Paletted {
+	var centroids []ColorItem
+	transparentIdx := -1
+	for i, c := range palette {
+		item, ignore := createColor(c)
+		if ignore {
+			transparentIdx = i
+		}
+		centroids = append(centroids, item)
+	}
+
+	mapping := make([]uint8, len(frame.Palette))
+	for i, c := range frame.Palette {
+		item, ignore := createColor(c)
+		if ignore && transparentIdx >= 0 {
+			mapping[i] = uint8(transparentIdx)
+			continue
+		}
+		mapping[i] = uint8(findClosestOpaque(arguments, item, centroids, transparentIdx))
+	}
+
+	out := image.NewPaletted(frame.Rect, palette)
+	b := frame.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			idx := int(frame.ColorIndexAt(x, y))
+			if idx < len(mapping) {
+				out.SetColorIndex(x, y, mapping[idx])
+			}
+		}
+	}
+	return out
+}
+
+// findClosestOpaque is findClosest skipping the transparent palette entry
+func findClosestOpaque(arguments int, c ColorItem, centroids []ColorItem, transparentIdx int) int {
+	closestIdx := -1
+	closestDistance := 0.0
+	for i := range centroids {
+		if i == transparentIdx {
+			continue
+		}
+		d := distance(arguments, c, centroids[i])
+		if closestIdx == -1 || d < closestDistance {
+			closestIdx = i
+			closestDistance = d
+		}
+	}
+	if closestIdx == -1 {
+		return 0
+	}
+	return closestIdx
+}
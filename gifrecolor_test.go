@@ -0,0 +1,120 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// recolorTestGIF returns two 4x1 frames, the first one reddish with a transparent pixel, the second one bluish
+func recolorTestGIF() *gif.GIF {
+	palette := color.Palette{
+		color.RGBA{R: 0xff, A: 0xff}, color.RGBA{R: 0xf0, G: 0x10, A: 0xff},
+		color.RGBA{B: 0xff, A: 0xff}, color.RGBA{G: 0x10, B: 0xf0, A: 0xff},
+		color.RGBA{},
+	}
+	frame := func(indices ...uint8) *image.Paletted {
+		img := image.NewPaletted(image.Rect(0, 0, 4, 1), palette)
+		copy(img.Pix, indices)
+		return img
+	}
+	return &gif.GIF{
+		Image:  []*image.Paletted{frame(0, 1, 0, 4), frame(2, 3, 2, 3)},
+		Delay:  []int{10, 20},
+		Config: image.Config{ColorModel: palette, Width: 4, Height: 1},
+	}
+}
+
+// isReddish/isBluish check the dominating channel of an opaque color
+func isReddish(c color.Color) bool {
+	r, g, b, a := c.RGBA()
+	return a == 0xffff && r > 0x8000 && g < 0x8000 && b < 0x8000
+}
+
+func isBluish(c color.Color) bool {
+	r, g, b, a := c.RGBA()
+	return a == 0xffff && b > 0x8000 && r < 0x8000 && g < 0x8000
+}
+
+func TestRecolorGIF(t *testing.T) {
+	g := recolorTestGIF()
+	out, err := RecolorGIF(g, 3, GIFPaletteGlobal, ArgumentDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	palette, ok := out.Config.ColorModel.(color.Palette)
+	if !ok || len(palette) != 3 {
+		t.Fatalf("Expected a global palette of 3 colors, got %v", out.Config.ColorModel)
+	}
+	if out.Delay[0] != 10 || out.Delay[1] != 20 {
+		t.Errorf("Expected the delays to be kept, got %v", out.Delay)
+	}
+	for i, frame := range out.Image {
+		if len(frame.Palette) != len(palette) {
+			t.Errorf("Frame %d: expected the global palette, got %v", i, frame.Palette)
+		}
+		for x := 0; x < 4; x++ {
+			c := frame.At(x, 0)
+			switch {
+			case i == 0 && x == 3:
+				if _, _, _, a := c.RGBA(); a != 0 {
+					t.Errorf("Frame %d, x %d: expected transparency, got %v", i, x, c)
+				}
+			case i == 0 && !isReddish(c), i == 1 && !isBluish(c):
+				t.Errorf("Frame %d, x %d: got %v", i, x, c)
+			}
+		}
+	}
+
+	out, err = RecolorGIF(g, 1, GIFPalettePerFrame, ArgumentDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Config.ColorModel != nil {
+		t.Error("Expected no global palette")
+	}
+	// the transparent frame keeps only the transparent entry, the other one gets a single blue
+	if len(out.Image[0].Palette) != 1 || len(out.Image[1].Palette) != 1 || !isBluish(out.Image[1].Palette[0]) {
+		t.Errorf("Expected one color per frame, got %v and %v", out.Image[0].Palette, out.Image[1].Palette)
+	}
+
+	for _, n := range []int{0, 257} {
+		if _, err := RecolorGIF(g, n, GIFPaletteGlobal, ArgumentDefault); err == nil {
+			t.Errorf("Expected an error for %d colors", n)
+		}
+	}
+	if _, err := RecolorGIF(&gif.GIF{}, 2, GIFPaletteGlobal, ArgumentDefault); err == nil {
+		t.Error("Expected an error for a GIF without frames")
+	}
+}
+
+func TestReencodeGIF(t *testing.T) {
+	var in, out bytes.Buffer
+	if err := gif.EncodeAll(&in, recolorTestGIF()); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReencodeGIF(&in, &out, 2, GIFPalettePerFrame, ArgumentDefault); err != nil {
+		t.Fatal(err)
+	}
+	g, err := gif.DecodeAll(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 2 || len(g.Image[0].Palette) > 2 || len(g.Image[1].Palette) > 2 {
+		t.Fatalf("Expected two frames of at most 2 colors, got %d frames", len(g.Image))
+	}
+	if !isReddish(g.Image[0].At(0, 0)) || !isBluish(g.Image[1].At(0, 0)) {
+		t.Errorf("Expected a red and a blue frame, got %v and %v", g.Image[0].At(0, 0), g.Image[1].At(0, 0))
+	}
+	if err := ReencodeGIF(bytes.NewReader([]byte("GIF89a")), &out, 2, GIFPaletteGlobal, ArgumentDefault); err == nil {
+		t.Error("Expected an error for a truncated GIF")
+	}
+}
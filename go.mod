@@ -5,5 +5,6 @@ go 1.22.3
 require (
 	github.com/lucasb-eyer/go-colorful v1.2.0
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
-	github.com/oliamb/cutter v0.2.2
 )
+
+require golang.org/x/net v0.35.0
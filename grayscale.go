@@ -0,0 +1,124 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"sort"
+)
+
+// isGrayscale checks if all colors are gray levels (R=G=B), e.g. coming from an image.Gray
+func isGrayscale(allColors []ColorItem) bool {
+	for _, c := range allColors {
+		if c.Color16.R != c.Color16.G || c.Color16.R != c.Color16.B {
+			return false
+		}
+	}
+	return true
+}
+
+// kmeansGray clusters gray levels in one dimension starting from the seeds, which is much faster than the 3-D path.
+// It is only used with the RGB distance: between gray levels it grows with the difference of the levels, so the
+// closest centroid, ties going to the first seed as in findClosest, and the result are the same. The LAB based
+// distances are not, as L is not linear in the level.
+func kmeansGray(allColors []ColorItem, seeds []ColorItem, arguments int) ([]ColorItem, Convergence) {
+	levels := make([]ColorItem, len(allColors))
+	copy(levels, allColors)
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Color16.R < levels[j].Color16.R })
+
+	// the centroids keep the order of the seeds, the levels are assigned to their indices
+	centroids := make([]float64, len(seeds))
+	for i, seed := range seeds {
+		centroids[i] = float64(seed.Color16.R)
+	}
+	assignment := make([]int, len(levels))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+
+	rounds := 0
+	maxRounds := 5000
+	changes := 1
+	for changes > 0 && rounds < maxRounds {
+		changes = 0
+
+		// the levels are sorted, so the closest centroid only moves forward in the order of the centroids
+		order := make([]int, len(centroids))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool {
+			a, b := order[i], order[j]
+			return centroids[a] < centroids[b] || (centroids[a] == centroids[b] && a < b)
+		})
+		c := 0
+		for i, level := range levels {
+			v := float64(level.Color16.R)
+			for c < len(order)-1 && closerGray(v, centroids, order[c+1], order[c]) {
+				c++
+			}
+			if assignment[i] != order[c] {
+				assignment[i] = order[c]
+				changes++
+			}
+		}
+
+		centroids = grayCentroids(levels, assignment, centroids, arguments)
+		rounds++
+	}
+
+	var result []ColorItem
+	for _, cluster := range groupGray(levels, assignment, len(centroids)) {
+		if len(cluster) == 0 {
+			continue
+		}
+		result = append(result, grayCentroid(cluster, arguments))
+	}
+	sortCentroids(result)
+	return result, Convergence{Iterations: rounds, Converged: changes == 0, Inertia: inertia(levels, result, arguments)}
+}
+
+// closerGray tells if the level v is closer to centroid a than to b, or as close and a is the first
+func closerGray(v float64, centroids []float64, a, b int) bool {
+	da, db := abs(centroids[a]-v), abs(centroids[b]-v)
+	return da < db || (da == db && a < b)
+}
+
+// groupGray splits the levels into their assigned clusters
+func groupGray(levels []ColorItem, assignment []int, k int) [][]ColorItem {
+	clusters := make([][]ColorItem, k)
+	for i, level := range levels {
+		clusters[assignment[i]] = append(clusters[assignment[i]], level)
+	}
+	return clusters
+}
+
+// grayCentroids calculates the new centroids, an empty cluster keeps its previous centroid
+func grayCentroids(levels []ColorItem, assignment []int, previous []float64, arguments int) []float64 {
+	centroids := make([]float64, len(previous))
+	for i, cluster := range groupGray(levels, assignment, len(previous)) {
+		if len(cluster) == 0 {
+			centroids[i] = previous[i]
+			continue
+		}
+		centroids[i] = float64(grayCentroid(cluster, arguments).Color16.R)
+	}
+	return centroids
+}
+
+// grayCentroid calculates the centroid of a cluster using mean or median (see ArgumentAverageMean)
+func grayCentroid(cluster []ColorItem, arguments int) ColorItem {
+	if IsBitSet(arguments, ArgumentAverageMean) {
+		return mean(cluster, IsBitSet(arguments, ArgumentCountWeighted))
+	}
+	return median(cluster, IsBitSet(arguments, ArgumentCountWeighted))
+}
+
+// abs returns the absolute value
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// grayLevels returns n distinct gray levels with random counts
+func grayLevels(rnd *rand.Rand, n int) []ColorItem {
+	var levels []ColorItem
+	for _, v := range rnd.Perm(256)[:n] {
+		levels = append(levels, newColorItem16(uint32(v)*0x101, uint32(v)*0x101, uint32(v)*0x101, 1+rnd.Intn(50)))
+	}
+	return levels
+}
+
+func TestKmeansGrayMatchesLloyd(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, arguments := range []int{ArgumentDefault, ArgumentAverageMean, ArgumentSeedRandom, ArgumentCountWeighted | ArgumentAverageMean} {
+		for i := 0; i < 20; i++ {
+			levels := grayLevels(rnd, 40)
+			seeds, err := kmeansSeed(4, levels, arguments|ArgumentDeterministic)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gray, _ := kmeansGray(levels, seeds, arguments)
+			want, _ := lloyd(levels, append([]ColorItem(nil), seeds...), arguments)
+			assertSameColors(t, gray, want)
+		}
+	}
+}
+
+func TestGrayFastPathOnlyForRGB(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	levels := grayLevels(rnd, 40)
+	// the LAB distance is not linear in the gray level, the 3-D path is used
+	got, _, err := clusterColors(4, append([]ColorItem(nil), levels...), ArgumentLAB|ArgumentDeterministic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seeds, err := kmeansSeed(4, levels, ArgumentLAB|ArgumentDeterministic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := lloyd(levels, seeds, ArgumentLAB|ArgumentDeterministic)
+	assertSameColors(t, got, want)
+}
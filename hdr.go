@@ -0,0 +1,134 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// FloatImage is an HDR image with linear light floating point channels (sRGB/Rec. 709 primaries, 1.0 = diffuse white)
+type FloatImage interface {
+	Bounds() image.Rectangle
+	// RGBAFloat returns the linear light color at x, y; alpha is 0-1 and not premultiplied
+	RGBAFloat(x, y int) (r, g, b, a float32)
+}
+
+// HDRImage is a FloatImage stored as planes, e.g. converted from EXR/HDR frames
+type HDRImage struct {
+	Width, Height int
+
+	// R, G, B are the linear light planes, row by row (Width*Height values each)
+	R, G, B []float32
+
+	// A is the optional alpha plane, if nil all pixels are opaque
+	A []float32
+}
+
+// ToneMapping defines how HDR values are mapped to the displayable range before clustering
+type ToneMapping int
+
+const (
+	// ToneMapReinhard compresses the luminance with L/(1+L), keeping the hue (default)
+	ToneMapReinhard ToneMapping = iota
+	// ToneMapACES uses a filmic curve approximating the ACES reference rendering
+	ToneMapACES
+	// ToneMapClamp clips everything above 1.0, highlights become white
+	ToneMapClamp
+)
+
+// HDROptions configures how an HDR image is converted before clustering
+type HDROptions struct {
+	ToneMapping ToneMapping
+
+	// Exposure in stops, applied before tone mapping
+	Exposure float64
+}
+
+// Bounds implements FloatImage
+func (h *HDRImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, h.Width, h.Height)
+}
+
+// RGBAFloat implements FloatImage
+func (h *HDRImage) RGBAFloat(x, y int) (r, g, b, a float32) {
+	i := y*h.Width + x
+	a = 1
+	if h.A != nil {
+		a = h.A[i]
+	}
+	return h.R[i], h.G[i], h.B[i], a
+}
+
+// validate checks that the planes match the dimensions
+func (h *HDRImage) validate() error {
+	n := h.Width * h.Height
+	if h.Width <= 0 || h.Height <= 0 {
+		return fmt.Errorf("Failed, invalid HDR image dimensions %dx%d", h.Width, h.Height)
+	}
+	if len(h.R) != n || len(h.G) != n || len(h.B) != n || (h.A != nil && len(h.A) != n) {
+		return fmt.Errorf("Failed, HDR planes do not match the dimensions %dx%d", h.Width, h.Height)
+	}
+	return nil
+}
+
+// ToneMap converts the HDR image into a (16 bit) sRGB image
+func ToneMap(img FloatImage, hdr HDROptions) image.Image {
+	exposure := math.Pow(2, hdr.Exposure)
+	b := img.Bounds()
+	out := image.NewNRGBA64(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.RGBAFloat(x, y)
+			mapped := toneMap(hdr.ToneMapping, [3]float64{float64(r) * exposure, float64(g) * exposure, float64(bl) * exposure})
+			out.SetNRGBA64(x, y, color.NRGBA64{
+				R: uint16(math.Round(clamp01(srgbEncode(mapped[0])) * 0xffff)),
+				G: uint16(math.Round(clamp01(srgbEncode(mapped[1])) * 0xffff)),
+				B: uint16(math.Round(clamp01(srgbEncode(mapped[2])) * 0xffff)),
+				A: uint16(math.Round(clamp01(float64(a)) * 0xffff)),
+			})
+		}
+	}
+	return out
+}
+
+// KmeansHDR tone maps the HDR image and finds its prominent colors
+func KmeansHDR(img FloatImage, hdr HDROptions, opts Options) (Result, error) {
+	if h, ok := img.(*HDRImage); ok {
+		if err := h.validate(); err != nil {
+			return Result{}, err
+		}
+	}
+	return KmeansWithOptions(ToneMap(img, hdr), opts)
+}
+
+// toneMap maps linear HDR values to linear values in 0-1
+func toneMap(mapping ToneMapping, v [3]float64) [3]float64 {
+	for i := range v {
+		// negative values are out of gamut noise
+		v[i] = math.Max(v[i], 0)
+	}
+
+	switch mapping {
+	case ToneMapClamp:
+		return [3]float64{math.Min(v[0], 1), math.Min(v[1], 1), math.Min(v[2], 1)}
+	case ToneMapACES:
+		for i := range v {
+			x := v[i]
+			v[i] = clamp01((x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14))
+		}
+		return v
+	}
+
+	// Reinhard on luminance, scaling the channels keeps the hue
+	l := 0.2126*v[0] + 0.7152*v[1] + 0.0722*v[2]
+	if l <= 0 {
+		return [3]float64{}
+	}
+	scale := (l / (1 + l)) / l
+	return [3]float64{math.Min(v[0]*scale, 1), math.Min(v[1]*scale, 1), math.Min(v[2]*scale, 1)}
+}
@@ -0,0 +1,53 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math"
+)
+
+// DefaultLabBinSize is the recommended bin size for Options.LabBinSize, e.g. for server workloads
+const DefaultLabBinSize = 4.0
+
+// labHistogram groups the colors into LAB bins of binSize (L 0-100 scale) per side.
+// Each bin is represented by the count weighted mean of its colors, with the summed count.
+func labHistogram(allColors []ColorItem, binSize float64) []ColorItem {
+	type bin struct {
+		r, g, b float64
+		cnt     int
+	}
+	bins := make(map[[3]int]*bin)
+	var order [][3]int
+
+	for _, c := range allColors {
+		l, a, b := c.toColorful().Lab()
+		idx := [3]int{
+			int(math.Floor(l * 100 / binSize)),
+			int(math.Floor(a * 100 / binSize)),
+			int(math.Floor(b * 100 / binSize)),
+		}
+		bn, ok := bins[idx]
+		if !ok {
+			bn = &bin{}
+			bins[idx] = bn
+			order = append(order, idx)
+		}
+		c16 := c.color16()
+		w := float64(c.Cnt)
+		bn.r += float64(c16.R) * w
+		bn.g += float64(c16.G) * w
+		bn.b += float64(c16.B) * w
+		bn.cnt += c.Cnt
+	}
+
+	histogram := make([]ColorItem, 0, len(order))
+	for _, idx := range order {
+		bn := bins[idx]
+		w := float64(bn.cnt)
+		histogram = append(histogram, newColorItem16(
+			uint32(math.Round(bn.r/w)), uint32(math.Round(bn.g/w)), uint32(math.Round(bn.b/w)), bn.cnt))
+	}
+	return histogram
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// exampleImages decodes the photos of the example directory
+func exampleImages(b *testing.B) []image.Image {
+	b.Helper()
+	paths, err := filepath.Glob(filepath.Join("example", "*.jpg"))
+	if err != nil || len(paths) == 0 {
+		b.Skip("no example images")
+	}
+	var images []image.Image
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			b.Fatalf("Failed decoding %s: %v", path, err)
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// BenchmarkLabBinSize measures the table of the Histogram mode section of the README: the time clustering the
+// example images (k=3) with each bin size, the colors clustered and how far (CIEDE2000 delta E, 0-100 scale) the
+// colors found are from the closest ones found clustering all colors count weighted
+func BenchmarkLabBinSize(b *testing.B) {
+	images := exampleImages(b)
+	for _, size := range []uint{400, 80} {
+		// the colors found without bins, to compare to
+		exact := make([][]ColorItem, len(images))
+		for _, binSize := range []float64{0, 2, 4, 8} {
+			if size == 80 && (binSize == 2 || binSize == 8) {
+				continue
+			}
+			b.Run(fmt.Sprintf("size=%d/bin=%v", size, binSize), func(b *testing.B) {
+				opts := DefaultOptions()
+				opts.K = 3
+				opts.Size = size
+				opts.LabBinSize = binSize
+				opts.Arguments = ArgumentCountWeighted | ArgumentDeterministic
+				colors := make([][]ColorItem, len(images))
+				clustered := 0
+				for i, src := range images {
+					img, _ := opts.prepare(src)
+					colors[i] = opts.colors(img)
+					clustered += len(colors[i])
+				}
+
+				var found [][]ColorItem
+				b.ResetTimer()
+				for n := 0; n < b.N; n++ {
+					found = found[:0]
+					for _, c := range colors {
+						centroids, _, err := clusterColors(opts.k(), c, opts.arguments())
+						if err != nil {
+							b.Fatal(err)
+						}
+						found = append(found, centroids)
+					}
+				}
+				b.StopTimer()
+
+				b.ReportMetric(float64(clustered), "colors")
+				if binSize == 0 {
+					copy(exact, found)
+					return
+				}
+				if exact[0] == nil {
+					// the benchmark without bins was filtered out
+					return
+				}
+				var sum, worst float64
+				var cnt int
+				for i, centroids := range found {
+					for _, c := range centroids {
+						closest := math.Inf(1)
+						for _, e := range exact[i] {
+							closest = math.Min(closest, distanceCIEDE2000(c, e)*100)
+						}
+						sum += closest
+						worst = math.Max(worst, closest)
+						cnt++
+					}
+				}
+				b.ReportMetric(sum/float64(cnt), "avg-ΔE")
+				b.ReportMetric(worst, "worst-ΔE")
+			})
+		}
+	}
+}
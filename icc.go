@@ -0,0 +1,474 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// ErrNoICCProfile is returned when no embedded ICC profile is found
+var ErrNoICCProfile = fmt.Errorf("Failed, no ICC profile found")
+
+// ICCProfile describes an RGB color space (matrix/TRC based) that pixels can be converted from into sRGB
+type ICCProfile struct {
+	// Name is informational only
+	Name string
+
+	// toXYZ converts linear RGB to XYZ (D50, as used by the ICC profile connection space)
+	toXYZ [3][3]float64
+	trc   [3]toneCurve
+
+	// toSRGB converts linear RGB to linear sRGB
+	toSRGB [3][3]float64
+}
+
+// toneCurve converts an encoded channel value (0-1) to linear light
+type toneCurve struct {
+	// table is used if set, otherwise the parametric function
+	table []float64
+
+	// funcType and params follow the ICC parametricCurveType definition (g, a, b, c, d, e, f)
+	funcType int
+	params   [7]float64
+}
+
+var (
+	// srgbCurve is the sRGB transfer function
+	srgbCurve = toneCurve{funcType: 3, params: [7]float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045}}
+
+	// ProfileAdobeRGB is Adobe RGB (1998)
+	ProfileAdobeRGB = newRGBProfile("Adobe RGB (1998)", [3][2]float64{{0.64, 0.33}, {0.21, 0.71}, {0.15, 0.06}}, toneCurve{params: [7]float64{563.0 / 256.0}})
+
+	// ProfileDisplayP3 is Display P3 (DCI-P3 primaries, D65 white point and the sRGB transfer function)
+	ProfileDisplayP3 = newRGBProfile("Display P3", [3][2]float64{{0.680, 0.320}, {0.265, 0.690}, {0.150, 0.060}}, srgbCurve)
+
+	// ProfileRec2020 is ITU-R BT.2020 (D65 white point and the BT.2020 transfer function)
+	ProfileRec2020 = newRGBProfile("Rec. 2020", [3][2]float64{{0.708, 0.292}, {0.170, 0.797}, {0.131, 0.046}}, toneCurve{funcType: 3, params: [7]float64{1 / 0.45, 1 / 1.099, 0.099 / 1.099, 1 / 4.5, 0.081}})
+
+	// ProfileSRGB is sRGB, converting from it leaves the pixels untouched
+	ProfileSRGB = newRGBProfile("sRGB", [3][2]float64{{0.64, 0.33}, {0.30, 0.60}, {0.15, 0.06}}, srgbCurve)
+)
+
+// whiteD65 and whiteD50 are the XYZ coordinates of the standard illuminants
+var (
+	whiteD65 = [3]float64{0.95047, 1.0, 1.08883}
+	whiteD50 = [3]float64{0.96422, 1.0, 0.82521}
+)
+
+// bradford is the Bradford cone response matrix used for chromatic adaptation
+var bradford = [3][3]float64{
+	{0.8951, 0.2664, -0.1614},
+	{-0.7502, 1.7135, 0.0367},
+	{0.0389, -0.0685, 1.0296},
+}
+
+// srgbToXYZD50 is the sRGB matrix adapted to D50, as found in the sRGB ICC profile
+var srgbToXYZD50 = [3][3]float64{
+	{0.4360747, 0.3850649, 0.1430804},
+	{0.2225045, 0.7168786, 0.0606169},
+	{0.0139322, 0.0971045, 0.7141733},
+}
+
+// newRGBProfile creates a profile from D65 based primaries (xy chromaticities) and a transfer function
+func newRGBProfile(name string, primaries [3][2]float64, curve toneCurve) *ICCProfile {
+	m := rgbToXYZMatrix(primaries, whiteD65)
+	p := &ICCProfile{Name: name, trc: [3]toneCurve{curve, curve, curve}}
+	p.toXYZ = mulMatrix(AdaptationMatrix(whiteD65, whiteD50, AdaptBradford), m)
+	p.toSRGB = mulMatrix(invertMatrix(srgbToXYZD50), p.toXYZ)
+	return p
+}
+
+// ParseICCProfile parses an RGB ICC profile based on colorant tags and tone reproduction curves (the common case for
+// Adobe RGB, Display P3, ProPhoto etc.). LUT based profiles are not supported.
+func ParseICCProfile(data []byte) (*ICCProfile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, fmt.Errorf("Failed, not an ICC profile")
+	}
+	if string(data[16:20]) != "RGB " {
+		return nil, fmt.Errorf("Failed, unsupported ICC color space %q", data[16:20])
+	}
+
+	tags := make(map[string][]byte)
+	numTags := int(binary.BigEndian.Uint32(data[128:132]))
+	for i := 0; i < numTags; i++ {
+		entry := 132 + i*12
+		if entry+12 > len(data) {
+			return nil, fmt.Errorf("Failed, truncated ICC tag table")
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+4 : entry+8]))
+		size := int(binary.BigEndian.Uint32(data[entry+8 : entry+12]))
+		// compared without adding, the sum can overflow an int on 32 bit platforms
+		if offset < 0 || size < 0 || size > len(data)-offset {
+			return nil, fmt.Errorf("Failed, ICC tag outside of profile")
+		}
+		tags[string(data[entry:entry+4])] = data[offset : offset+size]
+	}
+
+	p := &ICCProfile{Name: iccDescription(tags["desc"])}
+	for i, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz, err := parseICCXYZ(tags[sig])
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing ICC tag %s: %v", sig, err)
+		}
+		for row := 0; row < 3; row++ {
+			p.toXYZ[row][i] = xyz[row]
+		}
+	}
+	for i, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, err := parseICCCurve(tags[sig])
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing ICC tag %s: %v", sig, err)
+		}
+		p.trc[i] = curve
+	}
+	p.toSRGB = mulMatrix(invertMatrix(srgbToXYZD50), p.toXYZ)
+	return p, nil
+}
+
+// ICCProfileFromImageData extracts and parses the ICC profile embedded in encoded JPEG or PNG data
+func ICCProfileFromImageData(data []byte) (*ICCProfile, error) {
+	var raw []byte
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		raw, err = jpegICCProfile(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		raw, err = pngICCProfile(data)
+	default:
+		return nil, ErrNoICCProfile
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseICCProfile(raw)
+}
+
+// Convert converts a color in the profile's color space to sRGB
+func (p *ICCProfile) Convert(c color.Color) color.NRGBA64 {
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	r, g, b := p.convert(float64(n.R)/0xffff, float64(n.G)/0xffff, float64(n.B)/0xffff)
+	return color.NRGBA64{R: r, G: g, B: b, A: n.A}
+}
+
+// convert converts encoded channel values (0-1) to 16 bit sRGB values
+func (p *ICCProfile) convert(r, g, b float64) (uint16, uint16, uint16) {
+	lin := [3]float64{p.trc[0].linear(r), p.trc[1].linear(g), p.trc[2].linear(b)}
+	var out [3]uint16
+	for i := 0; i < 3; i++ {
+		v := p.toSRGB[i][0]*lin[0] + p.toSRGB[i][1]*lin[1] + p.toSRGB[i][2]*lin[2]
+		out[i] = uint16(math.Round(clamp01(srgbEncode(v)) * 0xffff))
+	}
+	return out[0], out[1], out[2]
+}
+
+// FromSRGB converts an sRGB color to (encoded) coordinates in the profile's color space, 0-1 for colors inside its gamut
+func (p *ICCProfile) FromSRGB(c color.Color) [3]float64 {
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	lin := [3]float64{srgbCurve.linear(float64(n.R) / 0xffff), srgbCurve.linear(float64(n.G) / 0xffff), srgbCurve.linear(float64(n.B) / 0xffff)}
+	m := invertMatrix(p.toSRGB)
+
+	var out [3]float64
+	for i := 0; i < 3; i++ {
+		v := m[i][0]*lin[0] + m[i][1]*lin[1] + m[i][2]*lin[2]
+		out[i] = p.trc[i].encode(v)
+	}
+	return out
+}
+
+// ConvertToSRGB returns a copy of img where all pixels have been converted from the profile's color space to sRGB
+func ConvertToSRGB(img image.Image, profile *ICCProfile) image.Image {
+	b := img.Bounds()
+	out := image.NewNRGBA64(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out.SetNRGBA64(x, y, profile.Convert(img.At(x, y)))
+		}
+	}
+	return out
+}
+
+// linear converts an encoded value to linear light
+func (t toneCurve) linear(v float64) float64 {
+	if len(t.table) > 0 {
+		pos := clamp01(v) * float64(len(t.table)-1)
+		i := int(pos)
+		if i >= len(t.table)-1 {
+			return t.table[len(t.table)-1]
+		}
+		frac := pos - float64(i)
+		return t.table[i]*(1-frac) + t.table[i+1]*frac
+	}
+
+	g, a, b, c, d, e, f := t.params[0], t.params[1], t.params[2], t.params[3], t.params[4], t.params[5], t.params[6]
+	switch t.funcType {
+	case 1:
+		if v >= -b/a {
+			return math.Pow(a*v+b, g)
+		}
+		return 0
+	case 2:
+		if v >= -b/a {
+			return math.Pow(a*v+b, g) + c
+		}
+		return c
+	case 3:
+		if v >= d {
+			return math.Pow(a*v+b, g)
+		}
+		return c * v
+	case 4:
+		if v >= d {
+			return math.Pow(a*v+b, g) + e
+		}
+		return c*v + f
+	}
+	return math.Pow(v, g)
+}
+
+// encode converts linear light to an encoded value (the inverse of linear), found by bisection since the curve is monotonic
+func (t toneCurve) encode(v float64) float64 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= t.linear(1) {
+		return 1
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 40; i++ {
+		mid := (lo + hi) / 2
+		if t.linear(mid) < v {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// srgbEncode converts linear light to an sRGB encoded value
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// parseICCXYZ parses an XYZType tag
+func parseICCXYZ(tag []byte) ([3]float64, error) {
+	var xyz [3]float64
+	if len(tag) < 20 || string(tag[0:4]) != "XYZ " {
+		return xyz, fmt.Errorf("invalid XYZ tag")
+	}
+	for i := 0; i < 3; i++ {
+		xyz[i] = s15Fixed16(tag[8+i*4:])
+	}
+	return xyz, nil
+}
+
+// parseICCCurve parses a curveType or parametricCurveType tag
+func parseICCCurve(tag []byte) (toneCurve, error) {
+	if len(tag) < 12 {
+		return toneCurve{}, fmt.Errorf("invalid curve tag")
+	}
+
+	switch string(tag[0:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:12]))
+		if n < 0 || n > (len(tag)-12)/2 {
+			return toneCurve{}, fmt.Errorf("truncated curve tag")
+		}
+		switch n {
+		case 0:
+			return toneCurve{params: [7]float64{1}}, nil
+		case 1:
+			// u8Fixed8Number
+			return toneCurve{params: [7]float64{float64(binary.BigEndian.Uint16(tag[12:14])) / 256}}, nil
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+i*2:])) / 0xffff
+		}
+		return toneCurve{table: table}, nil
+	case "para":
+		funcType := int(binary.BigEndian.Uint16(tag[8:10]))
+		numParams := []int{1, 3, 4, 5, 7}
+		if funcType >= len(numParams) || len(tag) < 12+numParams[funcType]*4 {
+			return toneCurve{}, fmt.Errorf("unsupported parametric curve")
+		}
+		t := toneCurve{funcType: funcType}
+		for i := 0; i < numParams[funcType]; i++ {
+			t.params[i] = s15Fixed16(tag[12+i*4:])
+		}
+		return t, nil
+	}
+	return toneCurve{}, fmt.Errorf("unsupported curve type %q", tag[0:4])
+}
+
+// iccDescription returns the ASCII description of a textDescriptionType tag (or mluc), empty if not parsable
+func iccDescription(tag []byte) string {
+	if len(tag) < 12 {
+		return ""
+	}
+	switch string(tag[0:4]) {
+	case "desc":
+		n := int(binary.BigEndian.Uint32(tag[8:12]))
+		if n > 0 && n <= len(tag)-12 {
+			return string(bytes.TrimRight(tag[12:12+n], "\x00"))
+		}
+	case "mluc":
+		if len(tag) < 28 {
+			return ""
+		}
+		length := int(binary.BigEndian.Uint32(tag[20:24]))
+		offset := int(binary.BigEndian.Uint32(tag[24:28]))
+		if offset < 0 || length < 0 || length > len(tag)-offset {
+			return ""
+		}
+		var runes []rune
+		for i := offset; i+1 < offset+length; i += 2 {
+			runes = append(runes, rune(binary.BigEndian.Uint16(tag[i:])))
+		}
+		return string(runes)
+	}
+	return ""
+}
+
+// jpegICCProfile collects the ICC_PROFILE APP2 segments of a JPEG
+func jpegICCProfile(data []byte) ([]byte, error) {
+	chunks := make(map[int][]byte)
+	total := 0
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		if marker == 0xda || marker == 0xd9 {
+			// start of scan, no more metadata
+			break
+		}
+		// the length includes its own 2 bytes
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xe2 && len(segment) > 14 && string(segment[0:12]) == "ICC_PROFILE\x00" {
+			chunks[int(segment[12])] = segment[14:]
+			total = int(segment[13])
+		}
+		pos += 2 + length
+	}
+
+	if total == 0 {
+		return nil, ErrNoICCProfile
+	}
+	var raw []byte
+	for i := 1; i <= total; i++ {
+		chunk, ok := chunks[i]
+		if !ok {
+			return nil, fmt.Errorf("Failed, ICC profile chunk %d of %d missing", i, total)
+		}
+		raw = append(raw, chunk...)
+	}
+	return raw, nil
+}
+
+// pngICCProfile decompresses the iCCP chunk of a PNG
+func pngICCProfile(data []byte) ([]byte, error) {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		typ := string(data[pos+4 : pos+8])
+		if length < 0 || length > len(data)-pos-12 {
+			break
+		}
+		chunk := data[pos+8 : pos+8+length]
+		switch typ {
+		case "iCCP":
+			// profile name, null separator, compression method, compressed profile
+			sep := bytes.IndexByte(chunk, 0)
+			if sep < 0 || sep+2 > len(chunk) {
+				return nil, fmt.Errorf("Failed, invalid iCCP chunk")
+			}
+			r, err := zlib.NewReader(bytes.NewReader(chunk[sep+2:]))
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+			return io.ReadAll(r)
+		case "IDAT", "IEND":
+			return nil, ErrNoICCProfile
+		}
+		pos += 12 + length
+	}
+	return nil, ErrNoICCProfile
+}
+
+// s15Fixed16 reads a signed 15.16 fixed point number
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+// rgbToXYZMatrix calculates the matrix converting linear RGB to XYZ from the primaries and white point
+func rgbToXYZMatrix(primaries [3][2]float64, white [3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i, p := range primaries {
+		x, y := p[0], p[1]
+		m[0][i] = x / y
+		m[1][i] = 1
+		m[2][i] = (1 - x - y) / y
+	}
+	inv := invertMatrix(m)
+	var s [3]float64
+	for i := 0; i < 3; i++ {
+		s[i] = inv[i][0]*white[0] + inv[i][1]*white[1] + inv[i][2]*white[2]
+	}
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			m[row][col] *= s[col]
+		}
+	}
+	return m
+}
+
+// mulMatrix multiplies two 3x3 matrices
+func mulMatrix(a, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j]
+		}
+	}
+	return m
+}
+
+// invertMatrix inverts a 3x3 matrix
+func invertMatrix(m [3][3]float64) [3][3]float64 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+
+	var inv [3][3]float64
+	inv[0][0] = (m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det
+	inv[0][1] = (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det
+	inv[0][2] = (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det
+	inv[1][0] = (m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det
+	inv[1][1] = (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det
+	inv[1][2] = (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det
+	inv[2][0] = (m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det
+	inv[2][1] = (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det
+	inv[2][2] = (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det
+	return inv
+}
+
+// clamp01 limits v to the range 0-1
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,233 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// iccTag is a tag of a hand-built ICC profile
+type iccTag struct {
+	sig  string
+	data []byte
+}
+
+// buildICCProfile returns an RGB ICC profile with the tags
+func buildICCProfile(tags []iccTag) []byte {
+	data := make([]byte, 132+12*len(tags))
+	copy(data[16:20], "RGB ")
+	copy(data[20:24], "XYZ ")
+	copy(data[36:40], "acsp")
+	binary.BigEndian.PutUint32(data[128:132], uint32(len(tags)))
+	for i, tag := range tags {
+		entry := 132 + 12*i
+		copy(data[entry:entry+4], tag.sig)
+		binary.BigEndian.PutUint32(data[entry+4:], uint32(len(data)))
+		binary.BigEndian.PutUint32(data[entry+8:], uint32(len(tag.data)))
+		data = append(data, tag.data...)
+		// tags are 4 byte aligned
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+	binary.BigEndian.PutUint32(data[0:4], uint32(len(data)))
+	return data
+}
+
+// iccXYZ returns an XYZType tag
+func iccXYZ(x, y, z float64) []byte {
+	tag := []byte("XYZ \x00\x00\x00\x00")
+	for _, v := range []float64{x, y, z} {
+		tag = binary.BigEndian.AppendUint32(tag, uint32(int32(math.Round(v*65536))))
+	}
+	return tag
+}
+
+// iccText returns a textDescriptionType tag
+func iccText(s string) []byte {
+	tag := binary.BigEndian.AppendUint32([]byte("desc\x00\x00\x00\x00"), uint32(len(s)+1))
+	return append(append(tag, s...), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+// srgbICCTags are the tags of an sRGB profile, with the curves given
+func srgbICCTags(curve []byte) []iccTag {
+	tags := []iccTag{{"desc", iccText("hand-built sRGB")}}
+	for i, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		tags = append(tags, iccTag{sig, iccXYZ(srgbToXYZD50[0][i], srgbToXYZD50[1][i], srgbToXYZD50[2][i])})
+	}
+	for _, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		tags = append(tags, iccTag{sig, curve})
+	}
+	return tags
+}
+
+// iccSRGBPara is a parametricCurveType tag with the sRGB transfer function
+func iccSRGBPara() []byte {
+	tag := []byte("para\x00\x00\x00\x00\x00\x03\x00\x00")
+	for _, v := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		tag = binary.BigEndian.AppendUint32(tag, uint32(int32(math.Round(v*65536))))
+	}
+	return tag
+}
+
+func TestICCBuiltInRoundTrip(t *testing.T) {
+	for _, p := range []*ICCProfile{ProfileSRGB, ProfileAdobeRGB, ProfileDisplayP3, ProfileRec2020} {
+		for _, c := range []color.NRGBA64{
+			{R: 0, G: 0, B: 0, A: 0xffff},
+			{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff},
+			{R: 0xffff, G: 0, B: 0, A: 0xffff},
+			{R: 0x1234, G: 0x8000, B: 0xfedc, A: 0xffff},
+			{R: 0x0100, G: 0x0200, B: 0x0300, A: 0xffff},
+		} {
+			// sRGB is inside the gamut of all of them, so the coordinates convert back
+			v := p.FromSRGB(c)
+			r, g, b := p.convert(v[0], v[1], v[2])
+			for i, d := range []int{int(r) - int(c.R), int(g) - int(c.G), int(b) - int(c.B)} {
+				if d < -1 || d > 1 {
+					t.Errorf("%s: channel %d of %v converted back %d off via %v", p.Name, i, c, d, v)
+				}
+			}
+		}
+	}
+	// sRGB is left as it is
+	c := color.NRGBA64{R: 0x1234, G: 0x5678, B: 0x9abc, A: 0x8000}
+	if got := ProfileSRGB.Convert(c); got != c {
+		t.Errorf("Expected sRGB %v unchanged, got %v", c, got)
+	}
+}
+
+func TestParseICCProfile(t *testing.T) {
+	p, err := ParseICCProfile(buildICCProfile(srgbICCTags(iccSRGBPara())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "hand-built sRGB" {
+		t.Errorf("Expected the name of the desc tag, got %q", p.Name)
+	}
+	for _, c := range []color.NRGBA64{{R: 0x1234, G: 0x8000, B: 0xfedc, A: 0xffff}, {R: 0xffff, G: 0x4000, A: 0xffff}} {
+		got := p.Convert(c)
+		for i, d := range []int{int(got.R) - int(c.R), int(got.G) - int(c.G), int(got.B) - int(c.B)} {
+			if d < -16 || d > 16 {
+				t.Errorf("Expected the sRGB profile to keep channel %d of %v, got %v", i, c, got)
+			}
+		}
+	}
+
+	// a linear curve (no entries, gamma 1) and a table
+	linear, err := ParseICCProfile(buildICCProfile(srgbICCTags([]byte("curv\x00\x00\x00\x00\x00\x00\x00\x00"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := ParseICCProfile(buildICCProfile(srgbICCTags([]byte("curv\x00\x00\x00\x00\x00\x00\x00\x02\x00\x00\xff\xff"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gray := color.NRGBA64{R: 0x8000, G: 0x8000, B: 0x8000, A: 0xffff}
+	want := uint16(math.Round(srgbEncode(float64(0x8000)/0xffff) * 0xffff))
+	for name, p := range map[string]*ICCProfile{"linear": linear, "table": table} {
+		if got := p.Convert(gray); got.G < want-16 || got.G > want+16 {
+			t.Errorf("Expected the %s profile to encode linear gray to %#x, got %v", name, want, got)
+		}
+	}
+}
+
+func TestParseICCProfileInvalid(t *testing.T) {
+	valid := buildICCProfile(srgbICCTags(iccSRGBPara()))
+	modify := func(f func(data []byte) []byte) []byte {
+		return f(append([]byte{}, valid...))
+	}
+	for name, data := range map[string][]byte{
+		"empty":               nil,
+		"short header":        valid[:100],
+		"no signature":        modify(func(d []byte) []byte { copy(d[36:40], "xxxx"); return d }),
+		"CMYK":                modify(func(d []byte) []byte { copy(d[16:20], "CMYK"); return d }),
+		"truncated tag table": valid[:140],
+		"too many tags":       modify(func(d []byte) []byte { binary.BigEndian.PutUint32(d[128:132], 0xffffffff); return d }),
+		"tag outside":         modify(func(d []byte) []byte { binary.BigEndian.PutUint32(d[136:140], uint32(len(d))); return d }),
+		"tag offset overflow": modify(func(d []byte) []byte { binary.BigEndian.PutUint32(d[136:140], 0x7fffffff); return d }),
+		"tag size overflow":   modify(func(d []byte) []byte { binary.BigEndian.PutUint32(d[140:144], 0x7fffffff); return d }),
+		"missing colorant":    buildICCProfile(srgbICCTags(iccSRGBPara())[:3]),
+		"truncated curve":     buildICCProfile(srgbICCTags([]byte("curv\x00\x00\x00\x00\x7f\xff\xff\xff"))),
+		"unsupported para":    buildICCProfile(srgbICCTags([]byte("para\x00\x00\x00\x00\x00\x09\x00\x00"))),
+		"unsupported curve":   buildICCProfile(srgbICCTags([]byte("mAB \x00\x00\x00\x00\x00\x00\x00\x00"))),
+	} {
+		if _, err := ParseICCProfile(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestICCProfileFromImageData(t *testing.T) {
+	profile := buildICCProfile(srgbICCTags(iccSRGBPara()))
+
+	// a JPEG with the profile in two APP2 chunks
+	jpg := []byte{0xff, 0xd8}
+	for i, part := range [][]byte{profile[:100], profile[100:]} {
+		segment := append([]byte("ICC_PROFILE\x00"), byte(i+1), 2)
+		segment = append(segment, part...)
+		jpg = append(binary.BigEndian.AppendUint16(append(jpg, 0xff, 0xe2), uint16(len(segment)+2)), segment...)
+	}
+	jpg = append(jpg, 0xff, 0xd9)
+
+	// a PNG with the compressed profile in the iCCP chunk
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	w.Write(profile)
+	w.Close()
+	chunk := append([]byte("iCCP"), "icc\x00\x00"...)
+	chunk = append(chunk, z.Bytes()...)
+	png := binary.BigEndian.AppendUint32([]byte("\x89PNG\r\n\x1a\n"), uint32(len(chunk)-4))
+	png = binary.BigEndian.AppendUint32(append(png, chunk...), crc32.ChecksumIEEE(chunk))
+
+	for name, data := range map[string][]byte{"jpeg": jpg, "png": png} {
+		p, err := ICCProfileFromImageData(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if p.Name != "hand-built sRGB" {
+			t.Errorf("%s: expected the embedded profile, got %q", name, p.Name)
+		}
+	}
+
+	for name, data := range map[string][]byte{
+		"zero segment length": {0xff, 0xd8, 0xff, 0xe2, 0, 0, 0, 0},
+		"no profile":          {0xff, 0xd8, 0xff, 0xd9},
+		"gif":                 []byte("GIF89a"),
+	} {
+		if _, err := ICCProfileFromImageData(data); !errors.Is(err, ErrNoICCProfile) {
+			t.Errorf("%s: expected ErrNoICCProfile, got %v", name, err)
+		}
+	}
+	if _, err := ICCProfileFromImageData(jpg[:len(jpg)/2]); err == nil {
+		t.Error("Expected an error for a missing chunk")
+	}
+}
+
+func TestConvertToSRGB(t *testing.T) {
+	img := image.NewNRGBA64(image.Rect(5, 5, 7, 6))
+	img.SetNRGBA64(5, 5, color.NRGBA64{R: 0xffff, A: 0xffff})
+	img.SetNRGBA64(6, 5, color.NRGBA64{G: 0x8000, B: 0x4000, A: 0x8000})
+	out := ConvertToSRGB(img, ProfileDisplayP3)
+	if out.Bounds() != img.Bounds() {
+		t.Fatalf("Expected the bounds %v, got %v", img.Bounds(), out.Bounds())
+	}
+	for x := 5; x < 7; x++ {
+		if got, want := out.At(x, 5), ProfileDisplayP3.Convert(img.At(x, 5)); got != want {
+			t.Errorf("Expected %v at %d, got %v", want, x, got)
+		}
+	}
+	// P3 red is outside of sRGB, its negative green and blue are clipped
+	if got := out.At(5, 5).(color.NRGBA64); got.R != 0xffff || got.G != 0 || got.B != 0 {
+		t.Errorf("Expected P3 red clipped to sRGB red, got %v", got)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// imageClusterMaxRounds is a safety net for the k-medoids iterations of ClusterImagesByPalette
+const imageClusterMaxRounds = 100
+
+// ImageCluster is a group of images with similar palettes, see ClusterImagesByPalette
+type ImageCluster struct {
+	// Exemplar is the index of the palette most typical for the cluster (its medoid)
+	Exemplar int
+
+	// Members are the indices of the palettes in the cluster, closest to the exemplar first (the exemplar itself),
+	// so the first members are the exemplar images of the cluster
+	Members []int
+}
+
+// PaletteDistance returns how different two palettes are: the CIEDE2000 delta E (0-100 scale) from each color to the
+// closest color of the other palette, averaged weighted by the counts of the colors, in both directions. Identical
+// palettes have distance 0, a palette and an empty one 100.
+func PaletteDistance(a, b []ColorItem) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 100
+	}
+
+	sum := 0.0
+	for _, pair := range [][2][]ColorItem{{a, b}, {b, a}} {
+		weights := percentages(pair[0])
+		equal := sumOf(weights) == 0
+		for i, c := range pair[0] {
+			closest := math.MaxFloat64
+			for _, other := range pair[1] {
+				closest = math.Min(closest, distanceCIEDE2000(c, other)*100)
+			}
+			w := weights[i] / 100
+			if equal {
+				// colors created without counts weigh the same
+				w = 1 / float64(len(pair[0]))
+			}
+			sum += w * closest
+		}
+	}
+	return sum / 2
+}
+
+// sumOf returns the sum of the values
+func sumOf(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// ClusterImagesByPalette groups images into k clusters of similar palettes (e.g. the colors of their results), to
+// organize a library by color. It runs k-medoids on the PaletteDistance of all pairs, so it takes O(n²) distances
+// for n palettes. The clusters are sorted by size, largest first, and are deterministic.
+func ClusterImagesByPalette(palettes [][]ColorItem, k int) ([]ImageCluster, error) {
+	n := len(palettes)
+	if k <= 0 || k > n {
+		return nil, fmt.Errorf("Failed, k must be between 1 and the number of palettes: %d vs %d", k, n)
+	}
+
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			dist[i][j] = PaletteDistance(palettes[i], palettes[j])
+			dist[j][i] = dist[i][j]
+		}
+	}
+
+	medoids := seedMedoids(dist, k)
+	assignment := make([]int, n)
+	for rounds := 0; rounds < imageClusterMaxRounds; rounds++ {
+		for i := range assignment {
+			assignment[i] = closestMedoid(dist[i], medoids)
+		}
+		// a medoid stays in its cluster, also if it has the same palette as another medoid
+		for c, m := range medoids {
+			assignment[m] = c
+		}
+
+		changed := false
+		for c := range medoids {
+			// the member with the smallest sum of distances to the other members becomes the medoid
+			best, bestSum := medoids[c], math.MaxFloat64
+			for i := range assignment {
+				if assignment[i] != c {
+					continue
+				}
+				sum := 0.0
+				for j := range assignment {
+					if assignment[j] == c {
+						sum += dist[i][j]
+					}
+				}
+				if sum < bestSum {
+					best, bestSum = i, sum
+				}
+			}
+			if best != medoids[c] {
+				medoids[c], changed = best, true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	clusters := make([]ImageCluster, k)
+	for c, m := range medoids {
+		clusters[c].Exemplar = m
+	}
+	for i, c := range assignment {
+		clusters[c].Members = append(clusters[c].Members, i)
+	}
+	for c := range clusters {
+		members, exemplar := clusters[c].Members, clusters[c].Exemplar
+		sort.SliceStable(members, func(x, y int) bool { return dist[exemplar][members[x]] < dist[exemplar][members[y]] })
+	}
+	sort.SliceStable(clusters, func(x, y int) bool { return len(clusters[x].Members) > len(clusters[y].Members) })
+	return clusters, nil
+}
+
+// seedMedoids picks the k initial medoids: the most central palette, then each time the palette farthest from the
+// medoids picked so far
+func seedMedoids(dist [][]float64, k int) []int {
+	first, firstSum := 0, math.MaxFloat64
+	for i := range dist {
+		if sum := sumOf(dist[i]); sum < firstSum {
+			first, firstSum = i, sum
+		}
+	}
+	medoids := []int{first}
+	for len(medoids) < k {
+		farthest, farthestDist := -1, -1.0
+		for i := range dist {
+			if slices.Contains(medoids, i) {
+				continue
+			}
+			if d := dist[i][medoids[closestMedoid(dist[i], medoids)]]; d > farthestDist {
+				farthest, farthestDist = i, d
+			}
+		}
+		medoids = append(medoids, farthest)
+	}
+	return medoids
+}
+
+// closestMedoid returns the index in medoids of the medoid closest to the palette with the distances dist
+func closestMedoid(dist []float64, medoids []int) int {
+	closest := 0
+	for c, m := range medoids {
+		if dist[m] < dist[medoids[closest]] {
+			closest = c
+		}
+	}
+	return closest
+}
@@ -11,14 +11,13 @@ import (
 	"log"
 
 	"image/jpeg"
+	"image/png"
+	"io"
 	"os"
 
 	"time"
 
 	"fmt"
-
-	"github.com/nfnt/resize"
-	"github.com/oliamb/cutter"
 )
 
 // ColorBackgroundMask defines which color channels to look for color to ignore
@@ -31,18 +30,39 @@ type ColorBackgroundMask struct {
 
 	// PercDiff if any of R,G,B is true (but not all), any of the other colors divided by the color value that is true, must be below PercDiff
 	PercDiff float32
+
+	// DeltaE if set, R,G,B, Treshold and PercDiff are not used, instead pixels within this CIEDE2000 delta E (0-100 scale)
+	// of Reference are ignored, see NewColorMask
+	DeltaE float64
+
+	// Reference is the (8 bit) background color used with DeltaE
+	Reference ColorRGB
+}
+
+// NewColorMask creates a mask ignoring pixels within deltaE (CIEDE2000, 0-100 scale) of the color,
+// e.g. NewColorMask(ColorRGB{R: 255, G: 255, B: 255}, 6) for white
+func NewColorMask(reference ColorRGB, deltaE float64) ColorBackgroundMask {
+	return ColorBackgroundMask{DeltaE: deltaE, Reference: reference}
 }
 
 // ProcessImg process the image and mark unwanted pixels transparent.
 // It checks the corners, if not all of them match the mask, we conclude it's not a clipart/solid background and do nothing
 func ProcessImg(arguments int, bgmasks []ColorBackgroundMask, img image.Image) draw.Image {
+	imgDraw, _, _ := processImg(arguments, bgmasks, img)
+	return imgDraw
+}
+
+// processImg is ProcessImg, also returning the index of the mask that was applied (-1 if none) and the number of
+// pixels it removed
+func processImg(arguments int, bgmasks []ColorBackgroundMask, img image.Image) (draw.Image, int, int) {
 	imgDraw := createDrawImage(img)
 	rect := imgDraw.Bounds()
 
 	//loop through the masks, and the first one that matches on the four corners is the one that will be used
 	foundMaskThatmatched := false
 	var bgmaskToUse ColorBackgroundMask
-	for _, bgmask := range bgmasks {
+	maskIdx := -1
+	for i, bgmask := range bgmasks {
 		// Check the corners, if not all of them are the color of the mask,
 		// we conclude it's not a solid background and do nothing special
 		if !ignorePixel(rect.Min.X, rect.Min.Y, bgmask, &imgDraw) || !ignorePixel(rect.Min.X, rect.Max.Y-1, bgmask, &imgDraw) || !ignorePixel(rect.Max.X-1, rect.Min.Y, bgmask, &imgDraw) || !ignorePixel(rect.Max.X-1, rect.Max.Y-1, bgmask, &imgDraw) {
@@ -50,14 +70,15 @@ func ProcessImg(arguments int, bgmasks []ColorBackgroundMask, img image.Image) d
 		}
 		foundMaskThatmatched = true
 		bgmaskToUse = bgmask
+		maskIdx = i
 	}
 
 	// no mask that we can apply
 	if !foundMaskThatmatched {
-		return imgDraw
+		return imgDraw, -1, 0
 	}
 
-	ProcessImgOutline(bgmaskToUse, &imgDraw)
+	removed := processImgOutline(bgmaskToUse, &imgDraw)
 
 	// if debug argument is set, save a tmp file to be able to view what was masked out
 	if IsBitSet(arguments, ArgumentDebugImage) {
@@ -67,13 +88,18 @@ func ProcessImg(arguments int, bgmasks []ColorBackgroundMask, img image.Image) d
 		jpeg.Encode(toimg, imgDraw, &jpeg.Options{Quality: 100})
 	}
 
-	return imgDraw
+	return imgDraw, maskIdx, removed
 }
 
 // ProcessImgOutline follow the outline of the image and mark all "white" pixels as transparent
 func ProcessImgOutline(bgmask ColorBackgroundMask, imgDraw *draw.Image) {
+	processImgOutline(bgmask, imgDraw)
+}
 
+// processImgOutline is ProcessImgOutline, returning the number of pixels marked
+func processImgOutline(bgmask ColorBackgroundMask, imgDraw *draw.Image) int {
 	rect := (*imgDraw).Bounds()
+	removed := 0
 
 	var pointsToProcess []image.Point
 
@@ -92,6 +118,7 @@ func ProcessImgOutline(bgmask ColorBackgroundMask, imgDraw *draw.Image) {
 
 			//Mark the pixel
 			markPixel(p.X, p.Y, (imgDraw))
+			removed++
 			if !isPixelTransparent(p.X, p.Y, imgDraw) {
 				log.Println("ERROR: marking")
 			}
@@ -123,44 +150,225 @@ func ProcessImgOutline(bgmask ColorBackgroundMask, imgDraw *draw.Image) {
 			}
 		}
 	}
+	return removed
 }
 
-// createDrawImage creates a draw.Image so we can work with the single pixels
+// createDrawImage creates a draw.Image so we can work with the single pixels, 16 bit images keep their precision
 func createDrawImage(img image.Image) draw.Image {
 	b := img.Bounds()
-	cimg := image.NewRGBA(b)
+	var cimg draw.Image
+	if is16Bit(img) {
+		cimg = image.NewRGBA64(b)
+	} else {
+		cimg = image.NewRGBA(b)
+	}
 	draw.Draw(cimg, b, img, b.Min, draw.Src)
 	return cimg
 }
 
+// is16Bit checks if the image stores more than 8 bits per channel
+func is16Bit(img image.Image) bool {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16:
+		return true
+	}
+	return false
+}
+
+// preparation describes what prepareImg did to the image
+type preparation struct {
+	// mask is the background mask that was applied, nil if none, and masked the number of pixels it removed
+	mask   *ColorBackgroundMask
+	masked int
+
+	// skipped is the number of pixels below the alpha threshold
+	skipped int
+
+	// floodFilled is set if the background was removed by floodFillBackground
+	floodFilled bool
+
+	// borderBackground is the number of pixels removed by the BorderBackground
+	borderBackground int
+
+	// chromaKeyed is the number of pixels removed by the chroma key
+	chromaKeyed int
+
+	// foreground is set if only the foreground found by edgeForeground was kept
+	foreground bool
+
+	// codePixels is the number of pixels removed by excludeCodes
+	codePixels int
+
+	// stats are the pixel counts reported in Result.Stats
+	stats Stats
+
+	// beforeMasks is the image before the background masks were applied
+	beforeMasks image.Image
+
+	// removals are the pixels removed by each mask, only collected for Options.MaskReport
+	removals []removal
+
+	// source is the rectangle of the original image the prepared image was made of, after the region and cropping
+	source image.Rectangle
+}
+
 // prepareImg resizes to a smaller size and remove any "white" background pixels for isolated/clipart images
-func prepareImg(arguments int, bgmasks []ColorBackgroundMask, imageSize uint, orgimg image.Image) image.Image {
+// Pixels with alpha below alphaThreshold are made transparent, the others opaque.
+func prepareImg(arguments int, bgmasks []ColorBackgroundMask, imageSize uint, resizer Resizer, alphaThreshold uint32, orgimg image.Image) (draw.Image, preparation) {
+	var prep preparation
+	prep.stats.TotalPixels = orgimg.Bounds().Dx() * orgimg.Bounds().Dy()
 
 	if !IsBitSet(arguments, ArgumentNoCropping) {
 		// crop to remove 25% on all sides
-		croppedimg, err := cutter.Crop(orgimg, cutter.Config{
-			Width:  int(orgimg.Bounds().Dx() / 2),
-			Height: int(orgimg.Bounds().Dy() / 2),
-			Mode:   cutter.Centered,
-		})
-
-		if err != nil {
-			log.Println("Warning: failed cropping")
-			log.Println(err)
-		} else {
-			orgimg = croppedimg
-		}
+		orgimg = cropImage(orgimg, cropBounds(arguments, orgimg.Bounds()))
+		prep.stats.CroppedPixels = prep.stats.TotalPixels - orgimg.Bounds().Dx()*orgimg.Bounds().Dy()
 	}
 
 	// Don't resize if the image is smaller than imageSize
 	rec := orgimg.Bounds()
 
-	if uint(rec.Dx()) > imageSize || uint(rec.Dy()) > imageSize {
-		img := resize.Resize(imageSize, 0, orgimg, resize.Lanczos3)
-		return ProcessImg(arguments, bgmasks, img)
+	if needsResize(rec, imageSize) {
+		if IsBitSet(arguments, ArgumentOrientationInvariant) && rec.Dy() > rec.Dx() {
+			orgimg = resizer.Resize(orgimg, 0, imageSize)
+		} else {
+			orgimg = resizer.Resize(orgimg, imageSize, 0)
+		}
+	}
+
+	prep.stats.ProcessedPixels = orgimg.Bounds().Dx() * orgimg.Bounds().Dy()
+	orgimg, prep.skipped = applyAlphaThreshold(orgimg, alphaThreshold)
+	prep.stats.TransparentPixels = prep.skipped
+
+	prep.beforeMasks = orgimg
+	img, maskIdx, masked := processImg(arguments, bgmasks, orgimg)
+	if maskIdx >= 0 {
+		prep.mask = &bgmasks[maskIdx]
+		prep.masked = masked
 	}
+	return img, prep
+}
 
-	return ProcessImg(arguments, bgmasks, orgimg)
+// needsResize checks if an image with bounds b is larger than imageSize and is resized by prepareImg
+func needsResize(b image.Rectangle, imageSize uint) bool {
+	return imageSize != OriginalSize && (uint(b.Dx()) > imageSize || uint(b.Dy()) > imageSize)
+}
+
+// cropBounds returns the bounds of the image after the cropping of prepareImg, 25% removed on all sides
+// unless ArgumentNoCropping is set. The center half is kept, for an odd size the extra pixel is removed on one side.
+// With ArgumentOrientationInvariant the same number of pixels is removed on opposite sides instead, so a rotated or
+// mirrored image keeps exactly the same pixels, e.g. 51 of 101 columns instead of 50.
+func cropBounds(arguments int, b image.Rectangle) image.Rectangle {
+	if IsBitSet(arguments, ArgumentNoCropping) {
+		return b
+	}
+	if IsBitSet(arguments, ArgumentOrientationInvariant) {
+		return image.Rect(b.Min.X+b.Dx()/4, b.Min.Y+b.Dy()/4, b.Max.X-b.Dx()/4, b.Max.Y-b.Dy()/4)
+	}
+	// the center half, the sides differing by a pixel for some sizes
+	w, h := b.Dx()/2, b.Dy()/2
+	x, y := b.Min.X+b.Dx()/2-w/2, b.Min.Y+b.Dy()/2-h/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// cropImage returns the part of the image within r, sharing the pixels if the image supports SubImage
+func cropImage(img image.Image, r image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	out := image.NewRGBA64(r)
+	draw.Draw(out, r, img, r.Min, draw.Src)
+	return out
+}
+
+// applyAlphaThreshold makes pixels with alpha below threshold transparent and the others opaque (un-premultiplied),
+// so semi-transparent edges are not darkened. It returns the number of transparent pixels.
+func applyAlphaThreshold(img image.Image, threshold uint32) (image.Image, int) {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img, 0
+	}
+
+	out := createDrawImage(img)
+	skipped := 0
+	b := out.Bounds()
+	if rgba, ok := out.(*image.RGBA); ok {
+		// only the pixels that are not opaque change, they are made transparent or opaque (un-premultiplied)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := rgba.PixOffset(b.Min.X, y)
+			for x := b.Min.X; x < b.Max.X; x, i = x+1, i+4 {
+				s := rgba.Pix[i : i+4 : i+4]
+				if s[3] == 0xff {
+					continue
+				}
+				a := uint32(s[3]) * 0x101
+				if a < threshold || a == 0 {
+					s[0], s[1], s[2], s[3] = 0, 0, 0, 0
+					skipped++
+					continue
+				}
+				for c := 0; c < 3; c++ {
+					s[c] = uint8(uint32(s[c]) * 0x101 * 0xffff / a >> 8)
+				}
+				s[3] = 0xff
+			}
+		}
+		return out, skipped
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(out.At(x, y)).(color.NRGBA64)
+			if uint32(c.A) < threshold || c.A == 0 {
+				out.Set(x, y, color.Transparent)
+				skipped++
+				continue
+			}
+			c.A = 0xffff
+			out.Set(x, y, c)
+		}
+	}
+	return out, skipped
+}
+
+// applyMask returns a copy of the image where the pixels with a zero mask value are transparent
+func applyMask(img image.Image, mask image.Image) (image.Image, error) {
+	b, mb := img.Bounds(), mask.Bounds()
+	if b.Dx() != mb.Dx() || b.Dy() != mb.Dy() {
+		return nil, fmt.Errorf("Failed, the mask is %dx%d but the image is %dx%d", mb.Dx(), mb.Dy(), b.Dx(), b.Dy())
+	}
+
+	out := createDrawImage(img)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			// Gray16Model gives zero both for a black gray pixel and a transparent alpha pixel
+			if color.Gray16Model.Convert(mask.At(mb.Min.X+x, mb.Min.Y+y)).(color.Gray16).Y == 0 {
+				out.Set(b.Min.X+x, b.Min.Y+y, color.Transparent)
+			}
+		}
+	}
+	return out, nil
+}
+
+// PixelMask excludes the pixels for which it returns true, e.g. a watermark corner or overexposed pixels.
+// x and y are coordinates of the original image.
+type PixelMask func(x, y int, c color.Color) bool
+
+// applyPixelMasks returns a copy of the image where the pixels excluded by any of the masks are transparent
+func applyPixelMasks(img image.Image, masks []PixelMask) image.Image {
+	out := createDrawImage(img)
+	forEachPixel(img, func(x, y int, r, g, b, a uint32) {
+		if a == 0 {
+			return
+		}
+		c := color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+		for _, m := range masks {
+			if m(x, y, c) {
+				clearPixel(out, x, y)
+				break
+			}
+		}
+	})
+	return out
 }
 
 // markPixel sets a purple color (to make it stick out if we want to look at the image) and makes the pixel transparent
@@ -168,6 +376,57 @@ func markPixel(x, y int, img *draw.Image) {
 	(*img).Set(x, y, color.RGBA{255, 0, 255, 0})
 }
 
+// DebugToWriter returns a function for Options.DebugImage writing the image as PNG to w
+func DebugToWriter(w io.Writer) func(img image.Image) error {
+	return func(img image.Image) error {
+		return png.Encode(w, img)
+	}
+}
+
+// debugVisualization returns an opaque copy of the image with the removed (transparent) pixels in pink
+func debugVisualization(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewNRGBA64(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			if c.A == 0 {
+				c = color.NRGBA64{R: 0xffff, B: 0xffff}
+			}
+			c.A = 0xffff
+			out.SetNRGBA64(x, y, c)
+		}
+	}
+	return out
+}
+
+// cropRegion returns the part of the image within r
+func cropRegion(img image.Image, r image.Rectangle) image.Image {
+	r = r.Intersect(img.Bounds())
+	if s, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return s.SubImage(r)
+	}
+	out := createDrawImage(image.NewRGBA(r))
+	if is16Bit(img) {
+		out = createDrawImage(image.NewRGBA64(r))
+	}
+	draw.Draw(out, r, img, r.Min, draw.Src)
+	return out
+}
+
+// countOpaque returns the number of pixels that are not transparent
+func countOpaque(img image.Image) int {
+	n := 0
+	forEachRun(img, func(x, y, cnt int, r, g, b, a uint32) {
+		if a != 0 {
+			n += cnt
+		}
+	})
+	return n
+}
+
 // isPixelTransparent returns bool if the pixel is transparent (alpha==0)
 func isPixelTransparent(x, y int, img *draw.Image) bool {
 	colorAt := (*img).At(x, y)
@@ -177,14 +436,22 @@ func isPixelTransparent(x, y int, img *draw.Image) bool {
 
 // ignorePixel checks if the pixel should be ignored (i.e. being transparent or white)
 func ignorePixel(x, y int, bgmask ColorBackgroundMask, img *draw.Image) bool {
-	colorAt := (*img).At(x, y)
+	return bgmask.matches((*img).At(x, y))
+}
 
+// matches checks if the color should be ignored (i.e. being transparent or matching the mask)
+func (bgmask ColorBackgroundMask) matches(colorAt color.Color) bool {
 	r, g, b, a := colorAt.RGBA()
 
 	if a == 0 {
 		return true
 	}
 
+	if bgmask.DeltaE > 0 {
+		c, _ := createColor(colorAt)
+		return distanceCIEDE2000(c, ColorItem{Color: bgmask.Reference})*100 <= bgmask.DeltaE
+	}
+
 	//if looking for black
 	if !(bgmask.R || bgmask.G || bgmask.B) {
 		if r > bgmask.Treshold {
@@ -250,3 +517,15 @@ func ignorePixel(x, y int, bgmask ColorBackgroundMask, img *draw.Image) bool {
 
 	return true
 }
+
+// clearPixel makes the pixel transparent, without converting the color for the common image types
+func clearPixel(img draw.Image, x, y int) {
+	switch m := img.(type) {
+	case *image.RGBA:
+		m.SetRGBA(x, y, color.RGBA{})
+	case *image.RGBA64:
+		m.SetRGBA64(x, y, color.RGBA64{})
+	default:
+		m.Set(x, y, color.Transparent)
+	}
+}
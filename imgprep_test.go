@@ -0,0 +1,90 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCropBoundsMatchesPrepareImg(t *testing.T) {
+	for _, arguments := range []int{ArgumentDefault, ArgumentOrientationInvariant, ArgumentNoCropping} {
+		for _, r := range []image.Rectangle{
+			image.Rect(0, 0, 100, 100),
+			image.Rect(0, 0, 101, 99),
+			image.Rect(0, 0, 3, 7),
+			image.Rect(10, 20, 113, 57),
+		} {
+			img := image.NewRGBA(r)
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 0xff})
+				}
+			}
+			prepared, _ := prepareImg(arguments, nil, OriginalSize, ResizerLanczos, DefaultAlphaThreshold, img)
+			crop := cropBounds(arguments, r)
+			if got := prepared.Bounds(); got != crop {
+				t.Errorf("Expected crop %v of %v with arguments %d, got %v", crop, r, arguments, got)
+			}
+			// the pixels are the ones of the crop of the original image
+			if got, want := color.RGBAModel.Convert(prepared.At(crop.Min.X, crop.Min.Y)), img.At(crop.Min.X, crop.Min.Y); got != want {
+				t.Errorf("Expected %v at %v of %v, got %v", want, crop.Min, r, got)
+			}
+		}
+	}
+}
+
+func TestCropBoundsOddSizes(t *testing.T) {
+	b := image.Rect(0, 0, 101, 101)
+	if got := cropBounds(ArgumentDefault, b); got != image.Rect(25, 25, 75, 75) {
+		t.Errorf("Expected the center half 50x50, got %v", got)
+	}
+	// the orientation invariant crop removes 25 pixels on every side
+	if got := cropBounds(ArgumentOrientationInvariant, b); got != image.Rect(25, 25, 76, 76) {
+		t.Errorf("Expected a symmetric 51x51 crop, got %v", got)
+	}
+	if got := cropBounds(ArgumentNoCropping, b); got != b {
+		t.Errorf("Expected no cropping, got %v", got)
+	}
+}
+
+func TestKmeansWithMaskSkippedPixels(t *testing.T) {
+	img := wideImage()
+	b := img.Bounds()
+	// exclude the left half
+	mask := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X + b.Dx()/2; x < b.Max.X; x++ {
+			mask.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	opts := DefaultOptions()
+	opts.Masks = nil
+	opts.Arguments = ArgumentNoCropping | ArgumentDeterministic
+	opts.K = 1
+
+	opts.Size = OriginalSize
+	res, err := KmeansWithMask(img, mask, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := b.Dx() / 2 * b.Dy(); res.SkippedPixels != want || res.Stats.ProcessedPixels != b.Dx()*b.Dy() {
+		t.Errorf("Expected %d of %d pixels skipped, got %d of %d", want, b.Dx()*b.Dy(), res.SkippedPixels, res.Stats.ProcessedPixels)
+	}
+	// the excluded red half is gone
+	if c := res.Colors[0].Color; c.R > 0x80 {
+		t.Errorf("Expected the excluded half to be ignored, got %v", c)
+	}
+
+	// resized to 40 pixels wide half of the processed pixels are skipped
+	opts.Size = 40
+	if res, err = KmeansWithMask(img, mask, opts); err != nil {
+		t.Fatal(err)
+	}
+	if 2*res.SkippedPixels != res.Stats.ProcessedPixels {
+		t.Errorf("Expected half of %d processed pixels skipped, got %d", res.Stats.ProcessedPixels, res.SkippedPixels)
+	}
+}
@@ -33,8 +33,41 @@ const (
 
 	ArgumentCIEDE2000
 	// ArgumentDebugImage saves a tmp file in /tmp/ where the area that has been cut away by the mask is marked pink
-	// useful when figuring out what values to pick for the masks
+	// useful when figuring out what values to pick for the masks, see Options.DebugImage to get the image instead
 	ArgumentDebugImage
+	// ArgumentLCh clusters in LCh(ab): distance as LAB, but centroids are calculated with circular hue,
+	// so merging two vivid hues does not produce a desaturated in-between color
+	ArgumentLCh
+	// ArgumentCAM16UCS uses the CAM16-UCS color appearance space when measuring distance,
+	// with the viewing conditions set by SetViewingConditions
+	ArgumentCAM16UCS
+	// ArgumentDeterministic uses a fixed random seed (and color order), so the same image always gives the same result
+	ArgumentDeterministic
+	// ArgumentOrientationInvariant resizes based on the longer side instead of the width, crops the same number of
+	// pixels on opposite sides and implies ArgumentDeterministic, so rotating or mirroring the image does not change
+	// the result (beyond resampling noise)
+	ArgumentOrientationInvariant
+	// ArgumentCountWeighted weights each color by its number of pixels when seeding and calculating centroids,
+	// instead of each unique color counting once (implied by Options.LabBinSize)
+	ArgumentCountWeighted
+	// ArgumentSaliency weights each pixel by its estimated saliency, so the colors of the subject dominate over the
+	// background without masks. Cnt then holds the weighted count, implies ArgumentCountWeighted
+	ArgumentSaliency
+	// ArgumentEdgeForeground only clusters the foreground, found by dilating the edges of the image and filling the
+	// area they enclose. Works best for busy subjects on plain backgrounds, combined with ArgumentNoCropping
+	ArgumentEdgeForeground
+	// ArgumentExcludeCodes excludes regions looking like QR codes or barcodes (high contrast black and white patterns),
+	// which otherwise make black and white dominant in e.g. photos of packaging and tickets
+	ArgumentExcludeCodes
+	// ArgumentPortable restricts the processing to integer math and floating point operations giving identical
+	// results on all architectures, so the same image gives a byte-identical palette on e.g. amd64 and arm64.
+	// Images are resized with ResizerBox and only the RGB space (or LAB with ArgumentFixedPointLAB) is supported,
+	// implies ArgumentDeterministic
+	ArgumentPortable
+	// ArgumentFixedPointLAB converts to LAB with fixed point (int32) math when measuring LAB or LCh distances:
+	// faster than the float conversion and identical on all architectures, see ArgumentPortable.
+	// The distances differ less than 0.0005 (L 0-1) from the float ones.
+	ArgumentFixedPointLAB
 )
 
 const (
@@ -42,17 +75,45 @@ const (
 	DefaultK = 3
 	// DefaultSize is the default size images are re-sized to
 	DefaultSize = 80
+	// OriginalSize as size (Options.Size or imageReSize) processes the image at its original resolution, e.g. for
+	// icons and swatches where resizing changes the counts
+	OriginalSize = 0
+	// DefaultAlphaThreshold is the alpha value (0-0xffff) below which pixels are skipped as transparent
+	DefaultAlphaThreshold = 0x8000
+	// DefaultWhiteThreshold is the value (0-0xffff) all channels of a pixel are at least to be removed by MaskWhite
+	DefaultWhiteThreshold = 0xc000
+	// DefaultBlackThreshold is the value (0-0xffff) all channels of a pixel are at most to be removed by MaskBlack
+	DefaultBlackThreshold = 0x5000
+	// DefaultGreenPercDiff is the largest ratio of red and blue to green of a pixel removed by MaskGreen
+	DefaultGreenPercDiff = 0.9
 )
 
 var (
 	// MaskWhite "constant" for white mask (for ease of re-use for other mask arrays)
-	MaskWhite = ColorBackgroundMask{R: true, G: true, B: true, Treshold: uint32(0xc000)}
+	MaskWhite = NewWhiteMask(DefaultWhiteThreshold)
 	// MaskBlack "constant" for black mask (for ease of re-use for other mask arrays)
-	MaskBlack = ColorBackgroundMask{R: false, G: false, B: false, Treshold: uint32(0x5000)}
+	MaskBlack = NewBlackMask(DefaultBlackThreshold)
 	// MaskGreen "constant" for green mask (for ease of re-use for other mask arrays)
-	MaskGreen = ColorBackgroundMask{R: false, G: true, B: false, PercDiff: 0.9}
+	MaskGreen = NewGreenMask(DefaultGreenPercDiff)
 )
 
+// NewWhiteMask creates a white mask removing pixels with all channels at least threshold (0-0xffff),
+// e.g. a higher threshold than DefaultWhiteThreshold keeps off-white cream colors
+func NewWhiteMask(threshold uint32) ColorBackgroundMask {
+	return ColorBackgroundMask{R: true, G: true, B: true, Treshold: threshold}
+}
+
+// NewBlackMask creates a black mask removing pixels with all channels at most threshold (0-0xffff),
+// e.g. a lower threshold than DefaultBlackThreshold keeps dark navy colors
+func NewBlackMask(threshold uint32) ColorBackgroundMask {
+	return ColorBackgroundMask{R: false, G: false, B: false, Treshold: threshold}
+}
+
+// NewGreenMask creates a green mask removing pixels where red and blue divided by green are below percDiff
+func NewGreenMask(percDiff float32) ColorBackgroundMask {
+	return ColorBackgroundMask{R: false, G: true, B: false, PercDiff: percDiff}
+}
+
 // ErrNoPixelsFound is returned when no non-alpha pixels are found in the provided image
 var ErrNoPixelsFound = fmt.Errorf("Failed, no non-alpha pixels found (either fully transparent image, or the ColorBackgroundMask removed all pixels)")
 
@@ -63,8 +124,24 @@ type ColorRGB struct {
 
 // ColorItem contains color and have many occurrences of this color found
 type ColorItem struct {
+	// Color has 8 bits per channel (0-0xff)
 	Color ColorRGB
 	Cnt   int
+
+	// Color16 is the same color with 16 bits per channel (0-0xffff), keeping the precision of 16 bit images
+	Color16 ColorRGB
+
+	// Percentage is the share (0-100) of the clustered pixels in this color, set on the colors of the results
+	Percentage float64
+
+	// ShareOfTotal is the share (0-1) of the opaque pixels in this color, counting the pixels removed by the masks
+	// (and other background removal) too, set on the colors of the results
+	ShareOfTotal float64
+
+	// Name is the closest CSS3 color keyword and NameDeltaE the CIEDE2000 delta E (0-100 scale) to it, set on the
+	// colors of the results if Options.ColorNames is set (see NearestColorName)
+	Name       string
+	NameDeltaE float64
 }
 
 // AsString gives back the color in hex as 6 character string
@@ -81,8 +158,22 @@ func createColor(c color.Color) (ColorItem, bool) {
 		return ColorItem{}, true
 	}
 
+	return newColorItem16(r, g, b, 0), false
+}
+
+// newColorItem16 creates a ColorItem from 16 bit values, deriving the 8 bit color from them
+func newColorItem16(r, g, b uint32, cnt int) ColorItem {
 	divby := uint32(256.0)
-	return ColorItem{Color: ColorRGB{R: r / divby, G: g / divby, B: b / divby}}, false
+	return ColorItem{
+		Color:   ColorRGB{R: r / divby, G: g / divby, B: b / divby},
+		Color16: ColorRGB{R: r, G: g, B: b},
+		Cnt:     cnt,
+	}
+}
+
+// key returns a map key unique for the 16 bit color
+func (c *ColorItem) key() uint64 {
+	return uint64(c.Color16.R)<<32 | uint64(c.Color16.G)<<16 | uint64(c.Color16.B)
 }
 
 // IsBitSet check if "lookingfor" is set in "bitset"
@@ -90,8 +181,17 @@ func IsBitSet(bitset int, lookingfor int) bool {
 	return lookingfor == (bitset & lookingfor)
 }
 
-// GetDefaultMasks returns the masks that are used for the default settings
-func GetDefaultMasks() []ColorBackgroundMask {
+// GetDefaultMasks returns the masks that are used for the default settings. If a tolerance (CIEDE2000 delta E, 0-100
+// scale) is given, the white and black masks ignore the colors within it instead of using the fixed thresholds,
+// e.g. a larger tolerance for low quality JPEGs. The green mask is hue based and not affected.
+func GetDefaultMasks(tolerance ...float64) []ColorBackgroundMask {
+	if len(tolerance) > 0 && tolerance[0] > 0 {
+		return []ColorBackgroundMask{
+			NewColorMask(ColorRGB{R: 0xff, G: 0xff, B: 0xff}, tolerance[0]),
+			NewColorMask(ColorRGB{}, tolerance[0]),
+			MaskGreen,
+		}
+	}
 	return []ColorBackgroundMask{MaskWhite, MaskBlack, MaskGreen}
 }
 
@@ -106,33 +206,57 @@ func KmeansWithArgs(arguments int, orgimg image.Image) (centroids []ColorItem, e
 	return KmeansWithAll(DefaultK, orgimg, arguments, DefaultSize, GetDefaultMasks())
 }
 
-// KmeansWithAll takes additional arguments to define k, arguments (see constants Argument*), size to resize and masks to use
+// KmeansWithAll takes additional arguments to define k, arguments (see constants Argument*), size to resize
+// (OriginalSize to not resize) and masks to use
 func KmeansWithAll(k int, orgimg image.Image, arguments int, imageReSize uint, bgmasks []ColorBackgroundMask) ([]ColorItem, error) {
+	res, err := KmeansWithOptions(orgimg, Options{K: k, Arguments: arguments, Size: imageReSize, Masks: bgmasks})
+	if err != nil {
+		return nil, err
+	}
+	return res.Colors, nil
+}
 
-	img := prepareImg(arguments, bgmasks, imageReSize, orgimg)
+// kmeansColors clusters the (unique) colors into k centroids, sorted according to dominance
+func kmeansColors(k int, allColors []ColorItem, arguments int) ([]ColorItem, error) {
+	centroids, _, err := clusterColors(k, allColors, arguments)
+	return centroids, err
+}
 
-	allColors, _ := extractColorsAsArray(img)
+// clusterColors clusters the colors as kmeansColors, also returning the iterations run and if they converged
+func clusterColors(k int, allColors []ColorItem, arguments int) ([]ColorItem, Convergence, error) {
 
 	numColors := len(allColors)
 
 	if numColors == 0 {
-		return nil, ErrNoPixelsFound
+		return nil, Convergence{}, ErrNoPixelsFound
 	}
 
+	// with no more colors than K each color is a centroid, the inertia is 0
 	if numColors == 1 {
-		return allColors, nil
+		return allColors, Convergence{Converged: true}, nil
 	}
 
 	if numColors <= k {
 		sortCentroids(allColors)
-		return allColors, nil
+		return allColors, Convergence{Converged: true}, nil
 	}
 
 	centroids, err := kmeansSeed(k, allColors, arguments)
 	if err != nil {
-		return nil, err
+		return nil, Convergence{}, err
+	}
+
+	if arguments&spaceArguments == 0 && isGrayscale(allColors) {
+		centroids, convergence := kmeansGray(allColors, centroids, arguments)
+		return centroids, convergence, nil
 	}
+	centroids, convergence := lloyd(allColors, centroids, arguments)
+	return centroids, convergence, nil
+}
 
+// lloyd runs the k-means iterations from the seeds
+func lloyd(allColors []ColorItem, centroids []ColorItem, arguments int) ([]ColorItem, Convergence) {
+	k := len(centroids)
 	cent := make([][]ColorItem, k)
 
 	//initialize
@@ -145,9 +269,13 @@ func KmeansWithAll(k int, orgimg image.Image, arguments int, imageReSize uint, b
 	rounds := 0
 	maxRounds := 5000
 	changes := 1
+	// sum is the inertia of the assignment, the one of the last round is of the final centroids if no color changed
+	// cluster
+	sum := 0.0
 
 	for changes > 0 && rounds < maxRounds {
 		changes = 0
+		sum = 0
 		tmpCent := make([][]ColorItem, k)
 		for i := 0; i < k; i++ {
 			tmpCent[i] = []ColorItem{}
@@ -155,7 +283,8 @@ func KmeansWithAll(k int, orgimg image.Image, arguments int, imageReSize uint, b
 
 		for i := 0; i < k; i++ {
 			for _, aColor := range cent[i] {
-				closestCentroid := findClosest(arguments, aColor, centroids)
+				closestCentroid, d := closest(arguments, aColor, centroids)
+				sum += float64(aColor.Cnt) * squaredDistance(arguments, d)
 
 				tmpCent[closestCentroid] = append(tmpCent[closestCentroid], aColor)
 				if closestCentroid != i {
@@ -173,7 +302,7 @@ func KmeansWithAll(k int, orgimg image.Image, arguments int, imageReSize uint, b
 	}
 
 	sortCentroids(centroids)
-	return centroids, nil
+	return centroids, Convergence{Iterations: rounds, Converged: changes == 0, Inertia: sum}
 }
 
 // ByColorCnt makes the ColorItem sortable
@@ -183,26 +312,46 @@ func (a byColorCnt) Len() int      { return len(a) }
 func (a byColorCnt) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a byColorCnt) Less(i, j int) bool {
 	if a[i].Cnt == a[j].Cnt {
+		if a[i].AsString() == a[j].AsString() {
+			return a[i].key() < a[j].key()
+		}
 		return a[i].AsString() < a[j].AsString()
 	}
 	return a[i].Cnt < a[j].Cnt
 }
 
-// sortCentroids sorts them from most dominant color descending
+// sortCentroids sorts them from most dominant color descending, equal counts by their hex string descending
 func sortCentroids(centroids []ColorItem) {
 	sort.Sort(sort.Reverse(byColorCnt(centroids)))
 }
 
+// moreDominant tells if a comes before b in the order of sortCentroids
+func moreDominant(a, b *ColorItem) bool {
+	if a.Cnt != b.Cnt {
+		return a.Cnt > b.Cnt
+	}
+	if ha, hb := a.AsString(), b.AsString(); ha != hb {
+		return ha > hb
+	}
+	return a.key() > b.key()
+}
+
 func calculateCentroids(cent [][]ColorItem, arguments int) []ColorItem {
 	var centroids []ColorItem
 
+	weighted := IsBitSet(arguments, ArgumentCountWeighted)
 	for _, colors := range cent {
 
 		var meanColor ColorItem
-		if IsBitSet(arguments, ArgumentAverageMean) {
-			meanColor = mean(colors)
-		} else {
-			meanColor = median(colors)
+		switch {
+		case IsBitSet(arguments, ArgumentLCh) && IsBitSet(arguments, ArgumentAverageMean):
+			meanColor = meanLCh(colors, weighted)
+		case IsBitSet(arguments, ArgumentLCh):
+			meanColor = medianLCh(colors, weighted)
+		case IsBitSet(arguments, ArgumentAverageMean):
+			meanColor = mean(colors, weighted)
+		default:
+			meanColor = median(colors, weighted)
 		}
 
 		centroids = append(centroids, meanColor)
@@ -211,59 +360,76 @@ func calculateCentroids(cent [][]ColorItem, arguments int) []ColorItem {
 	return centroids
 }
 
-// mean calculate the mean color values from an array of colors
-func mean(colors []ColorItem) ColorItem {
+// mean calculate the mean color values from an array of colors, optionally weighted by their count
+func mean(colors []ColorItem, weighted bool) ColorItem {
 
 	var r, g, b float64
 
 	r, g, b = 0.0, 0.0, 0.0
 
 	cntInThisBucket := 0
+	theSize := 0.0
 	for _, aColor := range colors {
 		cntInThisBucket += aColor.Cnt
-		r += float64(aColor.Color.R)
-		g += float64(aColor.Color.G)
-		b += float64(aColor.Color.B)
+		w := weight(aColor, weighted)
+		// the explicit conversions prevent fused multiply-adds, which only some architectures have (ArgumentPortable)
+		r += float64(float64(aColor.Color16.R) * w)
+		g += float64(float64(aColor.Color16.G) * w)
+		b += float64(float64(aColor.Color16.B) * w)
+		theSize += w
 	}
 
-	theSize := float64(len(colors))
-
-	return ColorItem{Cnt: cntInThisBucket, Color: ColorRGB{R: uint32(r / theSize), G: uint32(g / theSize), B: uint32(b / theSize)}}
+	return newColorItem16(uint32(r/theSize), uint32(g/theSize), uint32(b/theSize), cntInThisBucket)
 }
 
-// median calculate the median color from an array of colors
-func median(colors []ColorItem) ColorItem {
+// median calculate the median color from an array of colors, optionally weighted by their count
+func median(colors []ColorItem, weighted bool) ColorItem {
 
-	var rValues, gValues, bValues []int
+	var rValues, gValues, bValues, weights []float64
 
 	cntInThisBucket := 0
 
 	for _, aColor := range colors {
 		cntInThisBucket += aColor.Cnt
-		rValues = append(rValues, int(aColor.Color.R))
-		gValues = append(gValues, int(aColor.Color.G))
-		bValues = append(bValues, int(aColor.Color.B))
+		rValues = append(rValues, float64(aColor.Color16.R))
+		gValues = append(gValues, float64(aColor.Color16.G))
+		bValues = append(bValues, float64(aColor.Color16.B))
+		weights = append(weights, weight(aColor, weighted))
 	}
 
-	retR := 0
-	if 0 != len(rValues) {
-		sort.Ints(rValues)
-		retR = rValues[int(len(rValues)/2)]
-	}
+	return newColorItem16(uint32(medianOf(rValues, weights)), uint32(medianOf(gValues, weights)), uint32(medianOf(bValues, weights)), cntInThisBucket)
+}
 
-	retG := 0
-	if 0 != len(gValues) {
-		sort.Ints(gValues)
-		retG = gValues[int(len(gValues)/2)]
+// weight returns the count of the color if weighted, otherwise 1
+func weight(c ColorItem, weighted bool) float64 {
+	if weighted {
+		return float64(c.Cnt)
 	}
+	return 1
+}
 
-	retB := 0
-	if 0 != len(bValues) {
-		sort.Ints(bValues)
-		retB = bValues[int(len(bValues)/2)]
+// medianOf returns the weighted median, the smallest value where the cumulative weight exceeds half the total weight.
+// With all weights 1 this is the upper median (element len/2 of the sorted values), 0 if there are no values
+func medianOf(values, weights []float64) float64 {
+	if len(values) == 0 {
+		return 0
 	}
+	idx := make([]int, len(values))
+	total := 0.0
+	for i := range idx {
+		idx[i] = i
+		total += weights[i]
+	}
+	sort.Slice(idx, func(i, j int) bool { return values[idx[i]] < values[idx[j]] })
 
-	return ColorItem{Cnt: cntInThisBucket, Color: ColorRGB{R: uint32(retR), G: uint32(retG), B: uint32(retB)}}
+	sofar := 0.0
+	for _, i := range idx {
+		sofar += weights[i]
+		if sofar > total/2 {
+			return values[i]
+		}
+	}
+	return values[idx[len(idx)-1]]
 }
 
 // extractColorsAsArray counts the number of occurrences of each color in the image, returns array and numPixels
@@ -280,37 +446,72 @@ func extractColorsAsArray(img image.Image) ([]ColorItem, int) {
 }
 
 // extractColors counts the number of occurrences of each color in the image, returns map
-func extractColors(img image.Image) (map[string]ColorItem, int) {
+func extractColors(img image.Image) (map[uint64]ColorItem, int) {
 
-	m := make(map[string]ColorItem)
+	if p, ok := img.(*image.Paletted); ok {
+		return extractPaletteColors(p)
+	}
+
+	m := make(map[uint64]ColorItem)
 
+	// runs of pixels with the same color (the flat regions of e.g. screenshots and clipart) are counted at once
 	numPixels := 0
-	data := img.Bounds()
-	for x := data.Min.X; x < data.Max.X; x++ {
-		for y := data.Min.Y; y < data.Max.Y; y++ {
-			colorAt := img.At(x, y)
-			colorItem, ignore := createColor(colorAt)
-			if ignore {
-				continue
-			}
-			numPixels++
-			asString := colorItem.AsString()
-			value, ok := m[asString]
-			if ok {
-				value.Cnt++
-				m[asString] = value
-			} else {
-				colorItem.Cnt = 1
-				m[asString] = colorItem
-			}
+	forEachRun(img, func(x, y, n int, r, g, b, a uint32) {
+		if a == 0 {
+			// transparent pixels are ignored
+			return
 		}
-	}
+		numPixels += n
+		c := newColorItem16(r, g, b, n)
+		key := c.key()
+		if value, ok := m[key]; ok {
+			value.Cnt += n
+			m[key] = value
+		} else {
+			m[key] = c
+		}
+	})
 	return m, numPixels
 }
 
+// extractWeightedColors counts the colors like extractColorsAsArray, but each pixel counts its weight,
+// pixels with weight 0 are ignored
+func extractWeightedColors(img image.Image, weight func(x, y int) int) []ColorItem {
+	m := make(map[uint64]ColorItem)
+
+	forEachPixel(img, func(x, y int, r, g, bl, a uint32) {
+		if a == 0 {
+			return
+		}
+		w := weight(x, y)
+		if w == 0 {
+			return
+		}
+		c := newColorItem16(r, g, bl, w)
+		key := c.key()
+		if value, ok := m[key]; ok {
+			value.Cnt += w
+			m[key] = value
+		} else {
+			m[key] = c
+		}
+	})
+
+	colors := make([]ColorItem, 0, len(m))
+	for _, c := range m {
+		colors = append(colors, c)
+	}
+	return colors
+}
+
 // findClosest returns the index of the closest centroid to the color "c"
 func findClosest(arguments int, c ColorItem, centroids []ColorItem) int {
+	idx, _ := closest(arguments, c, centroids)
+	return idx
+}
 
+// closest returns the index of the closest centroid and the distance to it
+func closest(arguments int, c ColorItem, centroids []ColorItem) (int, float64) {
 	centLen := len(centroids)
 
 	closestIdx := 0
@@ -323,71 +524,55 @@ func findClosest(arguments int, c ColorItem, centroids []ColorItem) int {
 			closestDistance = distance
 		}
 	}
-	return closestIdx
+	return closestIdx, closestDistance
 }
 
 // distance returns the distance between two colors
 func distance(arguments int, c ColorItem, p ColorItem) float64 {
+	if IsBitSet(arguments, ArgumentCAM16UCS) {
+		return distanceCAM16UCS(c, p)
+	}
 	if IsBitSet(arguments, ArgumentCIEDE2000) {
 		return distanceCIEDE2000(c, p)
 	}
-	if IsBitSet(arguments, ArgumentLAB) {
+	if (IsBitSet(arguments, ArgumentLAB) || IsBitSet(arguments, ArgumentLCh)) && IsBitSet(arguments, ArgumentFixedPointLAB) {
+		return distanceFixedLAB(c, p)
+	}
+	if IsBitSet(arguments, ArgumentLAB) || IsBitSet(arguments, ArgumentLCh) {
 		return distanceLAB(c, p)
 	}
 	return distanceRGB(c, p)
 }
 
 func distanceLAB(c ColorItem, p ColorItem) float64 {
-	errmsg := "Warning: LAB failed, fallback to RGB"
-
-	a, err := colorful.Hex("#" + c.AsString())
-	if err != nil {
-		log.Fatal(err)
-		log.Println(errmsg)
-		return distanceRGB(c, p)
-	}
-
-	b, err2 := colorful.Hex("#" + p.AsString())
-	if err2 != nil {
-		log.Fatal(err2)
-		log.Println(errmsg)
-		return distanceRGB(c, p)
-	}
-
-	return a.DistanceLab(b)
+	return c.toColorful().DistanceLab(p.toColorful())
 }
 
 func distanceCIEDE2000(c ColorItem, p ColorItem) float64 {
-	errmsg := "Warning: CIEDE2000 failed, fallback to RGB"
-
-	a, err := colorful.Hex("#" + c.AsString())
-	if err != nil {
-		log.Fatal(err)
-		log.Println(errmsg)
-		return distanceRGB(c, p)
-	}
-
-	b, err2 := colorful.Hex("#" + p.AsString())
-	if err2 != nil {
-		log.Fatal(err2)
-		log.Println(errmsg)
-		return distanceRGB(c, p)
-	}
-
-	return a.DistanceCIEDE2000(b)
+	return c.toColorful().DistanceCIEDE2000(p.toColorful())
 }
 
 func distanceRGB(c ColorItem, p ColorItem) float64 {
-	r := c.Color.R
-	g := c.Color.G
-	b := c.Color.B
-
-	r2 := p.Color.R
-	g2 := p.Color.G
-	b2 := p.Color.B
+	r := float64(c.Color16.R) - float64(p.Color16.R)
+	g := float64(c.Color16.G) - float64(p.Color16.G)
+	b := float64(c.Color16.B) - float64(p.Color16.B)
 
 	//sqrt not needed since we just want to compare distances to each other
-	return float64((r-r2)*(r-r2) + (g-g2)*(g-g2) + (b-b2)*(b-b2))
+	return r*r + g*g + b*b
+}
+
+// toColorful converts the (16 bit) color to a colorful.Color
+func (c *ColorItem) toColorful() colorful.Color {
+	c16 := c.color16()
+	return colorful.Color{R: float64(c16.R) / 0xffff, G: float64(c16.G) / 0xffff, B: float64(c16.B) / 0xffff}
+}
+
+// color16 returns Color16, derived from Color if only that one is set (e.g. a ColorItem created by the caller)
+func (c *ColorItem) color16() ColorRGB {
+	if c.Color16 == (ColorRGB{}) && c.Color != (ColorRGB{}) {
+		return ColorRGB{R: c.Color.R * 0x101, G: c.Color.G * 0x101, B: c.Color.B * 0x101}
+	}
+	return c.Color16
 }
 
 // kmeansSeed calculates the initial cluster centroids
@@ -396,22 +581,27 @@ func kmeansSeed(k int, allColors []ColorItem, arguments int) ([]ColorItem, error
 		return nil, fmt.Errorf("Failed, k larger than len(allColors): %d vs %d\n", k, len(allColors))
 	}
 
-	rand.Seed(time.Now().UnixNano())
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	if IsBitSet(arguments, ArgumentDeterministic) {
+		// the order of allColors comes from a map, sort it so the same colors always give the same seeds
+		sort.Slice(allColors, func(i, j int) bool { return allColors[i].key() < allColors[j].key() })
+		rnd = rand.New(rand.NewSource(1))
+	}
 
 	if IsBitSet(arguments, ArgumentSeedRandom) {
-		return kmeansSeedRandom(k, allColors), nil
+		return kmeansSeedRandom(rnd, k, allColors), nil
 	}
-	return kmeansPlusPlusSeed(k, arguments, allColors), nil
+	return kmeansPlusPlusSeed(rnd, k, arguments, allColors), nil
 }
 
 // kmeansSeedRandom picks k random points as initial centroids
-func kmeansSeedRandom(k int, allColors []ColorItem) []ColorItem {
+func kmeansSeedRandom(rnd *rand.Rand, k int, allColors []ColorItem) []ColorItem {
 	var centroids []ColorItem
 
 	taken := make(map[int]bool)
 
 	for i := 0; i < k; i++ {
-		idx := rand.Intn(len(allColors))
+		idx := rnd.Intn(len(allColors))
 
 		//check if we already taken this one
 		_, ok := taken[idx]
@@ -426,12 +616,12 @@ func kmeansSeedRandom(k int, allColors []ColorItem) []ColorItem {
 }
 
 // kmeansPlusPlusSeed picks initial centroids using K-Means++
-func kmeansPlusPlusSeed(k int, arguments int, allColors []ColorItem) []ColorItem {
+func kmeansPlusPlusSeed(rnd *rand.Rand, k int, arguments int, allColors []ColorItem) []ColorItem {
 	var centroids []ColorItem
 
 	taken := make(map[int]bool)
 
-	initIdx := rand.Intn(len(allColors))
+	initIdx := rnd.Intn(len(allColors))
 	centroids = append(centroids, allColors[initIdx])
 	taken[initIdx] = true
 
@@ -456,23 +646,35 @@ func kmeansPlusPlusSeed(k int, arguments int, allColors []ColorItem) []ColorItem
 				}
 			}
 
-			squareDistance := minDistanceToCluster * minDistanceToCluster
+			// the explicit conversion prevents a fused multiply-add with the sum below (ArgumentPortable)
+			squareDistance := float64(minDistanceToCluster * minDistanceToCluster * weight(allColors[j], IsBitSet(arguments, ArgumentCountWeighted)))
 			totaldistances += squareDistance
 			point2distance = append(point2distance, squareDistance)
 		}
 
-		rndpoint := rand.Float64() * totaldistances
-
-		sofar := 0.0
-		for j := 0; j < len(point2distance); j++ {
-			if rndpoint <= sofar {
-				centroids = append(centroids, allColors[j])
-				taken[j] = true
-				break
-			}
-			sofar += point2distance[j]
-		}
+		picked := pickSeed(point2distance, taken, rnd.Float64()*totaldistances)
+		centroids = append(centroids, allColors[picked])
+		taken[picked] = true
 	}
 
 	return centroids
 }
+
+// pickSeed returns the index of the untaken color whose interval of the summed distances contains rndpoint, the last
+// untaken one if rounding leaves rndpoint past the end. Picking the color after the interval instead could pick a
+// taken color (or none), leaving fewer than k seeds and empty clusters.
+func pickSeed(point2distance []float64, taken map[int]bool, rndpoint float64) int {
+	picked := -1
+	sofar := 0.0
+	for j := 0; j < len(point2distance); j++ {
+		if _, ok := taken[j]; ok {
+			continue
+		}
+		picked = j
+		sofar += point2distance[j]
+		if rndpoint < sofar {
+			break
+		}
+	}
+	return picked
+}
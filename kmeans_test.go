@@ -0,0 +1,51 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPickSeed(t *testing.T) {
+	taken := map[int]bool{0: true}
+	for _, tc := range []struct {
+		rndpoint float64
+		want     int
+	}{
+		// the first interval is of color 1, not of the color after it
+		{0.5, 1},
+		{1.5, 2},
+		// past the end by rounding
+		{3, 3},
+	} {
+		if got := pickSeed([]float64{0, 1, 1, 1}, taken, tc.rndpoint); got != tc.want {
+			t.Errorf("Expected %d for %v, got %d", tc.want, tc.rndpoint, got)
+		}
+	}
+	// a taken color with a distance left (e.g. a duplicate) is never picked
+	if got := pickSeed([]float64{1, 1}, taken, 0.5); got != 1 {
+		t.Errorf("Expected the untaken color, got %d", got)
+	}
+}
+
+func TestKmeansPlusPlusSeedDistinct(t *testing.T) {
+	colors := []ColorItem{
+		newColorItem16(0xffff, 0, 0, 1),
+		newColorItem16(0, 0xffff, 0, 1),
+		newColorItem16(0, 0, 0xffff, 1),
+		newColorItem16(0x8000, 0x8000, 0x8000, 1),
+	}
+	for seed := int64(0); seed < 50; seed++ {
+		seeds := kmeansPlusPlusSeed(rand.New(rand.NewSource(seed)), len(colors), ArgumentDefault, colors)
+		seen := make(map[uint64]bool)
+		for _, c := range seeds {
+			seen[c.key()] = true
+		}
+		if len(seeds) != len(colors) || len(seen) != len(colors) {
+			t.Fatalf("Expected %d distinct seeds with seed %d, got %v", len(colors), seed, seeds)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// labelMapMaxColors is the largest number of colors of a label map, one palette index is left for the pixels
+// without color
+const labelMapMaxColors = 255
+
+// labelMap assigns each pixel of the processed image to the closest color of the result (or Result.Other) in the
+// color space of the clustering. The index of a pixel is that of its color in Result.Colors, len(Result.Colors) for
+// Result.Other, and the last index of the palette (transparent) for skipped and masked pixels.
+func labelMap(img image.Image, res Result, arguments int) *image.Paletted {
+	colors := res.Colors
+	if res.Other != nil {
+		colors = append(append([]ColorItem{}, colors...), *res.Other)
+	}
+	palette := append(Palette(colors), color.Transparent)
+	none := uint8(len(colors))
+
+	m := image.NewPaletted(img.Bounds(), palette)
+	if len(colors) == 0 {
+		for i := range m.Pix {
+			m.Pix[i] = none
+		}
+		return m
+	}
+	assigned := make(map[uint64]uint8)
+	forEachRun(img, func(x, y, n int, r, g, b, a uint32) {
+		idx := none
+		if a != 0 {
+			c := newColorItem16(r, g, b, 0)
+			var ok bool
+			if idx, ok = assigned[c.key()]; !ok {
+				idx = uint8(findClosest(arguments, c, colors))
+				assigned[c.key()] = idx
+			}
+		}
+		offset := m.PixOffset(x, y)
+		for i := 0; i < n; i++ {
+			m.Pix[offset+i] = idx
+		}
+	})
+	return m
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// lch is a color in the LCh(ab) space, hue in degrees
+type lch struct {
+	l, c, h float64
+}
+
+// toLCh converts the color to LCh(ab)
+func (c *ColorItem) toLCh() lch {
+	h, chroma, l := c.toColorful().Hcl()
+	return lch{l: l, c: chroma, h: h}
+}
+
+// colorItemFromLCh converts an LCh(ab) color back to a ColorItem, clamping colors outside of the sRGB gamut
+func colorItemFromLCh(v lch, cnt int) ColorItem {
+	rgb := colorful.Hcl(v.h, v.c, v.l).Clamped()
+	return newColorItem16(uint32(math.Round(rgb.R*0xffff)), uint32(math.Round(rgb.G*0xffff)), uint32(math.Round(rgb.B*0xffff)), cnt)
+}
+
+// meanLCh calculates the mean color in LCh(ab), averaging the hue on the circle.
+// The hue is weighted by chroma, so (almost) gray colors do not pull the hue and merging
+// two vivid hues keeps the chroma instead of passing through gray as averaging RGB/LAB does.
+func meanLCh(colors []ColorItem, weighted bool) ColorItem {
+	if len(colors) == 0 {
+		return ColorItem{}
+	}
+
+	var l, c, sinH, cosH, theSize float64
+	cnt := 0
+	for _, aColor := range colors {
+		cnt += aColor.Cnt
+		w := weight(aColor, weighted)
+		v := aColor.toLCh()
+		l += v.l * w
+		c += v.c * w
+		rad := v.h * math.Pi / 180
+		sinH += v.c * math.Sin(rad) * w
+		cosH += v.c * math.Cos(rad) * w
+		theSize += w
+	}
+
+	return colorItemFromLCh(lch{l: l / theSize, c: c / theSize, h: circularDegrees(sinH, cosH)}, cnt)
+}
+
+// medianLCh calculates the median lightness and chroma, with the (chroma weighted) circular mean as hue
+func medianLCh(colors []ColorItem, weighted bool) ColorItem {
+	if len(colors) == 0 {
+		return ColorItem{}
+	}
+
+	var lValues, cValues, weights []float64
+	var sinH, cosH float64
+	cnt := 0
+	for _, aColor := range colors {
+		cnt += aColor.Cnt
+		w := weight(aColor, weighted)
+		v := aColor.toLCh()
+		lValues = append(lValues, v.l)
+		cValues = append(cValues, v.c)
+		weights = append(weights, w)
+		rad := v.h * math.Pi / 180
+		sinH += v.c * math.Sin(rad) * w
+		cosH += v.c * math.Cos(rad) * w
+	}
+
+	return colorItemFromLCh(lch{l: medianOf(lValues, weights), c: medianOf(cValues, weights), h: circularDegrees(sinH, cosH)}, cnt)
+}
+
+// circularDegrees returns the angle (0-360) of the summed unit vectors
+func circularDegrees(sinSum, cosSum float64) float64 {
+	h := math.Atan2(sinSum, cosSum) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
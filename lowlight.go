@@ -0,0 +1,70 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import "image"
+
+const (
+	// LowLightLuma is the luma (0-0xffff) below which a pixel counts as dark for IsLowLight
+	LowLightLuma = 0x4000
+
+	// LowLightShare is the share (0-1) of the pixels that are dark in a low-light image
+	LowLightShare = 0.75
+
+	// MetadataAdjusted is the key of Result.Metadata naming the settings adjusted by Options.AutoLowLight
+	MetadataAdjusted = "adjusted"
+
+	// AdjustedLowLight is the value of MetadataAdjusted for low-light images
+	AdjustedLowLight = "low-light"
+)
+
+// IsLowLight tells if the image is a low-light image, e.g. a night shot or concert photo: at least LowLightShare of
+// the (non transparent) pixels are darker than LowLightLuma. Larger images are sampled (ResizerSample).
+func IsLowLight(img image.Image) bool {
+	if b := img.Bounds(); b.Dx()*b.Dy() > classifySamples {
+		img = sampleResizer{samples: classifySamples}.Resize(img, 0, 0)
+	}
+	colors, numPixels := extractColorsAsArray(img)
+	if numPixels == 0 {
+		return false
+	}
+
+	dark := 0
+	for _, c := range colors {
+		if luma(c) < LowLightLuma {
+			dark += c.Cnt
+		}
+	}
+	return float64(dark) >= LowLightShare*float64(numPixels)
+}
+
+// lowLight returns the options adjusted for low-light images: the masks removing black (the shadows holding most of
+// the image) are left out and EqualizeLuminance weights the highlights
+func (o Options) lowLight() Options {
+	var masks []ColorBackgroundMask
+	for _, mask := range o.Masks {
+		if !isBlackMask(mask) {
+			masks = append(masks, mask)
+		}
+	}
+	o.Masks = masks
+	o.EqualizeLuminance = true
+
+	metadata := make(map[string]string, len(o.Metadata)+1)
+	for k, v := range o.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetadataAdjusted] = AdjustedLowLight
+	o.Metadata = metadata
+	return o
+}
+
+// isBlackMask tells if the mask removes black, e.g. MaskBlack or a delta E mask of black
+func isBlackMask(mask ColorBackgroundMask) bool {
+	if mask.DeltaE > 0 {
+		return mask.Reference == ColorRGB{}
+	}
+	return !mask.R && !mask.G && !mask.B
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// And returns a mask excluding the pixels excluded by m and all the others,
+// e.g. ColorPixelMask(white).And(BorderPixelMask(bounds, 10)) for white pixels near the border
+func (m PixelMask) And(others ...PixelMask) PixelMask {
+	return func(x, y int, c color.Color) bool {
+		if !m(x, y, c) {
+			return false
+		}
+		for _, o := range others {
+			if !o(x, y, c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a mask excluding the pixels excluded by m or any of the others, as Options.PixelMasks combines its masks
+func (m PixelMask) Or(others ...PixelMask) PixelMask {
+	return func(x, y int, c color.Color) bool {
+		if m(x, y, c) {
+			return true
+		}
+		for _, o := range others {
+			if o(x, y, c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a mask excluding the pixels m keeps, i.e. keeping only the pixels m excludes
+func (m PixelMask) Not() PixelMask {
+	return func(x, y int, c color.Color) bool {
+		return !m(x, y, c)
+	}
+}
+
+// ColorPixelMask returns a mask excluding the pixels matching the background mask anywhere in the image,
+// not only the areas connected to the corners
+func ColorPixelMask(bgmask ColorBackgroundMask) PixelMask {
+	return func(x, y int, c color.Color) bool {
+		return bgmask.matches(c)
+	}
+}
+
+// RegionPixelMask returns a mask excluding the pixels inside r, e.g. a watermark corner
+func RegionPixelMask(r image.Rectangle) PixelMask {
+	return func(x, y int, c color.Color) bool {
+		return image.Pt(x, y).In(r)
+	}
+}
+
+// BorderPixelMask returns a mask excluding the pixels within width pixels of the border of bounds,
+// the bounds of the original image
+func BorderPixelMask(bounds image.Rectangle, width int) PixelMask {
+	return RegionPixelMask(bounds.Inset(width)).Not().And(RegionPixelMask(bounds))
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// maskChangeDeltaE is the CIEDE2000 delta E (0-100 scale) above which the top color counts as changed
+const maskChangeDeltaE = 10.0
+
+// MaskStat describes what a mask (or other background removal) removed from the image
+type MaskStat struct {
+	// Name is the mask, e.g. "white-background", "flood fill", "border background", "chroma key", "edge foreground" or "codes"
+	Name string
+
+	// Pixels is the number of (processed) pixels removed
+	Pixels int
+
+	// Average is the average color removed, Cnt is Pixels
+	Average ColorItem
+
+	// ChangedTop is set if the most prominent color would differ (more than delta E 10) without this mask
+	ChangedTop bool
+}
+
+// removal contains the pixels removed by a mask
+type removal struct {
+	name   string
+	points []image.Point
+	colors []color.Color
+}
+
+// findRemoved compares the image before and after a mask, collecting the pixels that became transparent
+func findRemoved(name string, before image.Image, after image.Image) removal {
+	r := removal{name: name}
+	b := after.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := before.At(x, y)
+			if _, ignore := createColor(c); ignore {
+				continue
+			}
+			if _, ignore := createColor(after.At(x, y)); ignore {
+				r.points = append(r.points, image.Point{X: x, Y: y})
+				r.colors = append(r.colors, c)
+			}
+		}
+	}
+	return r
+}
+
+// maskStats describes each removal, clustering the final image with the removed pixels put back to see if the top
+// color changes. Both are clustered deterministically so the random seeds do not cause differences.
+func maskStats(img image.Image, removals []removal, opts Options) ([]MaskStat, error) {
+	opts.Arguments |= ArgumentDeterministic
+	top, err := topColor(img, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []MaskStat
+	for _, r := range removals {
+		stat := MaskStat{Name: r.name, Pixels: len(r.points)}
+		if len(r.points) == 0 {
+			stats = append(stats, stat)
+			continue
+		}
+
+		var removed []ColorItem
+		restored := createDrawImage(img)
+		for i, p := range r.points {
+			c, _ := createColor(r.colors[i])
+			c.Cnt = 1
+			removed = append(removed, c)
+			restored.Set(p.X, p.Y, r.colors[i])
+		}
+		stat.Average = mean(removed, false)
+
+		without, err := topColor(restored, opts)
+		if err != nil {
+			return nil, err
+		}
+		stat.ChangedTop = top == nil || distanceCIEDE2000(*top, *without)*100 > maskChangeDeltaE
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// topColor clusters the prepared image and returns the most prominent color, nil if all pixels were removed
+func topColor(img image.Image, opts Options) (*ColorItem, error) {
+	colors := opts.colors(img)
+	if len(colors) == 0 {
+		return nil, nil
+	}
+	centroids, err := kmeansColors(opts.k(), colors, opts.arguments())
+	if err != nil {
+		return nil, err
+	}
+	return &centroids[0], nil
+}
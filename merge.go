@@ -0,0 +1,145 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+// MergeSimilarColors merges the colors closer than deltaE (CIEDE2000, 0-100 scale), e.g. two nearly identical blues
+// of a large K: the closest pair is merged first, into their mean color weighted by count with the counts and
+// shares summed, until no pair is closer. The result is sorted by count, most frequent first.
+func MergeSimilarColors(colors []ColorItem, deltaE float64) []ColorItem {
+	merged, _ := mergeSimilar(colors, deltaE)
+	return merged
+}
+
+// mergeSimilar merges the colors as MergeSimilarColors, also returning the indices of the colors merged into each
+func mergeSimilar(colors []ColorItem, deltaE float64) ([]ColorItem, [][]int) {
+	merged := append([]ColorItem{}, colors...)
+	groups := make([][]int, len(colors))
+	for i := range groups {
+		groups[i] = []int{i}
+	}
+
+	for {
+		bi, bj, best := -1, -1, deltaE
+		for i := range merged {
+			for j := i + 1; j < len(merged); j++ {
+				if d := distanceCIEDE2000(merged[i], merged[j]) * 100; d < best {
+					bi, bj, best = i, j, d
+				}
+			}
+		}
+		if bi < 0 {
+			break
+		}
+
+		pair := []ColorItem{merged[bi], merged[bj]}
+		for k := range pair {
+			// colors created by the caller may only have the 8 bit color
+			pair[k].Color16 = pair[k].color16()
+		}
+		c := mean(pair, pair[0].Cnt+pair[1].Cnt > 0)
+		c.Percentage = pair[0].Percentage + pair[1].Percentage
+		c.ShareOfTotal = pair[0].ShareOfTotal + pair[1].ShareOfTotal
+		merged[bi] = c
+		groups[bi] = append(groups[bi], groups[bj]...)
+		merged = append(merged[:bj], merged[bj+1:]...)
+		groups = append(groups[:bj], groups[bj+1:]...)
+	}
+
+	order := sortOrder(merged, SortCount)
+	sorted, sortedGroups := make([]ColorItem, len(order)), make([][]int, len(order))
+	for i, idx := range order {
+		sorted[i], sortedGroups[i] = merged[idx], groups[idx]
+	}
+	return sorted, sortedGroups
+}
+
+// mergeDetails combines the details of the clusters merged into one, the averages weighted by pixels. The delta E,
+// variance and distances stay those to the centroids before merging.
+func mergeDetails(details []clusterDetail, group []int) clusterDetail {
+	var d clusterDetail
+	for _, idx := range group {
+		if idx >= len(details) {
+			continue
+		}
+		src := details[idx]
+		d.pixels += src.pixels
+		d.centerPixels += src.centerPixels
+		d.centerArea = src.centerArea
+		d.avgDeltaE += src.avgDeltaE * float64(src.pixels)
+		d.variance += src.variance * float64(src.pixels)
+		d.avgDistance += src.avgDistance * float64(src.pixels)
+		d.maxDistance = max(d.maxDistance, src.maxDistance)
+		d.bounds = d.bounds.Union(src.bounds)
+		d.centerX += src.centerX * float64(src.pixels)
+		d.centerY += src.centerY * float64(src.pixels)
+	}
+	if d.pixels > 0 {
+		d.avgDeltaE /= float64(d.pixels)
+		d.variance /= float64(d.pixels)
+		d.avgDistance /= float64(d.pixels)
+		d.centerX /= float64(d.pixels)
+		d.centerY /= float64(d.pixels)
+	}
+	return d
+}
+
+// DropSmallColors removes the colors with a Percentage below minPercentage, e.g. tiny noise clusters. If
+// redistribute is set their counts and shares are added to the closest remaining color (CIEDE2000), whose color is
+// kept. The most dominant color is always kept. The result is sorted by count, most frequent first.
+func DropSmallColors(colors []ColorItem, minPercentage float64, redistribute bool) []ColorItem {
+	kept, _ := dropSmall(colors, minPercentage, redistribute)
+	return kept
+}
+
+// dropSmall drops the colors as DropSmallColors, also returning the indices of the colors counted in each
+func dropSmall(colors []ColorItem, minPercentage float64, redistribute bool) ([]ColorItem, [][]int) {
+	order := sortOrder(colors, SortCount)
+	var kept []ColorItem
+	var groups [][]int
+	var dropped []int
+	for i, idx := range order {
+		if i > 0 && colors[idx].Percentage < minPercentage {
+			dropped = append(dropped, idx)
+			continue
+		}
+		kept = append(kept, colors[idx])
+		groups = append(groups, []int{idx})
+	}
+	if !redistribute {
+		return kept, groups
+	}
+
+	for _, idx := range dropped {
+		closest, best := 0, -1.0
+		for i := range kept {
+			if d := distanceCIEDE2000(colors[idx], kept[i]); best < 0 || d < best {
+				closest, best = i, d
+			}
+		}
+		kept[closest].Cnt += colors[idx].Cnt
+		kept[closest].Percentage += colors[idx].Percentage
+		kept[closest].ShareOfTotal += colors[idx].ShareOfTotal
+		groups[closest] = append(groups[closest], idx)
+	}
+
+	order = sortOrder(kept, SortCount)
+	sorted, sortedGroups := make([]ColorItem, len(order)), make([][]int, len(order))
+	for i, idx := range order {
+		sorted[i], sortedGroups[i] = kept[idx], groups[idx]
+	}
+	return sorted, sortedGroups
+}
+
+// regroupDetails replaces the details of the result by those of the groups of clusters the colors were made of
+func regroupDetails(res *Result, groups [][]int) {
+	if res.details == nil {
+		return
+	}
+	details := make([]clusterDetail, len(groups))
+	for i, group := range groups {
+		details[i] = mergeDetails(res.details, group)
+	}
+	res.details = details
+}
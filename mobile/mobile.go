@@ -0,0 +1,175 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mobile wraps the color extraction in the types gomobile can bind (numbers, strings, booleans, byte slices
+// and pointers to structs of them), so Android and iOS apps can call it natively:
+//
+//	gomobile bind -target=android github.com/cjkgg/prominentcolor/mobile
+//
+// The palettes are returned as a Palette with indexed access to its colors, as gomobile does not bind slices of
+// structs.
+package mobile
+
+import (
+	"fmt"
+
+	"github.com/cjkgg/prominentcolor"
+)
+
+// The pixel formats of FromPixels, see prominentcolor.PixelFormat
+const (
+	FormatRGBA = int(prominentcolor.PixelFormatRGBA)
+	FormatBGRA = int(prominentcolor.PixelFormatBGRA)
+	FormatRGBX = int(prominentcolor.PixelFormatRGBX)
+	FormatBGRX = int(prominentcolor.PixelFormatBGRX)
+	FormatRGB  = int(prominentcolor.PixelFormatRGB)
+	FormatBGR  = int(prominentcolor.PixelFormatBGR)
+)
+
+// Options are the settings of the extraction, a subset of prominentcolor.Options. The zero value clusters the
+// original size image without masks, NewOptions returns the defaults of prominentcolor.Kmeans.
+type Options struct {
+	// K is the number of colors, prominentcolor.DefaultK if 0
+	K int
+
+	// Size is the width the image is resized to, 0 keeps the original size
+	Size int
+
+	// NoCropping processes the whole image instead of its center
+	NoCropping bool
+
+	// Space is the color space of the clustering: "rgb" (if empty), "lab", "lch", "ciede2000" or "cam16-ucs"
+	Space string
+
+	// DefaultMasks removes white, black and green backgrounds
+	DefaultMasks bool
+
+	// Deterministic gives the same colors for the same image on every run
+	Deterministic bool
+
+	// ColorNames sets the Name of the colors (not supported in the tiny build)
+	ColorNames bool
+}
+
+// NewOptions returns the options used by prominentcolor.Kmeans
+func NewOptions() *Options {
+	return &Options{K: prominentcolor.DefaultK, Size: prominentcolor.DefaultSize, DefaultMasks: true}
+}
+
+// options converts the options, nil giving the defaults
+func (o *Options) options() (prominentcolor.Options, error) {
+	if o == nil {
+		o = NewOptions()
+	}
+	if o.K < 0 || o.Size < 0 {
+		return prominentcolor.Options{}, fmt.Errorf("Failed, K and Size can not be negative: %d, %d", o.K, o.Size)
+	}
+	opts := prominentcolor.Options{K: o.K, Size: uint(o.Size), ColorNames: o.ColorNames}
+	if opts.K == 0 {
+		opts.K = prominentcolor.DefaultK
+	}
+	if o.NoCropping {
+		opts.Crop = prominentcolor.CropNone
+	}
+	if o.Space != "" {
+		space, err := prominentcolor.ParseSpaceMode(o.Space)
+		if err != nil {
+			return prominentcolor.Options{}, err
+		}
+		opts.Space = space
+	}
+	if o.DefaultMasks {
+		opts.Masks = prominentcolor.GetDefaultMasks()
+	}
+	if o.Deterministic {
+		opts.Arguments |= prominentcolor.ArgumentDeterministic
+	}
+	return opts, nil
+}
+
+// Color is a color of a palette
+type Color struct {
+	// Hex is the color as RRGGBB, R, G and B its channels (0-255)
+	Hex     string
+	R, G, B int
+
+	// Percentage is the share (0-100) of the clustered pixels
+	Percentage float64
+
+	// Name is the closest CSS color keyword, if Options.ColorNames is set
+	Name string
+}
+
+// newColor converts a color
+func newColor(c prominentcolor.ColorItem) *Color {
+	return &Color{Hex: c.AsString(), R: int(c.Color.R), G: int(c.Color.G), B: int(c.Color.B), Percentage: c.Percentage,
+		Name: c.Name}
+}
+
+// Palette is the result of an extraction
+type Palette struct {
+	// Fingerprint identifies the algorithm version and options, see prominentcolor.Result.Fingerprint
+	Fingerprint string
+
+	colors []prominentcolor.ColorItem
+}
+
+// Len returns the number of colors
+func (p *Palette) Len() int {
+	return len(p.colors)
+}
+
+// Get returns the color at index i (0 is the most prominent), nil if out of range
+func (p *Palette) Get(i int) *Color {
+	if i < 0 || i >= len(p.colors) {
+		return nil
+	}
+	return newColor(p.colors[i])
+}
+
+// newPalette converts a result
+func newPalette(res prominentcolor.Result) *Palette {
+	return &Palette{Fingerprint: res.Fingerprint, colors: res.Colors}
+}
+
+// FromBytes finds the colors of an encoded image (e.g. JPEG or PNG), nil options giving the defaults
+func FromBytes(data []byte, o *Options) (*Palette, error) {
+	opts, err := o.options()
+	if err != nil {
+		return nil, err
+	}
+	res, err := prominentcolor.KmeansFromBytes(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newPalette(res), nil
+}
+
+// FromPixels finds the colors of a raw pixel buffer (e.g. an Android Bitmap copied with copyPixelsToBuffer, or the
+// data of a CGImage) in one of the Format constants, stride being the number of bytes per row
+func FromPixels(pix []byte, width, height, stride, format int, o *Options) (*Palette, error) {
+	opts, err := o.options()
+	if err != nil {
+		return nil, err
+	}
+	res, err := prominentcolor.KmeansRaw(pix, width, height, stride, prominentcolor.PixelFormat(format), opts)
+	if err != nil {
+		return nil, err
+	}
+	return newPalette(res), nil
+}
+
+// DominantColor returns the most prominent color of an encoded image without clustering, see
+// prominentcolor.DominantColorFromBytes
+func DominantColor(data []byte, o *Options) (*Color, error) {
+	opts, err := o.options()
+	if err != nil {
+		return nil, err
+	}
+	c, err := prominentcolor.DominantColorFromBytes(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newColor(c), nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mobile
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/cjkgg/prominentcolor"
+)
+
+// testOptions cluster the whole original size image into 2 colors
+func testOptions() *Options {
+	return &Options{K: 2, NoCropping: true, Deterministic: true}
+}
+
+func TestFromPixels(t *testing.T) {
+	// 4x2 BGRA with a padded stride, red on the left and blue on the right
+	const width, height, stride = 4, 2, 20
+	pix := make([]byte, stride*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			px := pix[y*stride+x*4:]
+			if x < width/2 {
+				px[2] = 0xff
+			} else {
+				px[0] = 0xff
+			}
+			px[3] = 0xff
+		}
+	}
+	p, err := FromPixels(pix, width, height, stride, FormatBGRA, testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 2 || p.Fingerprint == "" {
+		t.Fatalf("Expected 2 colors and a fingerprint, got %d and %q", p.Len(), p.Fingerprint)
+	}
+	hexes := map[string]float64{}
+	for i := 0; i < p.Len(); i++ {
+		c := p.Get(i)
+		hexes[c.Hex] = c.Percentage
+	}
+	if hexes["FF0000"] != 50 || hexes["0000FF"] != 50 {
+		t.Errorf("Expected half red and half blue, got %v", hexes)
+	}
+	if c := p.Get(0); c.Hex == "FF0000" && c.R != 0xff || c.Hex == "0000FF" && c.B != 0xff {
+		t.Errorf("Expected the channels of %s, got %+v", c.Hex, c)
+	}
+	if p.Get(-1) != nil || p.Get(2) != nil {
+		t.Error("Expected nil out of range")
+	}
+
+	if _, err := FromPixels(pix[:stride], width, height, stride, FormatBGRA, testOptions()); err == nil {
+		t.Error("Expected an error for a short buffer")
+	}
+}
+
+func TestFromBytes(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < len(img.Pix); i += 4 {
+		c := color.NRGBA{G: 0xff, A: 0xff}
+		if i < len(img.Pix)/4 {
+			c = color.NRGBA{R: 0xff, A: 0xff}
+		}
+		copy(img.Pix[i:i+4], []uint8{c.R, c.G, c.B, c.A})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := FromBytes(buf.Bytes(), testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := p.Get(0); p.Len() != 2 || c.Hex != "00FF00" || c.Percentage != 75 {
+		t.Errorf("Expected green first at 75%%, got %+v of %d colors", c, p.Len())
+	}
+
+	c, err := DominantColor(buf.Bytes(), testOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Hex != "00FF00" {
+		t.Errorf("Expected green to dominate, got %+v", c)
+	}
+
+	if _, err := FromBytes([]byte("not an image"), nil); err == nil {
+		t.Error("Expected an error for invalid data")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	opts, err := (*Options)(nil).options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.K != prominentcolor.DefaultK || opts.Size != prominentcolor.DefaultSize || len(opts.Masks) == 0 {
+		t.Errorf("Expected the defaults for nil options, got %+v", opts)
+	}
+
+	opts, err = (&Options{Space: "lab", NoCropping: true, Deterministic: true}).options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.K != prominentcolor.DefaultK || opts.Space != prominentcolor.SpaceLAB || opts.Crop != prominentcolor.CropNone ||
+		opts.Arguments&prominentcolor.ArgumentDeterministic == 0 || opts.Masks != nil {
+		t.Errorf("Expected the options to be converted, got %+v", opts)
+	}
+
+	for name, o := range map[string]*Options{
+		"negative K":    {K: -1},
+		"negative size": {Size: -1},
+		"unknown space": {Space: "xyz"},
+	} {
+		if _, err := o.options(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SeedMode defines how the initial centroids are picked
+type SeedMode int
+
+const (
+	// SeedKmeansPlusPlus picks initial centroids that are far apart (default)
+	SeedKmeansPlusPlus SeedMode = iota
+	// SeedRandom picks random initial centroids
+	SeedRandom
+)
+
+// AverageMode defines how the color of a centroid is calculated
+type AverageMode int
+
+const (
+	// AverageMedian takes the median color of the cluster (default)
+	AverageMedian AverageMode = iota
+	// AverageMean takes the mean color of the cluster
+	AverageMean
+)
+
+// SpaceMode defines the color space distances are measured in
+type SpaceMode int
+
+const (
+	// SpaceRGB uses the euclidean distance in RGB (default)
+	SpaceRGB SpaceMode = iota
+	// SpaceLAB uses the euclidean distance in LAB
+	SpaceLAB
+	// SpaceLCh uses LAB distance and calculates centroids in LCh(ab), see ArgumentLCh
+	SpaceLCh
+	// SpaceCIEDE2000 uses the CIEDE2000 color difference
+	SpaceCIEDE2000
+	// SpaceCAM16UCS uses the distance in CAM16-UCS, see ArgumentCAM16UCS
+	SpaceCAM16UCS
+)
+
+// CropMode defines what part of the image is processed
+type CropMode int
+
+const (
+	// CropCenter processes the center of the image, removing 25% on all sides (default)
+	CropCenter CropMode = iota
+	// CropNone processes the whole image
+	CropNone
+)
+
+// SortMode defines the order of the colors of a result
+type SortMode int
+
+const (
+	// SortCount orders the colors by their number of pixels, most frequent first (default), equal counts by their hex
+	// value descending so the order is the same on every run
+	SortCount SortMode = iota
+	// SortHue orders the colors by LCh(ab) hue angle starting at red, the grays (chroma below SortGrayChroma)
+	// following from dark to light
+	SortHue
+	// SortLightness orders the colors by LAB lightness, from dark to light
+	SortLightness
+	// SortChroma orders the colors by LAB chroma, the most vivid first
+	SortChroma
+)
+
+// spaceArguments are the legacy bits selecting a color space
+const spaceArguments = ArgumentLAB | ArgumentLCh | ArgumentCIEDE2000 | ArgumentCAM16UCS
+
+func (m SeedMode) String() string {
+	switch m {
+	case SeedKmeansPlusPlus:
+		return "kmeans++"
+	case SeedRandom:
+		return "random"
+	}
+	return "unknown"
+}
+
+func (m AverageMode) String() string {
+	switch m {
+	case AverageMedian:
+		return "median"
+	case AverageMean:
+		return "mean"
+	}
+	return "unknown"
+}
+
+func (m SpaceMode) String() string {
+	switch m {
+	case SpaceRGB:
+		return "rgb"
+	case SpaceLAB:
+		return "lab"
+	case SpaceLCh:
+		return "lch"
+	case SpaceCIEDE2000:
+		return "ciede2000"
+	case SpaceCAM16UCS:
+		return "cam16-ucs"
+	}
+	return "unknown"
+}
+
+func (m CropMode) String() string {
+	switch m {
+	case CropCenter:
+		return "center"
+	case CropNone:
+		return "none"
+	}
+	return "unknown"
+}
+
+func (m SortMode) String() string {
+	switch m {
+	case SortCount:
+		return "count"
+	case SortHue:
+		return "hue"
+	case SortLightness:
+		return "lightness"
+	case SortChroma:
+		return "chroma"
+	}
+	return "unknown"
+}
+
+// WithArguments returns a copy of the options where the legacy bits (see constants Argument*) are converted to the
+// typed modes. Bits that are not modes (e.g. ArgumentDebugImage) are kept in Arguments.
+// If several color spaces are set, the one used by the distance calculation wins (CAM16-UCS, CIEDE2000, LCh, LAB).
+func (o Options) WithArguments(arguments int) Options {
+	o.Seed = SeedKmeansPlusPlus
+	if IsBitSet(arguments, ArgumentSeedRandom) {
+		o.Seed = SeedRandom
+	}
+
+	o.Average = AverageMedian
+	if IsBitSet(arguments, ArgumentAverageMean) {
+		o.Average = AverageMean
+	}
+
+	o.Crop = CropCenter
+	if IsBitSet(arguments, ArgumentNoCropping) {
+		o.Crop = CropNone
+	}
+
+	switch {
+	case IsBitSet(arguments, ArgumentCAM16UCS):
+		o.Space = SpaceCAM16UCS
+	case IsBitSet(arguments, ArgumentCIEDE2000):
+		o.Space = SpaceCIEDE2000
+	case IsBitSet(arguments, ArgumentLCh):
+		o.Space = SpaceLCh
+	case IsBitSet(arguments, ArgumentLAB):
+		o.Space = SpaceLAB
+	default:
+		o.Space = SpaceRGB
+	}
+
+	o.Arguments = arguments &^ (ArgumentSeedRandom | ArgumentAverageMean | ArgumentNoCropping | spaceArguments)
+	return o
+}
+
+// arguments returns the bits used internally, combining the typed modes with the legacy Arguments.
+// A non-default mode overrides the corresponding legacy bits.
+func (o Options) arguments() int {
+	arguments := o.Arguments
+
+	if o.LabBinSize > 0 || o.EqualizeLuminance {
+		arguments |= ArgumentCountWeighted
+	}
+	if o.OrientationInvariant {
+		arguments |= ArgumentOrientationInvariant
+	}
+	if IsBitSet(arguments, ArgumentSaliency) || len(o.SafeAreas) > 0 {
+		arguments |= ArgumentCountWeighted
+	}
+	if len(o.SafeAreas) > 0 {
+		arguments |= ArgumentNoCropping
+	}
+	if o.Portable {
+		arguments |= ArgumentPortable
+	}
+	if o.FixedPointLAB {
+		arguments |= ArgumentFixedPointLAB
+	}
+	if IsBitSet(arguments, ArgumentOrientationInvariant) || IsBitSet(arguments, ArgumentPortable) {
+		arguments |= ArgumentDeterministic
+	}
+
+	if o.Seed == SeedRandom {
+		arguments |= ArgumentSeedRandom
+	}
+	if o.Average == AverageMean {
+		arguments |= ArgumentAverageMean
+	}
+	if o.Crop == CropNone {
+		arguments |= ArgumentNoCropping
+	}
+
+	if o.Space != SpaceRGB {
+		arguments &^= spaceArguments
+		switch o.Space {
+		case SpaceLAB:
+			arguments |= ArgumentLAB
+		case SpaceLCh:
+			arguments |= ArgumentLCh
+		case SpaceCIEDE2000:
+			arguments |= ArgumentCIEDE2000
+		case SpaceCAM16UCS:
+			arguments |= ArgumentCAM16UCS
+		}
+	}
+	return arguments
+}
+
+// SeedModes returns all seed modes, the algorithms picking the initial centroids of K-means
+func SeedModes() []SeedMode {
+	return []SeedMode{SeedKmeansPlusPlus, SeedRandom}
+}
+
+// AverageModes returns all average modes
+func AverageModes() []AverageMode {
+	return []AverageMode{AverageMedian, AverageMean}
+}
+
+// SpaceModes returns all space modes, the color spaces distances are measured in
+func SpaceModes() []SpaceMode {
+	return []SpaceMode{SpaceRGB, SpaceLAB, SpaceLCh, SpaceCIEDE2000, SpaceCAM16UCS}
+}
+
+// CropModes returns all crop modes
+func CropModes() []CropMode {
+	return []CropMode{CropCenter, CropNone}
+}
+
+// SortModes returns all sort modes
+func SortModes() []SortMode {
+	return []SortMode{SortCount, SortHue, SortLightness, SortChroma}
+}
+
+// optionAlgorithms are the algorithms enabled by options rather than modes, listed by Algorithms after the modes
+var optionAlgorithms = []string{"grayscale-1d", "lab-histogram", "saliency", "edge-foreground"}
+
+// Algorithms returns the names of the algorithms, as in Capabilities: the seed modes (see ParseSeedMode), the
+// average modes (see ParseAverageMode) and the algorithms enabled by options (e.g. "lab-histogram" by LabBinSize)
+func Algorithms() []string {
+	names := []string{"kmeans"}
+	for _, m := range SeedModes() {
+		names = append(names, m.String())
+	}
+	for _, m := range AverageModes() {
+		names = append(names, m.String())
+	}
+	return append(names, optionAlgorithms...)
+}
+
+// Metrics returns the names of the space modes, the color spaces distances are measured in (see ParseSpaceMode), as
+// in Capabilities
+func Metrics() []string {
+	var names []string
+	for _, m := range SpaceModes() {
+		names = append(names, m.String())
+	}
+	return names
+}
+
+// parseMode finds the mode named s (case insensitive) among the modes
+func parseMode[M fmt.Stringer](kind, s string, modes []M) (M, error) {
+	var names []string
+	for _, m := range modes {
+		if strings.EqualFold(m.String(), s) {
+			return m, nil
+		}
+		names = append(names, m.String())
+	}
+	var zero M
+	return zero, fmt.Errorf("Failed, unknown %s %q, one of %s", kind, s, strings.Join(names, ", "))
+}
+
+// ParseSeedMode returns the seed mode with the name, e.g. "kmeans++"
+func ParseSeedMode(s string) (SeedMode, error) {
+	return parseMode("seed mode", s, SeedModes())
+}
+
+// ParseAverageMode returns the average mode with the name, e.g. "median"
+func ParseAverageMode(s string) (AverageMode, error) {
+	return parseMode("average mode", s, AverageModes())
+}
+
+// ParseSpaceMode returns the space mode with the name, e.g. "ciede2000"
+func ParseSpaceMode(s string) (SpaceMode, error) {
+	return parseMode("space mode", s, SpaceModes())
+}
+
+// ParseCropMode returns the crop mode with the name, e.g. "none"
+func ParseCropMode(s string) (CropMode, error) {
+	return parseMode("crop mode", s, CropModes())
+}
+
+// ParseSortMode returns the sort mode with the name, e.g. "hue"
+func ParseSortMode(s string) (SortMode, error) {
+	return parseMode("sort mode", s, SortModes())
+}
+
+// MarshalText returns the name of the mode, e.g. for JSON
+func (m SeedMode) MarshalText() ([]byte, error) { return []byte(m.String()), nil }
+
+// UnmarshalText parses the name of the mode as ParseSeedMode, e.g. for flag.TextVar and JSON
+func (m *SeedMode) UnmarshalText(text []byte) (err error) {
+	*m, err = ParseSeedMode(string(text))
+	return err
+}
+
+// MarshalText returns the name of the mode, e.g. for JSON
+func (m AverageMode) MarshalText() ([]byte, error) { return []byte(m.String()), nil }
+
+// UnmarshalText parses the name of the mode as ParseAverageMode, e.g. for flag.TextVar and JSON
+func (m *AverageMode) UnmarshalText(text []byte) (err error) {
+	*m, err = ParseAverageMode(string(text))
+	return err
+}
+
+// MarshalText returns the name of the mode, e.g. for JSON
+func (m SpaceMode) MarshalText() ([]byte, error) { return []byte(m.String()), nil }
+
+// UnmarshalText parses the name of the mode as ParseSpaceMode, e.g. for flag.TextVar and JSON
+func (m *SpaceMode) UnmarshalText(text []byte) (err error) {
+	*m, err = ParseSpaceMode(string(text))
+	return err
+}
+
+// MarshalText returns the name of the mode, e.g. for JSON
+func (m CropMode) MarshalText() ([]byte, error) { return []byte(m.String()), nil }
+
+// UnmarshalText parses the name of the mode as ParseCropMode, e.g. for flag.TextVar and JSON
+func (m *CropMode) UnmarshalText(text []byte) (err error) {
+	*m, err = ParseCropMode(string(text))
+	return err
+}
+
+// MarshalText returns the name of the mode, e.g. for JSON
+func (m SortMode) MarshalText() ([]byte, error) { return []byte(m.String()), nil }
+
+// UnmarshalText parses the name of the mode as ParseSortMode, e.g. for flag.TextVar and JSON
+func (m *SortMode) UnmarshalText(text []byte) (err error) {
+	*m, err = ParseSortMode(string(text))
+	return err
+}
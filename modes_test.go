@@ -0,0 +1,67 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"encoding"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// modeRoundTrip checks every mode parses back from its name, also as text and case insensitive
+func modeRoundTrip[M interface {
+	comparable
+	String() string
+}](t *testing.T, modes []M, parse func(string) (M, error), unmarshal func(*M) encoding.TextUnmarshaler) {
+	t.Helper()
+	for _, m := range modes {
+		if got, err := parse(strings.ToUpper(m.String())); err != nil || got != m {
+			t.Errorf("Expected %q to parse to %v, got %v, %v", m.String(), m, got, err)
+		}
+		var got M
+		if err := unmarshal(&got).UnmarshalText([]byte(m.String())); err != nil || got != m {
+			t.Errorf("Expected %q to unmarshal to %v, got %v, %v", m.String(), m, got, err)
+		}
+	}
+	if _, err := parse("bogus"); err == nil {
+		t.Error("Expected an error for an unknown name")
+	}
+}
+
+func TestModesParse(t *testing.T) {
+	modeRoundTrip(t, SeedModes(), ParseSeedMode, func(m *SeedMode) encoding.TextUnmarshaler { return m })
+	modeRoundTrip(t, AverageModes(), ParseAverageMode, func(m *AverageMode) encoding.TextUnmarshaler { return m })
+	modeRoundTrip(t, SpaceModes(), ParseSpaceMode, func(m *SpaceMode) encoding.TextUnmarshaler { return m })
+	modeRoundTrip(t, CropModes(), ParseCropMode, func(m *CropMode) encoding.TextUnmarshaler { return m })
+	modeRoundTrip(t, SortModes(), ParseSortMode, func(m *SortMode) encoding.TextUnmarshaler { return m })
+}
+
+func TestSortModeFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sort := SortCount
+	fs.TextVar(&sort, "sort", SortCount, "sort mode")
+	if err := fs.Parse([]string{"-sort", "lightness"}); err != nil || sort != SortLightness {
+		t.Errorf("Expected -sort lightness to give %v, got %v, %v", SortLightness, sort, err)
+	}
+}
+
+func TestCapabilitiesFromEnumerations(t *testing.T) {
+	c := Capabilities()
+	if !reflect.DeepEqual(c.Algorithms, Algorithms()) || !reflect.DeepEqual(c.ColorSpaces, Metrics()) {
+		t.Errorf("Expected the capabilities to list Algorithms() and Metrics(), got %v and %v", c.Algorithms, c.ColorSpaces)
+	}
+	for _, name := range Metrics() {
+		if _, err := ParseSpaceMode(name); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, m := range SeedModes() {
+		if !strings.Contains(strings.Join(Algorithms(), " "), m.String()) {
+			t.Errorf("Expected the seed mode %v in the algorithms %v", m, Algorithms())
+		}
+	}
+}
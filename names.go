@@ -0,0 +1,202 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+func init() {
+	registerBackend("color-names")
+}
+
+// namedColor is a named color of the CSS3 (X11) color keywords
+type namedColor struct {
+	name  string
+	color ColorRGB
+}
+
+// cssColors are the 147 CSS3 color keywords, derived from the X11 color names, sorted by name
+var cssColors = []namedColor{
+	{"aliceblue", ColorRGB{R: 0xF0, G: 0xF8, B: 0xFF}},
+	{"antiquewhite", ColorRGB{R: 0xFA, G: 0xEB, B: 0xD7}},
+	{"aqua", ColorRGB{R: 0x00, G: 0xFF, B: 0xFF}},
+	{"aquamarine", ColorRGB{R: 0x7F, G: 0xFF, B: 0xD4}},
+	{"azure", ColorRGB{R: 0xF0, G: 0xFF, B: 0xFF}},
+	{"beige", ColorRGB{R: 0xF5, G: 0xF5, B: 0xDC}},
+	{"bisque", ColorRGB{R: 0xFF, G: 0xE4, B: 0xC4}},
+	{"black", ColorRGB{R: 0x00, G: 0x00, B: 0x00}},
+	{"blanchedalmond", ColorRGB{R: 0xFF, G: 0xEB, B: 0xCD}},
+	{"blue", ColorRGB{R: 0x00, G: 0x00, B: 0xFF}},
+	{"blueviolet", ColorRGB{R: 0x8A, G: 0x2B, B: 0xE2}},
+	{"brown", ColorRGB{R: 0xA5, G: 0x2A, B: 0x2A}},
+	{"burlywood", ColorRGB{R: 0xDE, G: 0xB8, B: 0x87}},
+	{"cadetblue", ColorRGB{R: 0x5F, G: 0x9E, B: 0xA0}},
+	{"chartreuse", ColorRGB{R: 0x7F, G: 0xFF, B: 0x00}},
+	{"chocolate", ColorRGB{R: 0xD2, G: 0x69, B: 0x1E}},
+	{"coral", ColorRGB{R: 0xFF, G: 0x7F, B: 0x50}},
+	{"cornflowerblue", ColorRGB{R: 0x64, G: 0x95, B: 0xED}},
+	{"cornsilk", ColorRGB{R: 0xFF, G: 0xF8, B: 0xDC}},
+	{"crimson", ColorRGB{R: 0xDC, G: 0x14, B: 0x3C}},
+	{"cyan", ColorRGB{R: 0x00, G: 0xFF, B: 0xFF}},
+	{"darkblue", ColorRGB{R: 0x00, G: 0x00, B: 0x8B}},
+	{"darkcyan", ColorRGB{R: 0x00, G: 0x8B, B: 0x8B}},
+	{"darkgoldenrod", ColorRGB{R: 0xB8, G: 0x86, B: 0x0B}},
+	{"darkgray", ColorRGB{R: 0xA9, G: 0xA9, B: 0xA9}},
+	{"darkgreen", ColorRGB{R: 0x00, G: 0x64, B: 0x00}},
+	{"darkgrey", ColorRGB{R: 0xA9, G: 0xA9, B: 0xA9}},
+	{"darkkhaki", ColorRGB{R: 0xBD, G: 0xB7, B: 0x6B}},
+	{"darkmagenta", ColorRGB{R: 0x8B, G: 0x00, B: 0x8B}},
+	{"darkolivegreen", ColorRGB{R: 0x55, G: 0x6B, B: 0x2F}},
+	{"darkorange", ColorRGB{R: 0xFF, G: 0x8C, B: 0x00}},
+	{"darkorchid", ColorRGB{R: 0x99, G: 0x32, B: 0xCC}},
+	{"darkred", ColorRGB{R: 0x8B, G: 0x00, B: 0x00}},
+	{"darksalmon", ColorRGB{R: 0xE9, G: 0x96, B: 0x7A}},
+	{"darkseagreen", ColorRGB{R: 0x8F, G: 0xBC, B: 0x8F}},
+	{"darkslateblue", ColorRGB{R: 0x48, G: 0x3D, B: 0x8B}},
+	{"darkslategray", ColorRGB{R: 0x2F, G: 0x4F, B: 0x4F}},
+	{"darkslategrey", ColorRGB{R: 0x2F, G: 0x4F, B: 0x4F}},
+	{"darkturquoise", ColorRGB{R: 0x00, G: 0xCE, B: 0xD1}},
+	{"darkviolet", ColorRGB{R: 0x94, G: 0x00, B: 0xD3}},
+	{"deeppink", ColorRGB{R: 0xFF, G: 0x14, B: 0x93}},
+	{"deepskyblue", ColorRGB{R: 0x00, G: 0xBF, B: 0xFF}},
+	{"dimgray", ColorRGB{R: 0x69, G: 0x69, B: 0x69}},
+	{"dimgrey", ColorRGB{R: 0x69, G: 0x69, B: 0x69}},
+	{"dodgerblue", ColorRGB{R: 0x1E, G: 0x90, B: 0xFF}},
+	{"firebrick", ColorRGB{R: 0xB2, G: 0x22, B: 0x22}},
+	{"floralwhite", ColorRGB{R: 0xFF, G: 0xFA, B: 0xF0}},
+	{"forestgreen", ColorRGB{R: 0x22, G: 0x8B, B: 0x22}},
+	{"fuchsia", ColorRGB{R: 0xFF, G: 0x00, B: 0xFF}},
+	{"gainsboro", ColorRGB{R: 0xDC, G: 0xDC, B: 0xDC}},
+	{"ghostwhite", ColorRGB{R: 0xF8, G: 0xF8, B: 0xFF}},
+	{"gold", ColorRGB{R: 0xFF, G: 0xD7, B: 0x00}},
+	{"goldenrod", ColorRGB{R: 0xDA, G: 0xA5, B: 0x20}},
+	{"gray", ColorRGB{R: 0x80, G: 0x80, B: 0x80}},
+	{"green", ColorRGB{R: 0x00, G: 0x80, B: 0x00}},
+	{"greenyellow", ColorRGB{R: 0xAD, G: 0xFF, B: 0x2F}},
+	{"grey", ColorRGB{R: 0x80, G: 0x80, B: 0x80}},
+	{"honeydew", ColorRGB{R: 0xF0, G: 0xFF, B: 0xF0}},
+	{"hotpink", ColorRGB{R: 0xFF, G: 0x69, B: 0xB4}},
+	{"indianred", ColorRGB{R: 0xCD, G: 0x5C, B: 0x5C}},
+	{"indigo", ColorRGB{R: 0x4B, G: 0x00, B: 0x82}},
+	{"ivory", ColorRGB{R: 0xFF, G: 0xFF, B: 0xF0}},
+	{"khaki", ColorRGB{R: 0xF0, G: 0xE6, B: 0x8C}},
+	{"lavender", ColorRGB{R: 0xE6, G: 0xE6, B: 0xFA}},
+	{"lavenderblush", ColorRGB{R: 0xFF, G: 0xF0, B: 0xF5}},
+	{"lawngreen", ColorRGB{R: 0x7C, G: 0xFC, B: 0x00}},
+	{"lemonchiffon", ColorRGB{R: 0xFF, G: 0xFA, B: 0xCD}},
+	{"lightblue", ColorRGB{R: 0xAD, G: 0xD8, B: 0xE6}},
+	{"lightcoral", ColorRGB{R: 0xF0, G: 0x80, B: 0x80}},
+	{"lightcyan", ColorRGB{R: 0xE0, G: 0xFF, B: 0xFF}},
+	{"lightgoldenrodyellow", ColorRGB{R: 0xFA, G: 0xFA, B: 0xD2}},
+	{"lightgray", ColorRGB{R: 0xD3, G: 0xD3, B: 0xD3}},
+	{"lightgreen", ColorRGB{R: 0x90, G: 0xEE, B: 0x90}},
+	{"lightgrey", ColorRGB{R: 0xD3, G: 0xD3, B: 0xD3}},
+	{"lightpink", ColorRGB{R: 0xFF, G: 0xB6, B: 0xC1}},
+	{"lightsalmon", ColorRGB{R: 0xFF, G: 0xA0, B: 0x7A}},
+	{"lightseagreen", ColorRGB{R: 0x20, G: 0xB2, B: 0xAA}},
+	{"lightskyblue", ColorRGB{R: 0x87, G: 0xCE, B: 0xFA}},
+	{"lightslategray", ColorRGB{R: 0x77, G: 0x88, B: 0x99}},
+	{"lightslategrey", ColorRGB{R: 0x77, G: 0x88, B: 0x99}},
+	{"lightsteelblue", ColorRGB{R: 0xB0, G: 0xC4, B: 0xDE}},
+	{"lightyellow", ColorRGB{R: 0xFF, G: 0xFF, B: 0xE0}},
+	{"lime", ColorRGB{R: 0x00, G: 0xFF, B: 0x00}},
+	{"limegreen", ColorRGB{R: 0x32, G: 0xCD, B: 0x32}},
+	{"linen", ColorRGB{R: 0xFA, G: 0xF0, B: 0xE6}},
+	{"magenta", ColorRGB{R: 0xFF, G: 0x00, B: 0xFF}},
+	{"maroon", ColorRGB{R: 0x80, G: 0x00, B: 0x00}},
+	{"mediumaquamarine", ColorRGB{R: 0x66, G: 0xCD, B: 0xAA}},
+	{"mediumblue", ColorRGB{R: 0x00, G: 0x00, B: 0xCD}},
+	{"mediumorchid", ColorRGB{R: 0xBA, G: 0x55, B: 0xD3}},
+	{"mediumpurple", ColorRGB{R: 0x93, G: 0x70, B: 0xDB}},
+	{"mediumseagreen", ColorRGB{R: 0x3C, G: 0xB3, B: 0x71}},
+	{"mediumslateblue", ColorRGB{R: 0x7B, G: 0x68, B: 0xEE}},
+	{"mediumspringgreen", ColorRGB{R: 0x00, G: 0xFA, B: 0x9A}},
+	{"mediumturquoise", ColorRGB{R: 0x48, G: 0xD1, B: 0xCC}},
+	{"mediumvioletred", ColorRGB{R: 0xC7, G: 0x15, B: 0x85}},
+	{"midnightblue", ColorRGB{R: 0x19, G: 0x19, B: 0x70}},
+	{"mintcream", ColorRGB{R: 0xF5, G: 0xFF, B: 0xFA}},
+	{"mistyrose", ColorRGB{R: 0xFF, G: 0xE4, B: 0xE1}},
+	{"moccasin", ColorRGB{R: 0xFF, G: 0xE4, B: 0xB5}},
+	{"navajowhite", ColorRGB{R: 0xFF, G: 0xDE, B: 0xAD}},
+	{"navy", ColorRGB{R: 0x00, G: 0x00, B: 0x80}},
+	{"oldlace", ColorRGB{R: 0xFD, G: 0xF5, B: 0xE6}},
+	{"olive", ColorRGB{R: 0x80, G: 0x80, B: 0x00}},
+	{"olivedrab", ColorRGB{R: 0x6B, G: 0x8E, B: 0x23}},
+	{"orange", ColorRGB{R: 0xFF, G: 0xA5, B: 0x00}},
+	{"orangered", ColorRGB{R: 0xFF, G: 0x45, B: 0x00}},
+	{"orchid", ColorRGB{R: 0xDA, G: 0x70, B: 0xD6}},
+	{"palegoldenrod", ColorRGB{R: 0xEE, G: 0xE8, B: 0xAA}},
+	{"palegreen", ColorRGB{R: 0x98, G: 0xFB, B: 0x98}},
+	{"paleturquoise", ColorRGB{R: 0xAF, G: 0xEE, B: 0xEE}},
+	{"palevioletred", ColorRGB{R: 0xDB, G: 0x70, B: 0x93}},
+	{"papayawhip", ColorRGB{R: 0xFF, G: 0xEF, B: 0xD5}},
+	{"peachpuff", ColorRGB{R: 0xFF, G: 0xDA, B: 0xB9}},
+	{"peru", ColorRGB{R: 0xCD, G: 0x85, B: 0x3F}},
+	{"pink", ColorRGB{R: 0xFF, G: 0xC0, B: 0xCB}},
+	{"plum", ColorRGB{R: 0xDD, G: 0xA0, B: 0xDD}},
+	{"powderblue", ColorRGB{R: 0xB0, G: 0xE0, B: 0xE6}},
+	{"purple", ColorRGB{R: 0x80, G: 0x00, B: 0x80}},
+	{"red", ColorRGB{R: 0xFF, G: 0x00, B: 0x00}},
+	{"rosybrown", ColorRGB{R: 0xBC, G: 0x8F, B: 0x8F}},
+	{"royalblue", ColorRGB{R: 0x41, G: 0x69, B: 0xE1}},
+	{"saddlebrown", ColorRGB{R: 0x8B, G: 0x45, B: 0x13}},
+	{"salmon", ColorRGB{R: 0xFA, G: 0x80, B: 0x72}},
+	{"sandybrown", ColorRGB{R: 0xF4, G: 0xA4, B: 0x60}},
+	{"seagreen", ColorRGB{R: 0x2E, G: 0x8B, B: 0x57}},
+	{"seashell", ColorRGB{R: 0xFF, G: 0xF5, B: 0xEE}},
+	{"sienna", ColorRGB{R: 0xA0, G: 0x52, B: 0x2D}},
+	{"silver", ColorRGB{R: 0xC0, G: 0xC0, B: 0xC0}},
+	{"skyblue", ColorRGB{R: 0x87, G: 0xCE, B: 0xEB}},
+	{"slateblue", ColorRGB{R: 0x6A, G: 0x5A, B: 0xCD}},
+	{"slategray", ColorRGB{R: 0x70, G: 0x80, B: 0x90}},
+	{"slategrey", ColorRGB{R: 0x70, G: 0x80, B: 0x90}},
+	{"snow", ColorRGB{R: 0xFF, G: 0xFA, B: 0xFA}},
+	{"springgreen", ColorRGB{R: 0x00, G: 0xFF, B: 0x7F}},
+	{"steelblue", ColorRGB{R: 0x46, G: 0x82, B: 0xB4}},
+	{"tan", ColorRGB{R: 0xD2, G: 0xB4, B: 0x8C}},
+	{"teal", ColorRGB{R: 0x00, G: 0x80, B: 0x80}},
+	{"thistle", ColorRGB{R: 0xD8, G: 0xBF, B: 0xD8}},
+	{"tomato", ColorRGB{R: 0xFF, G: 0x63, B: 0x47}},
+	{"turquoise", ColorRGB{R: 0x40, G: 0xE0, B: 0xD0}},
+	{"violet", ColorRGB{R: 0xEE, G: 0x82, B: 0xEE}},
+	{"wheat", ColorRGB{R: 0xF5, G: 0xDE, B: 0xB3}},
+	{"white", ColorRGB{R: 0xFF, G: 0xFF, B: 0xFF}},
+	{"whitesmoke", ColorRGB{R: 0xF5, G: 0xF5, B: 0xF5}},
+	{"yellow", ColorRGB{R: 0xFF, G: 0xFF, B: 0x00}},
+	{"yellowgreen", ColorRGB{R: 0x9A, G: 0xCD, B: 0x32}},
+}
+
+// NearestColorName returns the CSS3 (X11) color keyword closest to the color, e.g. "darkolivegreen", and its
+// CIEDE2000 delta E (0-100 scale) to the color. Of the aliases with the same color (gray and grey, aqua and cyan,
+// fuchsia and magenta) the first by name is returned.
+func NearestColorName(c ColorItem) (string, float64) {
+	best, bestDeltaE := 0, -1.0
+	for i, named := range cssColors {
+		if d := distanceCIEDE2000(c, ColorItem{Color: named.color}) * 100; bestDeltaE < 0 || d < bestDeltaE {
+			best, bestDeltaE = i, d
+		}
+	}
+	return cssColors[best].name, bestDeltaE
+}
+
+// nameColors sets the Name and NameDeltaE of the colors
+func nameColors(colors []ColorItem) {
+	for i := range colors {
+		colors[i].Name, colors[i].NameDeltaE = NearestColorName(colors[i])
+	}
+}
+
+// nameResult names the colors of the result and its Other
+func nameResult(res *Result) {
+	nameColors(res.Colors)
+	if res.Other != nil {
+		res.Other.Name, res.Other.NameDeltaE = NearestColorName(*res.Other)
+	}
+}
+
+// notInBuild returns the first option needing a component left out of this build, "" if there is none (see
+// names_tiny.go)
+func (o Options) notInBuild() string {
+	return ""
+}
@@ -0,0 +1,18 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build tiny
+
+package prominentcolor
+
+// nameResult is never called in the tiny build, validate rejects Options.ColorNames
+func nameResult(res *Result) {}
+
+// notInBuild returns the first option needing a component left out of the tiny build, "" if there is none
+func (o Options) notInBuild() string {
+	if o.ColorNames {
+		return "ColorNames"
+	}
+	return ""
+}
@@ -0,0 +1,584 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+)
+
+// Options contains the settings used when extracting the prominent colors
+type Options struct {
+	// K is the number of centroids (colors) to find, DefaultK if less than 1
+	K int
+
+	// Seed, Average, Space and Crop select the algorithms, the zero values are the defaults
+	Seed    SeedMode
+	Average AverageMode
+	Space   SpaceMode
+	Crop    CropMode
+
+	// Arguments consists of the legacy bits, see constants Argument*. Prefer the typed modes above,
+	// Arguments is only needed for bits without a mode (e.g. ArgumentDebugImage), see also WithArguments
+	Arguments int
+
+	// Size is the width the image is re-sized to before processing, OriginalSize (0) skips resizing
+	Size uint
+
+	// Undither blends the two colors of dither patterns (e.g. in screenshots scaled down to a few colors) into the
+	// color they are perceived as, before cropping and resizing, see DitheredProportion
+	Undither bool
+
+	// Resizer scales the image to Size, ResizerLanczos if nil
+	Resizer Resizer
+
+	// Samples if set replaces the resizing: about this many original pixels are picked with ResizerSample, keeping
+	// the colors found in the image while bounding the runtime. Size and Resizer are then ignored.
+	Samples int
+
+	// Masks are the background masks to apply
+	Masks []ColorBackgroundMask
+
+	// PixelMasks exclude the pixels of the original image for which any of them returns true, before cropping and
+	// resizing. Excluded pixels count as transparent pixels in the Stats.
+	PixelMasks []PixelMask
+
+	// OrientationInvariant is the strict mode guaranteeing that rotating or mirroring the image
+	// does not change the result beyond a small delta E, see ArgumentOrientationInvariant
+	OrientationInvariant bool
+
+	// Portable guarantees byte-identical palettes on all architectures, e.g. when palettes are used as cache keys,
+	// see ArgumentPortable. Options relying on floating point math are then rejected.
+	Portable bool
+
+	// FixedPointLAB measures LAB and LCh distances with fixed point math, see ArgumentFixedPointLAB.
+	// With Portable it allows clustering in LAB.
+	FixedPointLAB bool
+
+	// Region if not empty restricts the processing to this rectangle of the original image (e.g. a face or product
+	// bounding box), the center is then not cropped
+	Region image.Rectangle
+
+	// SafeAreas if set weights each pixel by the number of these centered crops it survives, e.g. SafeAreasSocial,
+	// pixels outside all of them are not used. The center is then not cropped.
+	SafeAreas []SafeArea
+
+	// BackgroundTolerance enables removing the background by flood filling from the border pixels, removing the
+	// connected pixels within this CIEDE2000 delta E (e.g. DefaultBackgroundTolerance) of the border color.
+	// When set the Masks are not used.
+	BackgroundTolerance float64
+
+	// EqualizeLuminance weights the colors by how rare their luma is, so the highlights of mostly dark images (e.g.
+	// concert photos or night shots) are found instead of several shades of black. It implies ArgumentCountWeighted,
+	// the Cnt of the colors then holds the weighted count.
+	EqualizeLuminance bool
+
+	// AutoLowLight adjusts the options for low-light images (see IsLowLight): the masks removing black are left out
+	// and EqualizeLuminance is set. Result.LowLight and the MetadataAdjusted key of Result.Metadata report it.
+	AutoLowLight bool
+
+	// LabBinSize enables clustering a histogram instead of the individual colors: colors are grouped into LAB bins of
+	// this size (L 0-100 scale, e.g. DefaultLabBinSize) and the bins are clustered weighted by their pixel count.
+	// This is much faster on large images and photos at a small loss of accuracy (see README), and implies
+	// ArgumentCountWeighted. DefaultLabBinSize is the recommended setting for server workloads.
+	LabBinSize float64
+
+	// ChromaKey if set removes a green/blue screen background by hue, e.g. &ChromaKeyGreen.
+	// When set the Masks are not used.
+	ChromaKey *ChromaKey
+
+	// BorderBackground if set removes the background color detected at the border of the image,
+	// e.g. &DefaultBorderBackground. When set the Masks are not used.
+	BorderBackground *BorderBackground
+
+	// ExactFlatArt returns the exact colors of flat art (illustrations, logos, icons) instead of clustering them:
+	// if FlatArtMaxColors colors cover FlatArtCoverage of the pixels, the K most common colors are returned with
+	// their pixel counts and Result.FlatArt is set. Resizing blends the edges of the colors, use it with
+	// OriginalSize to count the pixels of the image.
+	ExactFlatArt bool
+
+	// MaskReport enables Result.MaskStats, this clusters the image once more for each mask
+	MaskReport bool
+
+	// DebugImage if set is called with the processed (cropped, resized and masked) image, removed pixels in pink,
+	// an error fails the extraction. See DebugToWriter, and ArgumentDebugImage for the legacy file output
+	DebugImage func(img image.Image) error
+
+	// AlphaThreshold is the alpha value (0-0xffff) below which pixels are skipped, 0 means DefaultAlphaThreshold.
+	// Set it to 1 to only skip fully transparent pixels. Images without alpha channel are not affected.
+	AlphaThreshold uint16
+
+	// Profile is the color space of the image, if set pixels are converted to sRGB before processing
+	Profile *ICCProfile
+
+	// WhiteBalance if set adapts the colors of the result from its light to D65 (daylight) weighted by its confidence,
+	// so the palettes of an object taken in tungsten and daylight agree more closely
+	WhiteBalance *WhiteBalance
+
+	// AdaptExifWhiteBalance sets WhiteBalance from the EXIF data (see ExifWhiteBalance) if it is not set, for the
+	// images decoded by KmeansFromBytes (and the functions decoding through it, e.g. KmeansFromFile and KmeansBatch).
+	// Images the camera balanced automatically (WhiteBalance.Auto) are already corrected and are not adapted, images
+	// with a manual or unknown white balance are adapted from their EXIF light source.
+	AdaptExifWhiteBalance bool
+
+	// MergeDeltaE if set merges the colors of the result closer than this CIEDE2000 delta E (0-100 scale),
+	// see MergeSimilarColors. Result.Colors then has fewer than K colors.
+	MergeDeltaE float64
+
+	// MinPercentage if set drops the colors of the result with a Percentage below it (0-100), e.g. 1 for tiny noise
+	// clusters, see DropSmallColors. The most dominant color is always kept.
+	MinPercentage float64
+
+	// RedistributeSmall adds the counts and shares of the colors dropped by MinPercentage to the closest remaining
+	// color, so the percentages still add up to 100
+	RedistributeSmall bool
+
+	// Sort is the order of Result.Colors, by count if not set
+	Sort SortMode
+
+	// TopN if set keeps only the TopN most dominant colors in Result.Colors and combines the others into
+	// Result.Other, e.g. for APIs only displaying a few swatches of a large K, see TopColors
+	TopN int
+
+	// ColorNames sets the Name and NameDeltaE of the colors of the result, see NearestColorName
+	ColorNames bool
+
+	// LabelMap sets Result.LabelMap, the color of each pixel of the processed image. K is at most 255 then.
+	LabelMap bool
+
+	// Details collects where and how close to its color the pixels of each color are, for Result.Explain,
+	// ClusterSpreads, ClusterExtents and the variance of Features. This assigns the pixels once more.
+	Details bool
+
+	// Metadata is copied to Result.Metadata, e.g. the source URL, asset ID and license of the image, so the
+	// serialized results (see the export and queue packages) can be joined with other data
+	Metadata map[string]string
+}
+
+// Result contains the outcome of an extraction
+type Result struct {
+	// Colors are the centroids, sorted according to dominance (most frequent first) unless Options.Sort is set
+	Colors []ColorItem
+
+	// SkippedPixels is the number of processed pixels skipped for being below the alpha threshold
+	// (after cropping and resizing, not counting the background removed by the masks)
+	SkippedPixels int
+
+	// Stats are the number of pixels removed by each step of the processing
+	Stats Stats
+
+	// FlatArt is set if Options.ExactFlatArt found flat art, the Colors are then the exact colors of the pixels
+	FlatArt bool
+
+	// UniqueColors is the number of unique colors of the processed image, if Options.ExactFlatArt is set
+	UniqueColors int
+
+	// LowLight is set if Options.AutoLowLight adjusted the options for a low-light image
+	LowLight bool
+
+	// Convergence is how the clustering went. It is zero for the results of KmeansFrames and Stream, and for flat
+	// art the Inertia is of the unique colors to the Colors returned.
+	Convergence Convergence
+
+	// WhiteBalance is the white balance the colors were adapted from, nil if they were not adapted
+	WhiteBalance *WhiteBalance
+
+	// Other combines the colors removed by Options.TopN (see TopColors), nil if none were removed
+	Other *ColorItem
+
+	// OtherColors is the number of colors combined into Other
+	OtherColors int
+
+	// DroppedColors is the number of colors dropped by Options.MinPercentage
+	DroppedColors int
+
+	// Metadata is Options.Metadata
+	Metadata map[string]string
+
+	// Fingerprint is Options.Fingerprint of the options used, to tell if the result is stale
+	Fingerprint string
+
+	// LabelMap is the processed image (cropped, resized and masked) with the index in Colors of the closest color
+	// of each pixel, len(Colors) for Other and the last (transparent) palette index for skipped and masked pixels,
+	// if Options.LabelMap is set. The palette holds the colors, e.g. to render a segmentation overlay.
+	LabelMap *image.Paletted
+
+	// MaskStats describes what each applied mask (or other background removal) removed, if Options.MaskReport is set
+	MaskStats []MaskStat
+
+	// details (if Options.Details is set) and prep are used by Explain
+	details []clusterDetail
+	prep    preparation
+}
+
+// Stats contains the number of pixels removed by each step of the processing, e.g. to detect that the masks
+// removed the whole subject
+type Stats struct {
+	// TotalPixels is the size of the input image
+	TotalPixels int
+
+	// CroppedPixels are the pixels of the input image removed by cropping
+	CroppedPixels int
+
+	// ProcessedPixels is the size of the image after cropping and resizing, the following counts are of these pixels
+	ProcessedPixels int
+
+	// TransparentPixels are the pixels below the alpha threshold (same as Result.SkippedPixels)
+	TransparentPixels int
+
+	// MaskedPixels are the pixels removed by each mask, e.g. "white-background", "flood fill" or "chroma key"
+	MaskedPixels map[string]int
+
+	// ClusteredPixels are the remaining pixels that were clustered
+	ClusteredPixels int
+
+	// UnditheredPixels are the pixels of the input image blended with their neighbours by Options.Undither
+	UnditheredPixels int
+}
+
+// DefaultOptions returns the options used by Kmeans
+func DefaultOptions() Options {
+	return Options{
+		K:     DefaultK,
+		Size:  DefaultSize,
+		Masks: GetDefaultMasks(),
+	}
+}
+
+// KmeansWithOptions finds the prominent colors of the image using the settings in opts
+func KmeansWithOptions(orgimg image.Image, opts Options) (Result, error) {
+	if err := opts.validate(orgimg); err != nil {
+		return Result{}, err
+	}
+	// the fingerprint is of the options as given, not as adjusted for low-light images
+	fingerprint := opts.Fingerprint()
+	lowLight := opts.AutoLowLight && IsLowLight(orgimg)
+	if lowLight {
+		opts = opts.lowLight()
+	}
+	img, prep := opts.prepare(orgimg)
+	if err := opts.debug(img); err != nil {
+		return Result{}, err
+	}
+
+	var centroids []ColorItem
+	var flatArt bool
+	var convergence Convergence
+	var err error
+	if opts.ExactFlatArt {
+		centroids, flatArt = flatArtColors(img)
+	}
+	unique := len(centroids)
+	if flatArt {
+		// the shares are of all pixels, not only of the K colors returned
+		setShares(centroids, prep.stats.ClusteredPixels, prep.stats.maskedPixels())
+		all := centroids
+		centroids = centroids[:min(len(centroids), opts.k())]
+		convergence = Convergence{Converged: true, Inertia: inertia(all, centroids, opts.arguments())}
+	} else {
+		colors := opts.colors(img)
+		if centroids, convergence, err = clusterColors(opts.k(), colors, opts.arguments()); err != nil {
+			return Result{}, err
+		}
+		setShares(centroids, prep.stats.ClusteredPixels, prep.stats.maskedPixels())
+	}
+	res := Result{
+		Colors:        centroids,
+		SkippedPixels: prep.skipped,
+		Stats:         prep.stats,
+		FlatArt:       flatArt,
+		UniqueColors:  unique,
+		LowLight:      lowLight,
+		Convergence:   convergence,
+		Metadata:      opts.Metadata,
+		Fingerprint:   fingerprint,
+		prep:          prep,
+	}
+	if opts.Details {
+		res.details = describeClusters(img, centroids, opts.arguments(), prep.source)
+	}
+	opts.finish(&res)
+	if opts.LabelMap {
+		res.LabelMap = labelMap(img, res, opts.arguments())
+	}
+	if opts.MaskReport {
+		if res.MaskStats, err = maskStats(img, prep.removals, opts); err != nil {
+			return Result{}, err
+		}
+	}
+	return res, nil
+}
+
+// maskedPixels returns the pixels removed by all masks
+func (s Stats) maskedPixels() int {
+	masked := 0
+	for _, n := range s.MaskedPixels {
+		masked += n
+	}
+	return masked
+}
+
+// setShares sets the Percentage and ShareOfTotal of the colors from the number of clustered and masked pixels
+func setShares(colors []ColorItem, clustered, masked int) {
+	opaque := 0.0
+	if clustered+masked > 0 {
+		opaque = float64(clustered) / float64(clustered+masked)
+	}
+	for i, p := range percentages(colors) {
+		colors[i].Percentage = p
+		colors[i].ShareOfTotal = p / 100 * opaque
+	}
+}
+
+// KmeansWithMask is KmeansWithOptions only using the pixels where the mask (e.g. an *image.Gray or *image.Alpha of
+// the same size as the image) is non-zero, e.g. a segmentation mask. Excluded pixels are made transparent, so they are
+// counted in Result.SkippedPixels with the transparent ones; like all counts of the result it counts the processed
+// (cropped and resized) pixels, not those of the mask.
+func KmeansWithMask(orgimg image.Image, mask image.Image, opts Options) (Result, error) {
+	img, err := applyMask(orgimg, mask)
+	if err != nil {
+		return Result{}, err
+	}
+	return KmeansWithOptions(img, opts)
+}
+
+// prepare converts the image to sRGB (if needed) and then crops, resizes and removes the background
+func (o Options) prepare(orgimg image.Image) (image.Image, preparation) {
+	if o.Profile != nil {
+		orgimg = ConvertToSRGB(orgimg, o.Profile)
+	}
+	orgimg = convertCMYK(orgimg)
+	arguments := o.arguments()
+	total := orgimg.Bounds().Dx() * orgimg.Bounds().Dy()
+	if !o.Region.Empty() {
+		orgimg = cropRegion(orgimg, o.Region)
+		arguments |= ArgumentNoCropping
+	}
+	source := cropBounds(arguments, orgimg.Bounds())
+	if p, ok := orgimg.(*image.Paletted); ok && o.histogramOnly(arguments, p) {
+		img, prep := o.preparePaletted(arguments, p)
+		prep.stats.CroppedPixels += total - prep.stats.TotalPixels
+		prep.stats.TotalPixels = total
+		prep.source = source
+		return img, prep
+	}
+	size := o.Size
+	if o.Samples > 0 {
+		// the sampler picks the size from the number of samples, any size but OriginalSize makes it run
+		size = 1
+	}
+	if !needsResize(cropBounds(arguments, orgimg.Bounds()), size) && o.histogramOnly(arguments, orgimg) {
+		if img, prep, ok := o.prepareRuns(arguments, orgimg); ok {
+			prep.stats.CroppedPixels += total - prep.stats.TotalPixels
+			prep.stats.TotalPixels = total
+			prep.source = source
+			return img, prep
+		}
+	}
+	undithered := 0
+	if o.Undither {
+		orgimg, undithered = undither(orgimg)
+	}
+	if len(o.PixelMasks) > 0 {
+		orgimg = applyPixelMasks(orgimg, o.PixelMasks)
+	}
+	// the flood fill, chroma key and border background replace the masks
+	masks := o.Masks
+	if o.BackgroundTolerance > 0 || o.ChromaKey != nil || o.BorderBackground != nil {
+		masks = nil
+	}
+
+	img, prep := prepareImg(arguments, masks, size, o.resizer(), o.alphaThreshold(), orgimg)
+	prep.stats.CroppedPixels += total - prep.stats.TotalPixels
+	prep.stats.TotalPixels = total
+	prep.stats.UnditheredPixels = undithered
+	prep.stats.MaskedPixels = make(map[string]int)
+	// the pixels are counted while they are removed, the ones below the alpha threshold were made transparent and
+	// all others opaque
+	opaque := prep.stats.ProcessedPixels - prep.skipped - prep.masked
+	if prep.mask != nil {
+		prep.stats.MaskedPixels[maskName(*prep.mask)] = prep.masked
+		if o.MaskReport {
+			prep.removals = append(prep.removals, findRemoved(maskName(*prep.mask), prep.beforeMasks, img))
+		}
+	}
+
+	// step runs one of the background removals, recording (if requested) what it removed
+	step := func(name string, remove func() int) {
+		var before image.Image
+		if o.MaskReport {
+			before = createDrawImage(img)
+		}
+		removed := remove()
+		prep.stats.MaskedPixels[name] = removed
+		opaque -= removed
+		if o.MaskReport {
+			prep.removals = append(prep.removals, findRemoved(name, before, img))
+		}
+	}
+	if o.BackgroundTolerance > 0 {
+		step("flood fill", func() int {
+			removed := floodFillBackground(img, o.BackgroundTolerance)
+			prep.floodFilled = removed > 0
+			return removed
+		})
+	}
+	if o.BorderBackground != nil {
+		step("border background", func() int {
+			prep.borderBackground = o.BorderBackground.apply(orgimg, img)
+			return prep.borderBackground
+		})
+	}
+	if o.ChromaKey != nil {
+		step("chroma key", func() int {
+			prep.chromaKeyed = o.ChromaKey.apply(img)
+			return prep.chromaKeyed
+		})
+	}
+	if IsBitSet(o.arguments(), ArgumentEdgeForeground) {
+		step("edge foreground", func() int {
+			var removed int
+			prep.foreground, removed = edgeForeground(img)
+			return removed
+		})
+	}
+	if IsBitSet(o.arguments(), ArgumentExcludeCodes) {
+		step("codes", func() int {
+			prep.codePixels = excludeCodes(img)
+			return prep.codePixels
+		})
+	}
+	prep.stats.ClusteredPixels = opaque
+	prep.source = source
+	return img, prep
+}
+
+// k returns the number of colors to find, DefaultK if K is less than 1
+func (o Options) k() int {
+	if o.K < 1 {
+		return DefaultK
+	}
+	return o.K
+}
+
+// validate checks that the options can be used for the image
+func (o Options) validate(img image.Image) error {
+	if !o.Region.Empty() && !o.Region.Overlaps(img.Bounds()) {
+		return fmt.Errorf("Failed, region %v is outside of the image %v", o.Region, img.Bounds())
+	}
+	if name := o.notInBuild(); name != "" {
+		return fmt.Errorf("Failed, %s is not supported in the tiny build", name)
+	}
+	if o.LabelMap && o.k() > labelMapMaxColors {
+		return fmt.Errorf("Failed, K is at most %d with LabelMap: %d", labelMapMaxColors, o.k())
+	}
+	if IsBitSet(o.arguments(), ArgumentPortable) {
+		if name := o.notPortable(); name != "" {
+			return fmt.Errorf("Failed, %s is not supported in portable mode", name)
+		}
+	}
+	return nil
+}
+
+// notPortable returns the first option relying on floating point math that may differ between architectures,
+// "" if there is none
+func (o Options) notPortable() string {
+	arguments := o.arguments()
+	switch {
+	case arguments&spaceArguments != 0 && (arguments&spaceArguments != ArgumentLAB || !IsBitSet(arguments, ArgumentFixedPointLAB)):
+		return "a color space other than RGB or fixed point LAB"
+	case IsBitSet(arguments, ArgumentOrientationInvariant):
+		return "OrientationInvariant"
+	case IsBitSet(arguments, ArgumentSaliency):
+		return "ArgumentSaliency"
+	case IsBitSet(arguments, ArgumentEdgeForeground):
+		return "ArgumentEdgeForeground"
+	case IsBitSet(arguments, ArgumentExcludeCodes):
+		return "ArgumentExcludeCodes"
+	case o.LabBinSize > 0:
+		return "LabBinSize"
+	case o.BackgroundTolerance > 0:
+		return "BackgroundTolerance"
+	case o.ChromaKey != nil:
+		return "ChromaKey"
+	case o.BorderBackground != nil:
+		return "BorderBackground"
+	case o.Profile != nil:
+		return "Profile"
+	case o.Resizer != nil && o.Resizer != ResizerBox && o.Resizer != ResizerSample:
+		return "a Resizer other than ResizerBox or ResizerSample"
+	}
+	for _, m := range o.Masks {
+		if m.DeltaE > 0 {
+			return "a mask with DeltaE"
+		}
+	}
+	return ""
+}
+
+// debug passes the visualization of the processed image to DebugImage, if set
+func (o Options) debug(img image.Image) error {
+	if o.DebugImage == nil {
+		return nil
+	}
+	if err := o.DebugImage(debugVisualization(img)); err != nil {
+		return fmt.Errorf("Failed debug image: %v", err)
+	}
+	return nil
+}
+
+// colors counts the colors of the prepared image, weighted by saliency, safe areas and luma and grouped into LAB bins if enabled
+func (o Options) colors(img image.Image) []ColorItem {
+	var weights []func(x, y int) int
+	if IsBitSet(o.arguments(), ArgumentSaliency) {
+		weights = append(weights, saliencyWeight(img))
+	}
+	if len(o.SafeAreas) > 0 {
+		weights = append(weights, safeAreaWeight(img.Bounds(), o.SafeAreas))
+	}
+
+	var allColors []ColorItem
+	if len(weights) == 0 {
+		allColors, _ = extractColorsAsArray(img)
+	} else {
+		allColors = extractWeightedColors(img, func(x, y int) int {
+			w := 1
+			for _, weight := range weights {
+				w *= weight(x, y)
+			}
+			return w
+		})
+	}
+
+	if o.EqualizeLuminance {
+		allColors = equalizeLuminance(allColors)
+	}
+	if o.LabBinSize <= 0 {
+		return allColors
+	}
+	return labHistogram(allColors, o.LabBinSize)
+}
+
+// resizer returns the resizer to use, applying the default
+func (o Options) resizer() Resizer {
+	if o.Samples > 0 {
+		return sampleResizer{samples: o.Samples}
+	}
+	if o.Resizer == nil && IsBitSet(o.arguments(), ArgumentPortable) {
+		return ResizerBox
+	}
+	if o.Resizer == nil {
+		return ResizerLanczos
+	}
+	return o.Resizer
+}
+
+// alphaThreshold returns the alpha threshold to use, applying the default
+func (o Options) alphaThreshold() uint32 {
+	if o.AlphaThreshold == 0 {
+		return DefaultAlphaThreshold
+	}
+	return uint32(o.AlphaThreshold)
+}
@@ -0,0 +1,28 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestZeroOptions(t *testing.T) {
+	img := framedImage(60, color.RGBA{R: 0xff, A: 0xff}, color.RGBA{G: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff})
+	res, err := KmeansWithOptions(img, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the red frame is cropped
+	if len(res.Colors) != 2 {
+		t.Errorf("got %d colors, want the 2 colors of the center", len(res.Colors))
+	}
+	if res.Fingerprint != (Options{K: DefaultK}).Fingerprint() {
+		t.Errorf("K 0 has fingerprint %s, want the one of DefaultK", res.Fingerprint)
+	}
+	if _, err := KmeansWithAll(0, img, ArgumentDefault, DefaultSize, nil); err != nil {
+		t.Errorf("KmeansWithAll with k 0: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Orientation is one of the 8 rotations/mirrorings of an image, numbered as the EXIF orientation tag
+type Orientation int
+
+const (
+	// OrientationNormal leaves the image as is
+	OrientationNormal Orientation = iota + 1
+	// OrientationMirrorHorizontal mirrors left to right
+	OrientationMirrorHorizontal
+	// OrientationRotate180 rotates 180 degrees
+	OrientationRotate180
+	// OrientationMirrorVertical mirrors top to bottom
+	OrientationMirrorVertical
+	// OrientationTranspose mirrors along the top-left to bottom-right diagonal
+	OrientationTranspose
+	// OrientationRotate90 rotates 90 degrees clockwise
+	OrientationRotate90
+	// OrientationTransverse mirrors along the top-right to bottom-left diagonal
+	OrientationTransverse
+	// OrientationRotate270 rotates 270 degrees clockwise
+	OrientationRotate270
+)
+
+// Orient returns a copy of the image with the orientation applied
+func Orient(img image.Image, o Orientation) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	swap := o >= OrientationTranspose
+	dst := image.Rect(0, 0, w, h)
+	if swap {
+		dst = image.Rect(0, 0, h, w)
+	}
+	out := createDrawImage(image.NewRGBA(dst))
+	if is16Bit(img) {
+		out = createDrawImage(image.NewRGBA64(dst))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case OrientationMirrorHorizontal:
+				dx, dy = w-1-x, y
+			case OrientationRotate180:
+				dx, dy = w-1-x, h-1-y
+			case OrientationMirrorVertical:
+				dx, dy = x, h-1-y
+			case OrientationTranspose:
+				dx, dy = y, x
+			case OrientationRotate90:
+				dx, dy = h-1-y, x
+			case OrientationTransverse:
+				dx, dy = h-1-y, w-1-x
+			case OrientationRotate270:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			out.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// CheckOrientationInvariance runs the extraction on all 8 orientations of the image and returns the largest
+// CIEDE2000 difference (0-100 scale) between a color of the normal orientation and the closest color of another one.
+// An error is returned if it is above maxDeltaE. Use it with Options.OrientationInvariant to verify the guarantee.
+func CheckOrientationInvariance(img image.Image, opts Options, maxDeltaE float64) (float64, error) {
+	base, err := KmeansWithOptions(img, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	worst := 0.0
+	for o := OrientationMirrorHorizontal; o <= OrientationRotate270; o++ {
+		res, err := KmeansWithOptions(Orient(img, o), opts)
+		if err != nil {
+			return 0, err
+		}
+		d := maxPaletteDistance(base.Colors, res.Colors)
+		worst = math.Max(worst, d)
+		if d > maxDeltaE {
+			return worst, fmt.Errorf("Failed, orientation %d changes the palette by delta E %.2f (max %.2f)", o, d, maxDeltaE)
+		}
+	}
+	return worst, nil
+}
+
+// maxPaletteDistance is the largest CIEDE2000 difference (0-100 scale) from a color in one palette to the closest color
+// of the other one, checked in both directions
+func maxPaletteDistance(a, b []ColorItem) float64 {
+	worst := 0.0
+	for _, pair := range [][2][]ColorItem{{a, b}, {b, a}} {
+		for _, c := range pair[0] {
+			closest := math.MaxFloat64
+			for _, other := range pair[1] {
+				closest = math.Min(closest, distanceCIEDE2000(c, other)*100)
+			}
+			if len(pair[1]) > 0 {
+				worst = math.Max(worst, closest)
+			}
+		}
+	}
+	return worst
+}
@@ -0,0 +1,214 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func init() {
+	registerBackend("url-fetching")
+}
+
+const (
+	// maxPageBytes is the most read of an HTML page
+	maxPageBytes = 2 << 20
+	// maxImageBytes is the most read of an image
+	maxImageBytes = 50 << 20
+	// DefaultFetchTimeout is the timeout of the client KmeansFromURL uses if none is given
+	DefaultFetchTimeout = 30 * time.Second
+)
+
+// ErrNotPublic is returned when fetching a URL of a host that is not a public internet address (e.g. localhost, a
+// private network or a cloud metadata service) with PublicHTTPClient
+var ErrNotPublic = fmt.Errorf("Failed, not a public address")
+
+// pageImageProperties are the meta properties naming the primary image of a page, in order of preference
+var pageImageProperties = []string{"og:image:secure_url", "og:image:url", "og:image", "twitter:image", "twitter:image:src"}
+
+// PrimaryImageURL finds the primary image of an HTML page, for link previews: the og:image (or twitter:image) meta
+// tag, the image_src link or else the first <img> that is not a 1x1 tracking pixel. Relative URLs are resolved
+// against base.
+func PrimaryImageURL(page io.Reader, base *url.URL) (*url.URL, error) {
+	meta := make(map[string]string)
+	var link, img string
+	tokens := html.NewTokenizer(io.LimitReader(page, maxPageBytes))
+	for {
+		tt := tokens.Next()
+		if tt == html.ErrorToken {
+			if err := tokens.Err(); err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := tokens.TagName()
+		if !hasAttr {
+			continue
+		}
+		attrs := make(map[string]string)
+		for more := true; more; {
+			var key, val []byte
+			key, val, more = tokens.TagAttr()
+			attrs[string(key)] = string(val)
+		}
+		switch string(name) {
+		case "meta":
+			key := strings.ToLower(attrs["property"] + attrs["name"])
+			if _, ok := meta[key]; !ok && attrs["content"] != "" {
+				meta[key] = attrs["content"]
+			}
+		case "link":
+			if link == "" && strings.EqualFold(attrs["rel"], "image_src") {
+				link = attrs["href"]
+			}
+		case "img":
+			if img == "" && attrs["src"] != "" && !strings.HasPrefix(attrs["src"], "data:") &&
+				!(attrs["width"] == "1" && attrs["height"] == "1") {
+				img = attrs["src"]
+			}
+		}
+	}
+
+	candidate := ""
+	for _, p := range pageImageProperties {
+		if meta[p] != "" {
+			candidate = meta[p]
+			break
+		}
+	}
+	if candidate == "" {
+		candidate = link
+	}
+	if candidate == "" {
+		candidate = img
+	}
+	if candidate == "" {
+		return nil, fmt.Errorf("Failed, no image found in the page")
+	}
+
+	u, err := url.Parse(strings.TrimSpace(candidate))
+	if err != nil {
+		return nil, fmt.Errorf("Failed, invalid image URL %q: %v", candidate, err)
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u, nil
+}
+
+// KmeansFromURL fetches the URL and finds the colors of the image, or if it is an HTML page of its primary image
+// (see PrimaryImageURL). Only http and https URLs are fetched. A nil client uses PublicHTTPClient with
+// DefaultFetchTimeout, as the image URL of a page is chosen by its author; a client given is used as is, e.g. to
+// reach internal hosts.
+func KmeansFromURL(client *http.Client, rawURL string, opts Options) (Result, error) {
+	if client == nil {
+		client = PublicHTTPClient(DefaultFetchTimeout)
+	}
+	resp, err := fetch(client, rawURL)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		imageURL, err := PrimaryImageURL(resp.Body, resp.Request.URL)
+		if err != nil {
+			return Result{}, err
+		}
+		resp.Body.Close()
+		if resp, err = fetch(client, imageURL.String()); err != nil {
+			return Result{}, err
+		}
+		defer resp.Body.Close()
+	}
+	return KmeansFromReader(io.LimitReader(resp.Body, maxImageBytes), opts)
+}
+
+// PublicHTTPClient returns a client with the timeout that only connects to public internet addresses, failing with
+// ErrNotPublic for loopback, private, link-local (e.g. cloud metadata services) and other special addresses. The
+// address is checked when connecting, so redirects and DNS names resolving to internal addresses are refused too.
+func PublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrNotPublic, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("Failed, stopped after 10 redirects")
+			}
+			return checkScheme(req.URL)
+		},
+	}
+}
+
+// cgnat is the shared address space of carrier-grade NAT (RFC 6598), not reachable from the internet
+var cgnat = net.IPNet{IP: net.IP{100, 64, 0, 0}, Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP checks if the address is a public internet address
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || cgnat.Contains(ip))
+}
+
+// checkScheme fails for other URLs than http and https, e.g. file: or ftp:
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Failed, only http and https URLs are fetched: %s", u.Redacted())
+	}
+	return nil
+}
+
+// fetch gets the http or https URL, failing on other statuses than 200
+func fetch(client *http.Client, rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed, invalid URL %q: %v", rawURL, err)
+	}
+	if err := checkScheme(u); err != nil {
+		return nil, err
+	}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Failed fetching %s: %s", rawURL, resp.Status)
+	}
+	return resp, nil
+}
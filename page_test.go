@@ -0,0 +1,89 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPrimaryImageURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/1")
+	for _, tc := range []struct {
+		name, page, want string
+	}{
+		{"og:image", `<html><head><meta property="og:image" content="/a.jpg"><meta name="twitter:image" content="/b.jpg"></head></html>`, "https://example.com/a.jpg"},
+		{"twitter:image", `<meta name="twitter:image" content="https://cdn.example.com/b.jpg">`, "https://cdn.example.com/b.jpg"},
+		{"image_src", `<link rel="image_src" href="c.png"><img src="d.png">`, "https://example.com/articles/c.png"},
+		{"img skipping tracking pixels", `<img src="t.gif" width="1" height="1"><img src='e.png' alt="a > b">`, "https://example.com/articles/e.png"},
+		{"entities", `<meta property="og:image" content="/f.jpg?a=1&amp;b=2"/>`, "https://example.com/f.jpg?a=1&b=2"},
+		{"script content", `<script>var s = '<meta property="og:image" content="/x.jpg">';</script><img src="/g.jpg">`, "https://example.com/g.jpg"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := PrimaryImageURL(strings.NewReader(tc.page), base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.String() != tc.want {
+				t.Errorf("got %s, want %s", u, tc.want)
+			}
+		})
+	}
+	if _, err := PrimaryImageURL(strings.NewReader("<p>no images</p>"), base); err == nil {
+		t.Error("got no error for a page without images")
+	}
+}
+
+func TestKmeansFromURLRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		for i := range img.Pix {
+			img.Pix[i] = 0xff
+		}
+		img.Set(4, 4, color.RGBA{R: 0xff, A: 0xff})
+		png.Encode(w, img)
+	}))
+	defer server.Close()
+
+	if _, err := KmeansFromURL(nil, server.URL, DefaultOptions()); !errors.Is(err, ErrNotPublic) {
+		t.Errorf("got %v fetching a loopback address with the default client, want ErrNotPublic", err)
+	}
+	if _, err := KmeansFromURL(server.Client(), server.URL, Options{K: 2, Crop: CropNone}); err != nil {
+		t.Errorf("got %v fetching with a client given", err)
+	}
+	if _, err := KmeansFromURL(server.Client(), "file:///etc/passwd", DefaultOptions()); err == nil {
+		t.Error("got no error fetching a file URL")
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for address, want := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"192.168.0.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+	} {
+		if got := isPublicIP(net.ParseIP(address)); got != want {
+			t.Errorf("isPublicIP(%s) = %t, want %t", address, got, want)
+		}
+	}
+}
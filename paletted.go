@@ -0,0 +1,114 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// histogramOnly checks if the options only need the colors of the pixels, not where they are, so a paletted image
+// can be processed from its palette and index histogram and a flat image from its runs of identical pixels
+func (o Options) histogramOnly(arguments int, img image.Image) bool {
+	if o.Profile != nil || o.Undither || len(o.PixelMasks) > 0 || len(o.SafeAreas) > 0 ||
+		o.BackgroundTolerance > 0 || o.ChromaKey != nil || o.BorderBackground != nil {
+		return false
+	}
+	if arguments&(ArgumentSaliency|ArgumentEdgeForeground|ArgumentExcludeCodes|ArgumentDebugImage) != 0 {
+		return false
+	}
+	// a background mask is only applied if it matches the four corners of the cropped image
+	b := cropBounds(arguments, img.Bounds())
+	for _, bgmask := range o.Masks {
+		if bgmask.matches(img.At(b.Min.X, b.Min.Y)) && bgmask.matches(img.At(b.Min.X, b.Max.Y-1)) &&
+			bgmask.matches(img.At(b.Max.X-1, b.Min.Y)) && bgmask.matches(img.At(b.Max.X-1, b.Max.Y-1)) {
+			return false
+		}
+	}
+	return true
+}
+
+// preparePaletted crops the paletted image like prepareImg, but without copying or resizing it: the pixels are
+// counted per palette index, which is exact and takes about as long as resizing would. The alpha threshold is
+// applied to the palette.
+func (o Options) preparePaletted(arguments int, img *image.Paletted) (image.Image, preparation) {
+	var prep preparation
+	prep.stats.TotalPixels = img.Bounds().Dx() * img.Bounds().Dy()
+	prep.stats.MaskedPixels = make(map[string]int)
+
+	b := cropBounds(arguments, img.Bounds())
+	prep.stats.CroppedPixels = prep.stats.TotalPixels - b.Dx()*b.Dy()
+	prep.stats.ProcessedPixels = b.Dx() * b.Dy()
+
+	threshold := o.alphaThreshold()
+	palette := make(color.Palette, len(img.Palette))
+	for i, c := range img.Palette {
+		n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+		if uint32(n.A) < threshold || n.A == 0 {
+			palette[i] = color.Transparent
+			continue
+		}
+		n.A = 0xffff
+		palette[i] = n
+	}
+	out := (&image.Paletted{Pix: img.Pix, Stride: img.Stride, Rect: img.Rect, Palette: palette}).SubImage(b).(*image.Paletted)
+
+	for i, cnt := range paletteCounts(out) {
+		if _, _, _, a := palette[i].RGBA(); a == 0 {
+			prep.skipped += cnt
+		}
+	}
+	prep.stats.TransparentPixels = prep.skipped
+	prep.stats.ClusteredPixels = prep.stats.ProcessedPixels - prep.skipped
+	prep.beforeMasks = out
+	return out, prep
+}
+
+// paletteCounts returns the number of pixels of each palette index, indices outside the palette are not counted
+func paletteCounts(img *image.Paletted) []int {
+	histogram := make([]int, len(img.Palette))
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := img.PixOffset(b.Min.X, y)
+		for _, idx := range img.Pix[i : i+b.Dx()] {
+			if int(idx) < len(histogram) {
+				histogram[idx]++
+			}
+		}
+	}
+	return histogram
+}
+
+// extractPaletteColors is extractColors for paletted images, counting the palette indices instead of the pixels
+func extractPaletteColors(img *image.Paletted) (map[uint64]ColorItem, int) {
+	histogram, _ := paletteHistogram(img)
+	m := make(map[uint64]ColorItem, len(histogram))
+	numPixels := 0
+	for _, c := range histogram {
+		numPixels += c.Cnt
+		m[c.key()] = c
+	}
+	return m, numPixels
+}
+
+// paletteHistogram counts how many pixels use each (non-transparent) palette color, and if any pixel is transparent
+func paletteHistogram(frame *image.Paletted) ([]ColorItem, bool) {
+	transparent := false
+	var histogram []ColorItem
+	for idx, cnt := range paletteCounts(frame) {
+		if cnt == 0 {
+			continue
+		}
+		item, ignore := createColor(frame.Palette[idx])
+		if ignore {
+			transparent = true
+			continue
+		}
+		item.Cnt = cnt
+		histogram = append(histogram, item)
+	}
+	// different palette entries may hold the same color
+	return mergeColors([][]ColorItem{histogram}), transparent
+}
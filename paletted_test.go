@@ -0,0 +1,65 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// framedImage draws a size x size image with a frame of the frame color, an interior of the background color and a
+// square of the center color in the middle, the frame being removed by the default cropping
+func framedImage(size int, frame, background, center color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: frame}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(size/6, size/6, size-size/6, size-size/6), &image.Uniform{C: background}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(size*5/12, size*5/12, size*7/12, size*7/12), &image.Uniform{C: center}, image.Point{}, draw.Src)
+	return img
+}
+
+// slowPath returns the options with a pixel mask excluding nothing, so the image is copied and masked instead of
+// being processed from its histogram
+func slowPath(opts Options) Options {
+	opts.PixelMasks = []PixelMask{func(x, y int, c color.Color) bool { return false }}
+	return opts
+}
+
+// assertSameColors fails the test if the colors or their counts differ
+func assertSameColors(t *testing.T, got, want []ColorItem) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d colors %v, want %d colors %v", len(got), got, len(want), want)
+	}
+	for i := range got {
+		if got[i].AsString() != want[i].AsString() || got[i].Cnt != want[i].Cnt {
+			t.Errorf("color %d is #%s (%d pixels), want #%s (%d pixels)", i, got[i].AsString(), got[i].Cnt,
+				want[i].AsString(), want[i].Cnt)
+		}
+	}
+}
+
+func TestPalettedMasksCroppedCorners(t *testing.T) {
+	red, blue := color.RGBA{R: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff}
+	rgba := framedImage(60, red, color.White, blue)
+	img := image.NewPaletted(rgba.Bounds(), color.Palette{red, color.White, blue})
+	draw.Draw(img, img.Bounds(), rgba, image.Point{}, draw.Src)
+
+	opts := DefaultOptions()
+	opts.Arguments = ArgumentDeterministic
+	fast, err := KmeansWithOptions(img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, err := KmeansWithOptions(img, slowPath(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameColors(t, fast.Colors, slow.Colors)
+	if len(fast.Colors) != 1 || fast.Colors[0].AsString() != "0000FF" {
+		t.Errorf("got %v, want only the blue center without the white background", fast.Colors)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// forEachPixel calls fn with the (alpha-premultiplied, 16 bit) color of every pixel, the same as img.At(x, y).RGBA().
+// The pixels of the common image types are read directly: JPEGs decode to *image.YCbCr, whose planes are converted
+// without going through color.Color for each pixel, and the prepared images are *image.RGBA. Grayscale pixels are
+// read as a single value and paletted ones through a table of the converted palette.
+func forEachPixel(img image.Image, fn func(x, y int, r, g, b, a uint32)) {
+	bounds := img.Bounds()
+	switch m := img.(type) {
+	case *image.YCbCr:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				ci := m.COffset(x, y)
+				r, g, b, a := color.YCbCr{Y: m.Y[m.YOffset(x, y)], Cb: m.Cb[ci], Cr: m.Cr[ci]}.RGBA()
+				fn(x, y, r, g, b, a)
+			}
+		}
+	case *image.RGBA:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := m.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x, i = x+1, i+4 {
+				s := m.Pix[i : i+4 : i+4]
+				fn(x, y, uint32(s[0])*0x101, uint32(s[1])*0x101, uint32(s[2])*0x101, uint32(s[3])*0x101)
+			}
+		}
+	case *image.Gray:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := m.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x, i = x+1, i+1 {
+				v := uint32(m.Pix[i]) * 0x101
+				fn(x, y, v, v, v, 0xffff)
+			}
+		}
+	case *image.Gray16:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := m.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x, i = x+1, i+2 {
+				v := uint32(m.Pix[i])<<8 | uint32(m.Pix[i+1])
+				fn(x, y, v, v, v, 0xffff)
+			}
+		}
+	case *image.CMYK:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := m.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x, i = x+1, i+4 {
+				s := m.Pix[i : i+4 : i+4]
+				r, g, b, a := color.CMYK{C: s[0], M: s[1], Y: s[2], K: s[3]}.RGBA()
+				fn(x, y, r, g, b, a)
+			}
+		}
+	case *image.Paletted:
+		palette := make([][4]uint32, len(m.Palette))
+		for i, c := range m.Palette {
+			r, g, b, a := c.RGBA()
+			palette[i] = [4]uint32{r, g, b, a}
+		}
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := m.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x, i = x+1, i+1 {
+				// like Paletted.At, an index outside the palette is the first color
+				c := palette[0]
+				if int(m.Pix[i]) < len(palette) {
+					c = palette[m.Pix[i]]
+				}
+				fn(x, y, c[0], c[1], c[2], c[3])
+			}
+		}
+	case *image.NRGBA:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := m.PixOffset(bounds.Min.X, y)
+			for x := bounds.Min.X; x < bounds.Max.X; x, i = x+1, i+4 {
+				s := m.Pix[i : i+4 : i+4]
+				r, g, b, a := color.NRGBA{R: s[0], G: s[1], B: s[2], A: s[3]}.RGBA()
+				fn(x, y, r, g, b, a)
+			}
+		}
+	default:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, g, b, a := img.At(x, y).RGBA()
+				fn(x, y, r, g, b, a)
+			}
+		}
+	}
+}
+
+// convertCMYK converts a CMYK image (e.g. a print JPEG) to RGBA once, with the bulk conversion of image/draw, so the
+// cropping and resizing do not convert each pixel they read. Other images are returned as is.
+func convertCMYK(img image.Image) image.Image {
+	c, ok := img.(*image.CMYK)
+	if !ok {
+		return img
+	}
+	out := image.NewRGBA(c.Bounds())
+	draw.Draw(out, out.Bounds(), c, c.Bounds().Min, draw.Src)
+	return out
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"math"
+)
+
+// PlaceholderKind describes what kind of placeholder an image is
+type PlaceholderKind int
+
+const (
+	// PlaceholderNone is a real image
+	PlaceholderNone PlaceholderKind = iota
+	// PlaceholderBlank is fully transparent
+	PlaceholderBlank
+	// PlaceholderSolid is a single (possibly slightly noisy) color
+	PlaceholderSolid
+	// PlaceholderCheckerboard is a two tone gray pattern, e.g. the transparency checkerboard
+	PlaceholderCheckerboard
+	// PlaceholderNoImage is a plain gray/white image with a small text or icon, e.g. "no image available"
+	PlaceholderNoImage
+)
+
+const (
+	// placeholderSize is the size the image is sampled down to before the detection
+	placeholderSize = 64
+	// placeholderColorfulness is the colorfulness (Hasler and Süsstrunk, 0-255 scale) below which an image is gray
+	placeholderColorfulness = 10
+	// placeholderDeltaE is the CIEDE2000 delta E (0-100 scale) within which pixels count as the same color
+	placeholderDeltaE = 4
+)
+
+func (k PlaceholderKind) String() string {
+	switch k {
+	case PlaceholderNone:
+		return "none"
+	case PlaceholderBlank:
+		return "blank"
+	case PlaceholderSolid:
+		return "solid"
+	case PlaceholderCheckerboard:
+		return "checkerboard"
+	case PlaceholderNoImage:
+		return "no-image"
+	}
+	return "unknown"
+}
+
+// IsPlaceholder checks if the image is a solid color, a checkerboard or a "no image available" style placeholder,
+// so it can be skipped or flagged before extracting its colors
+func IsPlaceholder(img image.Image) bool {
+	return DetectPlaceholder(img) != PlaceholderNone
+}
+
+// DetectPlaceholder returns what kind of placeholder the image is, PlaceholderNone for a real image
+func DetectPlaceholder(img image.Image) PlaceholderKind {
+	img = samplePixels(img, placeholderSize)
+
+	b := img.Bounds()
+	var pixels []ColorItem
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c, ignore := createColor(img.At(x, y))
+			if ignore {
+				continue
+			}
+			c.Cnt = 1
+			pixels = append(pixels, c)
+		}
+	}
+	if len(pixels) == 0 {
+		return PlaceholderBlank
+	}
+
+	// the share of the pixels close to the most common colors
+	shares := placeholderShares(pixels)
+	if shares[0] >= 0.98 {
+		return PlaceholderSolid
+	}
+	if colorfulness(pixels) >= placeholderColorfulness {
+		return PlaceholderNone
+	}
+	if len(shares) > 1 && shares[1] >= 0.25 && shares[0]+shares[1] >= 0.95 && alternates(img) {
+		return PlaceholderCheckerboard
+	}
+	if shares[0] >= 0.85 {
+		return PlaceholderNoImage
+	}
+	return PlaceholderNone
+}
+
+// samplePixels picks (at most) size by size evenly spaced pixels, keeping their exact colors unlike resizing
+func samplePixels(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := min(b.Dx(), size), min(b.Dy(), size)
+	out := image.NewRGBA64(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	return out
+}
+
+// placeholderShares groups the pixels by their exact color and returns the share of the pixels within
+// placeholderDeltaE of each of the two most common colors (most common first)
+func placeholderShares(pixels []ColorItem) []float64 {
+	merged := mergeColors([][]ColorItem{pixels})
+	sortCentroids(merged)
+
+	var references []ColorItem
+	for _, c := range merged {
+		if len(references) == 2 {
+			break
+		}
+		if len(references) == 1 && distanceCIEDE2000(c, references[0])*100 <= placeholderDeltaE {
+			continue
+		}
+		references = append(references, c)
+	}
+
+	shares := make([]float64, len(references))
+	for _, c := range merged {
+		for i, ref := range references {
+			if distanceCIEDE2000(c, ref)*100 <= placeholderDeltaE {
+				shares[i] += float64(c.Cnt) / float64(len(pixels))
+				break
+			}
+		}
+	}
+	return shares
+}
+
+// colorfulness is the metric by Hasler and Süsstrunk (0-255 scale), based on the opponent color channels
+func colorfulness(pixels []ColorItem) float64 {
+	var sumRG, sumYB, sumRG2, sumYB2 float64
+	for _, c := range pixels {
+		r, g, b := float64(c.Color.R), float64(c.Color.G), float64(c.Color.B)
+		rg := r - g
+		yb := (r+g)/2 - b
+		sumRG += rg
+		sumYB += yb
+		sumRG2 += rg * rg
+		sumYB2 += yb * yb
+	}
+	n := float64(len(pixels))
+	meanRG, meanYB := sumRG/n, sumYB/n
+	varRG := math.Max(sumRG2/n-meanRG*meanRG, 0)
+	varYB := math.Max(sumYB2/n-meanYB*meanYB, 0)
+	return math.Sqrt(varRG+varYB) + 0.3*math.Sqrt(meanRG*meanRG+meanYB*meanYB)
+}
+
+// alternates checks if the lightness changes often both along the rows and the columns, as in a checkerboard,
+// unlike an image split in two areas. Each pixel is compared to the mean lightness.
+func alternates(img image.Image) bool {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	lightness := make([]float64, w*h)
+	sum := 0.0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c, _ := createColor(img.At(b.Min.X+x, b.Min.Y+y))
+			l, _, _ := c.toColorful().Lab()
+			lightness[y*w+x] = l
+			sum += l
+		}
+	}
+	meanL := sum / float64(w*h)
+	light := func(x, y int) bool {
+		return lightness[y*w+x] >= meanL
+	}
+
+	horizontal, vertical := 0, 0
+	for y := 0; y < h; y++ {
+		for x := 1; x < w; x++ {
+			if light(x, y) != light(x-1, y) {
+				horizontal++
+			}
+		}
+	}
+	for x := 0; x < w; x++ {
+		for y := 1; y < h; y++ {
+			if light(x, y) != light(x, y-1) {
+				vertical++
+			}
+		}
+	}
+
+	// at least 3 changes per row and per column on average
+	return horizontal >= 3*h && vertical >= 3*w
+}
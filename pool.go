@@ -0,0 +1,187 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"context"
+	"image"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+func init() {
+	registerBackend("adaptive-pool")
+}
+
+// heapMetric is the runtime metric with the bytes of live and not yet swept heap objects
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// Job is an image to process by an AdaptivePool, either decoded (Image) or encoded (Data)
+type Job struct {
+	ID    string
+	Image image.Image
+	Data  []byte
+}
+
+// JobResult is the outcome of a Job
+type JobResult struct {
+	ID string
+	Result
+	Err error
+
+	// Latency is the time the job took, including decoding
+	Latency time.Duration
+}
+
+// AdaptivePool is a long-running worker pool adapting its concurrency to the load (AIMD): the number of workers
+// grows by one after as many jobs as there are workers were within TargetLatency and MaxHeapBytes, and is halved
+// when a job is slower or the heap is larger. It is halved at most once per adjustment window, for the jobs started
+// since the last change of the number of workers, so a burst of slow jobs running together halves it once.
+// Create it with NewAdaptivePool.
+type AdaptivePool struct {
+	opts Options
+
+	// MinWorkers and MaxWorkers bound the concurrency
+	MinWorkers, MaxWorkers int
+
+	// TargetLatency is the longest accepted time per job
+	TargetLatency time.Duration
+
+	// MaxHeapBytes if set is the largest accepted heap size
+	MaxHeapBytes uint64
+
+	mu        sync.Mutex
+	limit     int
+	active    int
+	successes int
+
+	// window counts the changes of limit, a job only halves it if started in the current window
+	window int
+
+	// changed is closed (and replaced) when a worker is freed
+	changed chan struct{}
+}
+
+// NewAdaptivePool creates a pool processing the jobs with opts, starting with a single worker
+func NewAdaptivePool(opts Options, maxWorkers int, targetLatency time.Duration) *AdaptivePool {
+	return &AdaptivePool{opts: opts, MinWorkers: 1, MaxWorkers: max(maxWorkers, 1), TargetLatency: targetLatency, limit: 1,
+		changed: make(chan struct{})}
+}
+
+// Concurrency returns the current number of workers allowed
+func (p *AdaptivePool) Concurrency() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.limit
+}
+
+// Run processes the jobs until the channel is closed or ctx is done, sending a result per job.
+// It returns when all started jobs are done, with the error of ctx if it was cancelled. Once ctx is done the
+// results not received yet are dropped, so Run returns even if results is no longer read.
+func (p *AdaptivePool) Run(ctx context.Context, jobs <-chan Job, results chan<- JobResult) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		var job Job
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case job, ok = <-jobs:
+			if !ok {
+				return nil
+			}
+		}
+
+		window, err := p.acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := p.process(job)
+			p.adapt(res.Latency, window)
+			select {
+			case results <- res:
+			case <-ctx.Done():
+			}
+		}()
+	}
+}
+
+// acquire waits for a free worker until ctx is done, returning the current adjustment window
+func (p *AdaptivePool) acquire(ctx context.Context) (int, error) {
+	for {
+		p.mu.Lock()
+		if p.active < p.limit {
+			p.active++
+			window := p.window
+			p.mu.Unlock()
+			return window, nil
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// process runs a single job
+func (p *AdaptivePool) process(job Job) JobResult {
+	start := time.Now()
+	res := JobResult{ID: job.ID}
+	if job.Image != nil {
+		res.Result, res.Err = KmeansWithOptions(job.Image, p.opts)
+	} else {
+		res.Result, res.Err = KmeansFromBytes(job.Data, p.opts)
+	}
+	res.Latency = time.Since(start)
+	return res
+}
+
+// adapt frees the worker and updates the concurrency limit from the latency of the job and the heap size, window
+// being the adjustment window the job was started in
+func (p *AdaptivePool) adapt(latency time.Duration, window int) {
+	overloaded := latency > p.TargetLatency || (p.MaxHeapBytes > 0 && heapBytes() > p.MaxHeapBytes)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active--
+	switch {
+	case overloaded && window == p.window:
+		p.limit = max(p.limit/2, p.MinWorkers, 1)
+		p.successes = 0
+		p.window++
+	case overloaded:
+		// already halved for the jobs running together with this one
+	default:
+		if p.successes++; p.successes >= p.limit {
+			p.limit = min(p.limit+1, p.MaxWorkers)
+			p.successes = 0
+			p.window++
+		}
+	}
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// heapBytes returns the size of the heap objects
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
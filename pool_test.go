@@ -0,0 +1,81 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdaptivePoolHalvesOncePerWindow(t *testing.T) {
+	p := NewAdaptivePool(DefaultOptions(), 16, time.Second)
+	p.limit = 8
+
+	// 8 slow jobs started together halve the workers once
+	var windows []int
+	for i := 0; i < 8; i++ {
+		window, err := p.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		windows = append(windows, window)
+	}
+	for _, window := range windows {
+		p.adapt(2*time.Second, window)
+	}
+	if got := p.Concurrency(); got != 4 {
+		t.Errorf("Expected 4 workers after a burst of slow jobs, got %d", got)
+	}
+
+	// a slow job started after that halves them again
+	window, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.adapt(2*time.Second, window)
+	if got := p.Concurrency(); got != 2 {
+		t.Errorf("Expected 2 workers, got %d", got)
+	}
+}
+
+func TestAdaptivePoolAcquireCancelled(t *testing.T) {
+	p := NewAdaptivePool(DefaultOptions(), 1, time.Second)
+	if _, err := p.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait for a worker to end with the context, got %v", err)
+	}
+}
+
+func TestAdaptivePoolRunReturnsWithoutReader(t *testing.T) {
+	p := NewAdaptivePool(DefaultOptions(), 4, time.Minute)
+	jobs := make(chan Job, 3)
+	for i := 0; i < 3; i++ {
+		jobs <- Job{Image: benchImage(40, 30)}
+	}
+	// nobody reads the results
+	results := make(chan JobResult)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx, jobs, results) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the context error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Run to return after the context was cancelled")
+	}
+}
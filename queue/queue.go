@@ -0,0 +1,204 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+// Package queue implements the consumer loop of a color extraction service fed from a message queue:
+// receive a request, fetch and analyze the image and publish the result. The queue (e.g. NATS or Kafka) is
+// plugged in through the Source and Sink interfaces and the message format through a Codec. No queue clients are
+// included, the Source and Sink of a queue are implemented by the caller.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cjkgg/prominentcolor"
+)
+
+// Message is a message received from a queue
+type Message struct {
+	Data []byte
+
+	// Ack if set acknowledges (commits) the message, it is called once the result is published
+	Ack func() error
+}
+
+// Source receives the request messages, e.g. a NATS subscription or a Kafka consumer
+type Source interface {
+	Receive(ctx context.Context) (Message, error)
+}
+
+// Sink publishes the result messages, e.g. to a NATS subject or a Kafka topic
+type Sink interface {
+	Publish(ctx context.Context, data []byte) error
+}
+
+// Request asks for the colors of an image, either fetched from URL or encoded in Data.
+// Metadata (e.g. the asset ID and license) is passed through to the Response.
+type Request struct {
+	ID       string            `json:"id"`
+	URL      string            `json:"url,omitempty"`
+	Data     []byte            `json:"data,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Color is a color of a Response
+type Color struct {
+	Hex     string  `json:"hex"`
+	Percent float64 `json:"percent"`
+}
+
+// Response is the result of a Request, Error is set if it failed. Fingerprint is the Result.Fingerprint, to tell
+// if stored responses are stale.
+type Response struct {
+	ID          string            `json:"id"`
+	Colors      []Color           `json:"colors,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// Codec converts the messages
+type Codec interface {
+	DecodeRequest(data []byte) (Request, error)
+	EncodeResponse(res Response) ([]byte, error)
+}
+
+// JSONCodec encodes the messages as JSON, Data as base64
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) DecodeRequest(data []byte) (Request, error) {
+	var req Request
+	err := json.Unmarshal(data, &req)
+	return req, err
+}
+
+func (jsonCodec) EncodeResponse(res Response) ([]byte, error) {
+	return json.Marshal(res)
+}
+
+// Consumer receives requests from Source and publishes the responses to Sink
+type Consumer struct {
+	Source Source
+	Sink   Sink
+
+	// Codec converts the messages, JSONCodec if nil
+	Codec Codec
+
+	// Options are used for every image
+	Options prominentcolor.Options
+
+	// Client fetches the images of requests with an URL, prominentcolor.PublicHTTPClient if nil
+	Client *http.Client
+
+	// AllowURL if set tells which URLs of requests may be fetched. The URLs of requests are only fetched if
+	// AllowURL or Client is set, otherwise these requests fail.
+	AllowURL func(u *url.URL) bool
+}
+
+// Run processes requests until ctx is done or the Source fails. A request that can not be decoded or analyzed gets a
+// response with the error, so a broken message is not retried forever.
+func (c *Consumer) Run(ctx context.Context) error {
+	codec := c.Codec
+	if codec == nil {
+		codec = JSONCodec
+	}
+	for {
+		msg, err := c.Source.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("Failed receiving: %v", err)
+		}
+
+		data, err := codec.EncodeResponse(c.handle(codec, msg.Data))
+		if err != nil {
+			return fmt.Errorf("Failed encoding response: %v", err)
+		}
+		if err := c.Sink.Publish(ctx, data); err != nil {
+			return fmt.Errorf("Failed publishing: %v", err)
+		}
+		if msg.Ack != nil {
+			if err := msg.Ack(); err != nil {
+				return fmt.Errorf("Failed acknowledging: %v", err)
+			}
+		}
+	}
+}
+
+// handle decodes and analyzes a request
+func (c *Consumer) handle(codec Codec, data []byte) Response {
+	req, err := codec.DecodeRequest(data)
+	if err != nil {
+		return Response{Error: fmt.Sprintf("Failed decoding request: %v", err)}
+	}
+	opts := c.Options
+	opts.Metadata = metadata(c.Options.Metadata, req.Metadata)
+	// the metadata is passed through even if the image can not be analyzed
+	res := Response{ID: req.ID, Metadata: opts.Metadata}
+
+	var result prominentcolor.Result
+	if req.URL != "" {
+		if err := c.allowed(req.URL); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		result, err = prominentcolor.KmeansFromURL(c.Client, req.URL, opts)
+	} else {
+		result, err = prominentcolor.KmeansFromBytes(req.Data, opts)
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Fingerprint = result.Fingerprint
+	for _, color := range result.Colors {
+		res.Colors = append(res.Colors, Color{Hex: "#" + color.AsString(), Percent: color.Percentage})
+	}
+	return res
+}
+
+// allowed checks if the URL of a request may be fetched
+func (c *Consumer) allowed(rawURL string) error {
+	if c.AllowURL == nil {
+		if c.Client == nil {
+			return fmt.Errorf("Failed, fetching URLs is disabled, set Consumer.Client or Consumer.AllowURL")
+		}
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("Failed parsing URL: %v", err)
+	}
+	if !c.AllowURL(u) {
+		return fmt.Errorf("Failed, URL %s is not allowed", u.Redacted())
+	}
+	return nil
+}
+
+// metadata combines the metadata of the options with the one of the request, which takes precedence
+func metadata(opts, req map[string]string) map[string]string {
+	if len(opts) == 0 {
+		return req
+	}
+	if len(req) == 0 {
+		return opts
+	}
+	m := make(map[string]string, len(opts)+len(req))
+	for k, v := range opts {
+		m[k] = v
+	}
+	for k, v := range req {
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package queue
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandleURLRequests(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	img.Set(0, 0, color.RGBA{0, 0, 0xFF, 0xFF})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+	request := []byte(`{"id": "a", "url": "` + server.URL + `/a.png"}`)
+
+	// fetching is disabled unless a Client or AllowURL is set
+	res := (&Consumer{}).handle(JSONCodec, request)
+	if !strings.Contains(res.Error, "disabled") {
+		t.Errorf("Expected fetching to be disabled, got %q", res.Error)
+	}
+
+	allowNone := func(u *url.URL) bool { return false }
+	res = (&Consumer{Client: server.Client(), AllowURL: allowNone}).handle(JSONCodec, request)
+	if !strings.Contains(res.Error, "not allowed") {
+		t.Errorf("Expected the URL not to be allowed, got %q", res.Error)
+	}
+
+	res = (&Consumer{Client: server.Client()}).handle(JSONCodec, request)
+	if res.Error != "" || len(res.Colors) == 0 {
+		t.Errorf("Expected colors, got %+v", res)
+	}
+	if res.ID != "a" {
+		t.Errorf("Expected ID a, got %q", res.ID)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// PixelFormat is the byte order of the pixels of a raw buffer, alpha is not premultiplied
+type PixelFormat int
+
+const (
+	// PixelFormatRGBA has 4 bytes per pixel: red, green, blue and alpha
+	PixelFormatRGBA PixelFormat = iota
+	// PixelFormatBGRA has 4 bytes per pixel: blue, green, red and alpha
+	PixelFormatBGRA
+	// PixelFormatRGBX has 4 bytes per pixel: red, green, blue and an unused byte
+	PixelFormatRGBX
+	// PixelFormatBGRX has 4 bytes per pixel: blue, green, red and an unused byte
+	PixelFormatBGRX
+	// PixelFormatRGB has 3 bytes per pixel: red, green and blue
+	PixelFormatRGB
+	// PixelFormatBGR has 3 bytes per pixel: blue, green and red
+	PixelFormatBGR
+)
+
+func (f PixelFormat) String() string {
+	switch f {
+	case PixelFormatRGBA:
+		return "RGBA"
+	case PixelFormatBGRA:
+		return "BGRA"
+	case PixelFormatRGBX:
+		return "RGBX"
+	case PixelFormatBGRX:
+		return "BGRX"
+	case PixelFormatRGB:
+		return "RGB"
+	case PixelFormatBGR:
+		return "BGR"
+	}
+	return "unknown"
+}
+
+// bytesPerPixel returns the size of a pixel, 0 for unknown formats
+func (f PixelFormat) bytesPerPixel() int {
+	switch f {
+	case PixelFormatRGBA, PixelFormatBGRA, PixelFormatRGBX, PixelFormatBGRX:
+		return 4
+	case PixelFormatRGB, PixelFormatBGR:
+		return 3
+	}
+	return 0
+}
+
+// rawImage is an image.Image reading the pixels directly from a raw buffer
+type rawImage struct {
+	pix    []byte
+	stride int
+	rect   image.Rectangle
+	format PixelFormat
+}
+
+func (r *rawImage) ColorModel() color.Model { return color.NRGBAModel }
+
+func (r *rawImage) Bounds() image.Rectangle { return r.rect }
+
+func (r *rawImage) At(x, y int) color.Color {
+	if !image.Pt(x, y).In(r.rect) {
+		return color.NRGBA{}
+	}
+	p := r.pix[y*r.stride+x*r.format.bytesPerPixel():]
+	switch r.format {
+	case PixelFormatBGRA:
+		return color.NRGBA{R: p[2], G: p[1], B: p[0], A: p[3]}
+	case PixelFormatRGBX, PixelFormatRGB:
+		return color.NRGBA{R: p[0], G: p[1], B: p[2], A: 0xff}
+	case PixelFormatBGRX, PixelFormatBGR:
+		return color.NRGBA{R: p[2], G: p[1], B: p[0], A: 0xff}
+	}
+	return color.NRGBA{R: p[0], G: p[1], B: p[2], A: p[3]}
+}
+
+// Opaque reports if the format has no alpha, so the alpha threshold can be skipped
+func (r *rawImage) Opaque() bool {
+	return r.format != PixelFormatBGRA
+}
+
+// NewRawImage wraps a raw pixel buffer (e.g. a frame of a capture pipeline) as an image without copying it.
+// stride is the number of bytes per row.
+func NewRawImage(pix []byte, width, height, stride int, format PixelFormat) (image.Image, error) {
+	bpp := format.bytesPerPixel()
+	if bpp == 0 {
+		return nil, fmt.Errorf("Failed, unknown pixel format %d", format)
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("Failed, invalid image dimensions %dx%d", width, height)
+	}
+	if stride < width*bpp {
+		return nil, fmt.Errorf("Failed, stride %d is less than %d pixels of %d bytes", stride, width, bpp)
+	}
+	if need := stride*(height-1) + width*bpp; len(pix) < need {
+		return nil, fmt.Errorf("Failed, buffer of %d bytes is too small for %dx%d %v with stride %d (%d bytes)",
+			len(pix), width, height, format, stride, need)
+	}
+
+	rect := image.Rect(0, 0, width, height)
+	if format == PixelFormatRGBA {
+		return &image.NRGBA{Pix: pix, Stride: stride, Rect: rect}, nil
+	}
+	return &rawImage{pix: pix, stride: stride, rect: rect, format: format}, nil
+}
+
+// KmeansRaw finds the colors of a raw pixel buffer, see NewRawImage
+func KmeansRaw(pix []byte, width, height, stride int, format PixelFormat, opts Options) (Result, error) {
+	img, err := NewRawImage(pix, width, height, stride, format)
+	if err != nil {
+		return Result{}, err
+	}
+	return KmeansWithOptions(img, opts)
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/nfnt/resize"
+)
+
+// Resizer scales the image before processing, a width or height of 0 keeps the aspect ratio
+type Resizer interface {
+	Resize(img image.Image, width, height uint) image.Image
+}
+
+// filterResizer resizes with an interpolation filter of github.com/nfnt/resize
+type filterResizer struct {
+	filter resize.InterpolationFunction
+}
+
+func (r filterResizer) Resize(img image.Image, width, height uint) image.Image {
+	return resize.Resize(width, height, img, r.filter)
+}
+
+var (
+	// ResizerNearest is the fastest, but fine textures can give other colors than seen when the image is scaled down
+	ResizerNearest Resizer = filterResizer{filter: resize.NearestNeighbor}
+	// ResizerBilinear is a fast compromise
+	ResizerBilinear Resizer = filterResizer{filter: resize.Bilinear}
+	// ResizerLanczos is the slowest and gives the colors of the scaled down image (default)
+	ResizerLanczos Resizer = filterResizer{filter: resize.Lanczos3}
+)
+
+// ResizerBox averages the pixels each output pixel covers using integer math only, so it gives identical results on
+// all architectures, see ArgumentPortable
+var ResizerBox Resizer = boxResizer{}
+
+type boxResizer struct{}
+
+func (boxResizer) Resize(img image.Image, width, height uint) image.Image {
+	b := img.Bounds()
+	w, h := int(width), int(height)
+	if w == 0 && h == 0 {
+		w, h = b.Dx(), b.Dy()
+	} else if w == 0 {
+		w = max(1, (b.Dx()*h+b.Dy()/2)/b.Dy())
+	} else if h == 0 {
+		h = max(1, (b.Dy()*w+b.Dx()/2)/b.Dx())
+	}
+
+	out := image.NewRGBA64(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		y1 = max(y1, y0+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			x1 = max(x1, x0+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			out.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return out
+}
+
+// ResizerSample picks original pixels instead of blending them, so the scaled down image only has colors found in
+// the image. The output pixels sample their part of the image at positions jittered by the R2 low-discrepancy
+// sequence, which avoids the aliasing of ResizerNearest on regular patterns. It is deterministic and uses integer
+// math only. See also Options.Samples.
+var ResizerSample Resizer = sampleResizer{}
+
+// r2X and r2Y are the steps of the R2 sequence, 1/φ₂ and 1/φ₂² (φ₂ the plastic number) as 0.32 fixed point
+const (
+	r2X = 3242174889
+	r2Y = 2447445413
+)
+
+// sampleResizer samples the image, to about samples pixels if set, otherwise to the width and height asked for
+type sampleResizer struct {
+	samples int
+}
+
+func (r sampleResizer) Resize(img image.Image, width, height uint) image.Image {
+	b := img.Bounds()
+	if b.Empty() {
+		return img
+	}
+	var w, h int
+	if r.samples > 0 {
+		if b.Dx()*b.Dy() <= r.samples {
+			return img
+		}
+		// keep the aspect ratio with w*h about samples
+		w = max(1, min(b.Dx(), int(math.Round(math.Sqrt(float64(r.samples)*float64(b.Dx())/float64(b.Dy()))))))
+		h = max(1, min(b.Dy(), r.samples/w))
+	} else {
+		w, h = int(width), int(height)
+		if w == 0 && h == 0 {
+			return img
+		} else if w == 0 {
+			w = max(1, (b.Dx()*h+b.Dy()/2)/b.Dy())
+		} else if h == 0 {
+			h = max(1, (b.Dy()*w+b.Dx()/2)/b.Dx())
+		}
+	}
+
+	out := image.NewRGBA64(image.Rect(0, 0, w, h))
+	var i uint32
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			i++
+			// the fractional part of i*r2X wraps around, as does the fixed point multiplication
+			sx := x0 + int(uint64(i*r2X)*uint64(max(x1-x0, 1))>>32)
+			sy := y0 + int(uint64(i*r2Y)*uint64(max(y1-y0, 1))>>32)
+			out.Set(x, y, img.At(sx, sy))
+		}
+	}
+	return out
+}
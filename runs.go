@@ -0,0 +1,138 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+)
+
+// runLengthMinRun is the shortest average run (in pixels) for which the runs of an image are kept, below it they take
+// more memory than a copy of the pixels
+const runLengthMinRun = 4
+
+// colorRun is a run of identical pixels in a row, ending before x = end
+type colorRun struct {
+	end int32
+	c   color.RGBA64
+}
+
+// runImage is an image stored as runs of identical pixels per row, taking a fraction of the memory of the pixels
+// for flat images like screenshots, illustrations and logos
+type runImage struct {
+	rect image.Rectangle
+	rows [][]colorRun
+}
+
+// ColorModel returns the color model of the image
+func (m *runImage) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+// Bounds returns the bounds of the image
+func (m *runImage) Bounds() image.Rectangle {
+	return m.rect
+}
+
+// At returns the color of the pixel at x, y
+func (m *runImage) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}).In(m.rect) {
+		return color.RGBA64{}
+	}
+	row := m.rows[y-m.rect.Min.Y]
+	i := sort.Search(len(row), func(i int) bool { return int(row[i].end) > x })
+	return row[i].c
+}
+
+// newRunImage encodes the pixels of img in b as runs, as they are after copying the image with createDrawImage and
+// applying the alpha threshold. It returns false if the average run is shorter than runLengthMinRun.
+func newRunImage(img image.Image, b image.Rectangle, threshold uint32) (*runImage, bool) {
+	m := &runImage{rect: b, rows: make([][]colorRun, b.Dy())}
+	// each row is copied to the same buffer, so the pixels are converted exactly as by createDrawImage
+	var row draw.Image = image.NewRGBA(image.Rect(0, 0, b.Dx(), 1))
+	if is16Bit(img) {
+		row = image.NewRGBA64(image.Rect(0, 0, b.Dx(), 1))
+	}
+
+	runs, pixels := 0, 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		draw.Draw(row, row.Bounds(), img, image.Point{X: b.Min.X, Y: y}, draw.Src)
+		converted, _ := applyAlphaThreshold(row, threshold)
+
+		var runsOfRow []colorRun
+		forEachPixel(converted, func(x, _ int, r, g, bl, a uint32) {
+			c := color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(bl), A: uint16(a)}
+			if n := len(runsOfRow); n > 0 && runsOfRow[n-1].c == c {
+				runsOfRow[n-1].end++
+				return
+			}
+			runsOfRow = append(runsOfRow, colorRun{end: int32(b.Min.X + x + 1), c: c})
+		})
+		m.rows[y-b.Min.Y] = runsOfRow
+
+		runs += len(runsOfRow)
+		pixels += b.Dx()
+		if runs*runLengthMinRun > pixels {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+// forEachRun calls fn for each run of n identical pixels in a row starting at x, y: the runs of a run-length
+// encoded image, and the runs of the pixels read by forEachPixel for other images
+func forEachRun(img image.Image, fn func(x, y, n int, r, g, b, a uint32)) {
+	if m, ok := img.(*runImage); ok {
+		for i, row := range m.rows {
+			x := m.rect.Min.X
+			for _, run := range row {
+				fn(x, m.rect.Min.Y+i, int(run.end)-x, uint32(run.c.R), uint32(run.c.G), uint32(run.c.B), uint32(run.c.A))
+				x = int(run.end)
+			}
+		}
+		return
+	}
+
+	var startX, startY, n int
+	var r0, g0, b0, a0 uint32
+	forEachPixel(img, func(x, y int, r, g, b, a uint32) {
+		if n > 0 && y == startY && r == r0 && g == g0 && b == b0 && a == a0 {
+			n++
+			return
+		}
+		if n > 0 {
+			fn(startX, startY, n, r0, g0, b0, a0)
+		}
+		startX, startY, n = x, y, 1
+		r0, g0, b0, a0 = r, g, b, a
+	})
+	if n > 0 {
+		fn(startX, startY, n, r0, g0, b0, a0)
+	}
+}
+
+// prepareRuns crops the image like prepareImg and keeps it as runs of identical pixels instead of copying it, for
+// flat images that are not resized and only need the histogram (see histogramOnly). It returns false if the image
+// is not flat enough.
+func (o Options) prepareRuns(arguments int, img image.Image) (image.Image, preparation, bool) {
+	var prep preparation
+	prep.stats.TotalPixels = img.Bounds().Dx() * img.Bounds().Dy()
+	prep.stats.MaskedPixels = make(map[string]int)
+
+	b := cropBounds(arguments, img.Bounds())
+	out, ok := newRunImage(img, b, o.alphaThreshold())
+	if !ok {
+		return nil, preparation{}, false
+	}
+	prep.stats.CroppedPixels = prep.stats.TotalPixels - b.Dx()*b.Dy()
+	prep.stats.ProcessedPixels = b.Dx() * b.Dy()
+	prep.skipped = prep.stats.ProcessedPixels - countOpaque(out)
+	prep.stats.TransparentPixels = prep.skipped
+	prep.stats.ClusteredPixels = prep.stats.ProcessedPixels - prep.skipped
+	prep.beforeMasks = out
+	return out, prep, true
+}
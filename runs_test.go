@@ -0,0 +1,49 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRunsMatchSlowPathOnFramedImages(t *testing.T) {
+	red, blue := color.RGBA{R: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff}
+	for _, tc := range []struct {
+		name                      string
+		frame, background, center color.Color
+	}{
+		{"white background", red, color.White, blue},
+		{"black background", red, color.Black, blue},
+		{"white frame", color.White, red, blue},
+		{"no background", red, blue, color.White},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := framedImage(60, tc.frame, tc.background, tc.center)
+			opts := DefaultOptions()
+			opts.Arguments = ArgumentDeterministic
+			fast, err := KmeansWithOptions(img, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slow, err := KmeansWithOptions(img, slowPath(opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameColors(t, fast.Colors, slow.Colors)
+		})
+	}
+}
+
+func TestKmeansMasksFramedBackground(t *testing.T) {
+	img := framedImage(60, color.RGBA{R: 0xff, A: 0xff}, color.White, color.RGBA{B: 0xff, A: 0xff})
+	colors, err := Kmeans(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colors) != 1 || colors[0].AsString() != "0000FF" || colors[0].Cnt != 100 {
+		t.Errorf("got %v, want only the 100 blue pixels of the center", colors)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+)
+
+// SafeArea is the aspect ratio (width / height) of a centered crop made by a platform, e.g. a social media feed
+type SafeArea float64
+
+const (
+	// SafeAreaSquare is the 1:1 crop of feeds and profile grids
+	SafeAreaSquare SafeArea = 1
+	// SafeAreaPortrait is the 4:5 crop of portrait feed posts
+	SafeAreaPortrait SafeArea = 4.0 / 5.0
+	// SafeAreaStory is the 9:16 crop of stories and short videos
+	SafeAreaStory SafeArea = 9.0 / 16.0
+)
+
+// SafeAreasSocial are the common social media crops
+var SafeAreasSocial = []SafeArea{SafeAreaSquare, SafeAreaPortrait, SafeAreaStory}
+
+// rect returns the largest centered rectangle with the aspect ratio within b
+func (a SafeArea) rect(b image.Rectangle) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if float64(w) > float64(h)*float64(a) {
+		w = int(float64(h)*float64(a) + 0.5)
+	} else {
+		h = int(float64(w)/float64(a) + 0.5)
+	}
+	minX, minY := b.Min.X+(b.Dx()-w)/2, b.Min.Y+(b.Dy()-h)/2
+	return image.Rect(minX, minY, minX+w, minY+h)
+}
+
+// safeAreaWeight returns the weight of each pixel, the number of the safe areas of b containing it
+func safeAreaWeight(b image.Rectangle, areas []SafeArea) func(x, y int) int {
+	rects := make([]image.Rectangle, len(areas))
+	for i, a := range areas {
+		rects[i] = a.rect(b)
+	}
+	return func(x, y int) int {
+		w := 0
+		for _, r := range rects {
+			if image.Pt(x, y).In(r) {
+				w++
+			}
+		}
+		return w
+	}
+}
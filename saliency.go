@@ -0,0 +1,114 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"math"
+)
+
+// maxSaliencyWeight is the count given to the most salient pixel, the least salient one counts as 1
+const maxSaliencyWeight = 100
+
+// binomialKernel approximates a gaussian blur, applied horizontally and vertically
+var binomialKernel = []float64{1, 4, 6, 4, 1}
+
+// saliencyMap estimates the saliency of each pixel using the frequency tuned method (Achanta et al. 2009):
+// the LAB distance between the slightly blurred pixel and the mean color of the image, normalized to 0-1.
+// Transparent pixels get 0. The map is indexed [y-Min.Y][x-Min.X].
+func saliencyMap(img image.Image) [][]float64 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// LAB planes (zero for transparent pixels), with the opacity to normalize the blur
+	lab := [3][]float64{make([]float64, w*h), make([]float64, w*h), make([]float64, w*h)}
+	opaque := make([]float64, w*h)
+	var mean [3]float64
+	n := 0.0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c, ignore := createColor(img.At(b.Min.X+x, b.Min.Y+y))
+			if ignore {
+				continue
+			}
+			l, a, bb := c.toColorful().Lab()
+			i := y*w + x
+			lab[0][i], lab[1][i], lab[2][i] = l, a, bb
+			opaque[i] = 1
+			mean[0], mean[1], mean[2] = mean[0]+l, mean[1]+a, mean[2]+bb
+			n++
+		}
+	}
+
+	saliency := make([][]float64, h)
+	for y := range saliency {
+		saliency[y] = make([]float64, w)
+	}
+	if n == 0 {
+		return saliency
+	}
+	for i := range mean {
+		mean[i] /= n
+	}
+
+	weights := blur(blur(opaque, w, h, 1, 0), w, h, 0, 1)
+	var blurred [3][]float64
+	for ch := range blurred {
+		blurred[ch] = blur(blur(lab[ch], w, h, 1, 0), w, h, 0, 1)
+	}
+
+	highest := 0.0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if opaque[i] == 0 || weights[i] == 0 {
+				continue
+			}
+			var d float64
+			for ch := range blurred {
+				d += sq(blurred[ch][i]/weights[i] - mean[ch])
+			}
+			saliency[y][x] = math.Sqrt(d)
+			highest = math.Max(highest, saliency[y][x])
+		}
+	}
+	if highest > 0 {
+		for y := range saliency {
+			for x := range saliency[y] {
+				saliency[y][x] /= highest
+			}
+		}
+	}
+	return saliency
+}
+
+// blur convolves the plane with binomialKernel in the direction dx, dy, ignoring pixels outside the plane
+func blur(plane []float64, w, h, dx, dy int) []float64 {
+	out := make([]float64, len(plane))
+	half := len(binomialKernel) / 2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for k, f := range binomialKernel {
+				sx, sy := x+(k-half)*dx, y+(k-half)*dy
+				if sx < 0 || sx >= w || sy < 0 || sy >= h {
+					continue
+				}
+				sum += plane[sy*w+sx] * f
+			}
+			out[y*w+x] = sum
+		}
+	}
+	return out
+}
+
+// saliencyWeight returns the weight of each pixel, between 1 and maxSaliencyWeight depending on its saliency
+func saliencyWeight(img image.Image) func(x, y int) int {
+	saliency := saliencyMap(img)
+	b := img.Bounds()
+	return func(x, y int) int {
+		return 1 + int(math.Round(saliency[y-b.Min.Y][x-b.Min.X]*(maxSaliencyWeight-1)))
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+)
+
+// ScreenshotWhiteThreshold is the value (0-0xffff) all channels of the near white UI chrome excluded by
+// ScreenshotOptions are at least, e.g. #F5F5F5 backgrounds and #FAFAFA cards
+const ScreenshotWhiteThreshold = 0xf000
+
+// ScreenshotOptions returns the options for screenshots of apps and web pages: the whole screen is used (no center
+// crop, no corner based background masks) at its original size, as the UI colors are flat and resizing only blends
+// them, and colors count by their number of pixels. The flat regions are counted as runs, so the original size stays
+// fast. If excludeWhite is set the white and near white UI chrome is excluded, see ScreenshotWhiteThreshold.
+func ScreenshotOptions(excludeWhite bool) Options {
+	opts := Options{
+		K:         DefaultK,
+		Crop:      CropNone,
+		Size:      OriginalSize,
+		Arguments: ArgumentCountWeighted,
+	}
+	if excludeWhite {
+		opts.PixelMasks = []PixelMask{ColorPixelMask(NewWhiteMask(ScreenshotWhiteThreshold))}
+	}
+	return opts
+}
+
+// ScreenshotAccentColor finds the accent color of the UI in the screenshot (e.g. the brand color of its header,
+// buttons and links) with ScreenshotOptions. It is the dominant color if that one is colorful (AccentMinChroma), as
+// when the white chrome is excluded and the accent fills e.g. a header bar, otherwise the AccentColor of the palette.
+// It returns false if the UI has no accent color (e.g. a black and white text editor).
+func ScreenshotAccentColor(img image.Image, excludeWhite bool) (ColorItem, bool, error) {
+	res, err := KmeansWithOptions(img, ScreenshotOptions(excludeWhite))
+	if err != nil {
+		return ColorItem{}, false, err
+	}
+	if len(res.Colors) > 0 && chroma(res.Colors[0]) >= AccentMinChroma {
+		return res.Colors[0], true, nil
+	}
+	accent, ok := AccentColor(res.Colors)
+	return accent, ok, nil
+}
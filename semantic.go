@@ -0,0 +1,116 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"math"
+)
+
+// SemanticK is the number of colors SemanticSwatchesOf finds to pick the swatches from, as Android's Palette
+const SemanticK = 16
+
+// the weights of the saturation, lightness and population in the score of a color for a swatch
+const (
+	semanticSaturationWeight = 0.24
+	semanticLightnessWeight  = 0.52
+	semanticPopulationWeight = 0.24
+)
+
+// semanticTarget are the HSL saturation and lightness ranges (0-1) of a swatch, with the values scoring best
+type semanticTarget struct {
+	minS, targetS, maxS float64
+	minL, targetL, maxL float64
+}
+
+// the targets of the swatches, as those of Android's Palette
+var (
+	targetLightVibrant = semanticTarget{0.35, 1, 1, 0.55, 0.74, 1}
+	targetVibrant      = semanticTarget{0.35, 1, 1, 0.3, 0.5, 0.7}
+	targetDarkVibrant  = semanticTarget{0.35, 1, 1, 0, 0.26, 0.45}
+	targetLightMuted   = semanticTarget{0, 0.3, 0.4, 0.55, 0.74, 1}
+	targetMuted        = semanticTarget{0, 0.3, 0.4, 0.3, 0.5, 0.7}
+	targetDarkMuted    = semanticTarget{0, 0.3, 0.4, 0, 0.26, 0.45}
+)
+
+// SemanticSwatches are the colors of an image by role, as the swatches of Android's Palette: vibrant (saturated)
+// and muted (desaturated) colors in a light, medium and dark variant. A swatch is nil if no color fits it.
+type SemanticSwatches struct {
+	Vibrant, LightVibrant, DarkVibrant *ColorItem
+	Muted, LightMuted, DarkMuted       *ColorItem
+
+	// Dominant is the most frequent color
+	Dominant *ColorItem
+}
+
+// SemanticSwatchesOf finds SemanticK colors of the image with DefaultOptions and picks the semantic swatches of them
+func SemanticSwatchesOf(img image.Image) (SemanticSwatches, error) {
+	opts := DefaultOptions()
+	opts.K = SemanticK
+	res, err := KmeansWithOptions(img, opts)
+	if err != nil {
+		return SemanticSwatches{}, err
+	}
+	return res.SemanticSwatches(), nil
+}
+
+// SemanticSwatches picks the semantic swatches of the colors of the result, see PickSemanticSwatches
+func (r Result) SemanticSwatches() SemanticSwatches {
+	return PickSemanticSwatches(r.Colors)
+}
+
+// PickSemanticSwatches picks the semantic swatches of the colors, the more colors the better (e.g. SemanticK). Of
+// the colors in the saturation and lightness ranges of a swatch, the one closest to its target saturation and
+// lightness and with the most pixels is picked, scored as Android's Palette does. A color is used for one swatch
+// only, picked in the order light vibrant, vibrant, dark vibrant, light muted, muted, dark muted.
+func PickSemanticSwatches(colors []ColorItem) SemanticSwatches {
+	var swatches SemanticSwatches
+	if len(colors) == 0 {
+		return swatches
+	}
+
+	maxCnt := 0
+	dominant := 0
+	for i, c := range colors {
+		if c.Cnt > maxCnt {
+			maxCnt, dominant = c.Cnt, i
+		}
+	}
+	swatches.Dominant = &colors[dominant]
+
+	used := make([]bool, len(colors))
+	pick := func(t semanticTarget) *ColorItem {
+		best, bestScore := -1, 0.0
+		for i := range colors {
+			if used[i] {
+				continue
+			}
+			hsl := colors[i].HSL()
+			if hsl.S < t.minS || hsl.S > t.maxS || hsl.L < t.minL || hsl.L > t.maxL {
+				continue
+			}
+			score := semanticSaturationWeight*(1-math.Abs(hsl.S-t.targetS)) +
+				semanticLightnessWeight*(1-math.Abs(hsl.L-t.targetL))
+			if maxCnt > 0 {
+				score += semanticPopulationWeight * float64(colors[i].Cnt) / float64(maxCnt)
+			}
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			return nil
+		}
+		used[best] = true
+		return &colors[best]
+	}
+	swatches.LightVibrant = pick(targetLightVibrant)
+	swatches.Vibrant = pick(targetVibrant)
+	swatches.DarkVibrant = pick(targetDarkVibrant)
+	swatches.LightMuted = pick(targetLightMuted)
+	swatches.Muted = pick(targetMuted)
+	swatches.DarkMuted = pick(targetDarkMuted)
+	return swatches
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// the skin tone ranges in YCbCr (Chai and Ngan), covering light to dark skin under normal lighting
+const (
+	skinMinCb, skinMaxCb = 77, 127
+	skinMinCr, skinMaxCr = 133, 173
+)
+
+// isSkinTone checks if the color is in the skin tone ranges
+func isSkinTone(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	_, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+	return cb >= skinMinCb && cb <= skinMaxCb && cr >= skinMinCr && cr <= skinMaxCr
+}
+
+// SkinPixelMask returns a mask excluding the pixels in the skin tone ranges, e.g. to ignore faces and hands
+func SkinPixelMask() PixelMask {
+	return func(x, y int, c color.Color) bool {
+		return isSkinTone(c)
+	}
+}
+
+// SkinToneProportion returns the share (0-1) of the pixels in the skin tone ranges, of the image cropped, resized and
+// masked as in KmeansWithOptions. It is only a color statistic, wood, sand and other beige colors are in the ranges
+// too, so it does not classify the image but can be combined with other signals.
+func SkinToneProportion(orgimg image.Image, opts Options) (float64, error) {
+	if err := opts.validate(orgimg); err != nil {
+		return 0, err
+	}
+	img, _ := opts.prepare(orgimg)
+
+	opaque, skin := 0, 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.At(x, y)
+			if _, ignore := createColor(c); ignore {
+				continue
+			}
+			opaque++
+			if isSkinTone(c) {
+				skin++
+			}
+		}
+	}
+	if opaque == 0 {
+		return 0, ErrNoPixelsFound
+	}
+	return float64(skin) / float64(opaque), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"sort"
+)
+
+// SortGrayChroma is the LAB chroma (0-100 scale) below which SortHue treats a color as gray, its hue being noise
+const SortGrayChroma = 5.0
+
+// SortColors orders the colors in place, colors that are equal for the mode keeping their order (equal counts of
+// SortCount are ordered by their hex value descending, as by Kmeans)
+func SortColors(colors []ColorItem, mode SortMode) {
+	sorted := make([]ColorItem, len(colors))
+	for i, idx := range sortOrder(colors, mode) {
+		sorted[i] = colors[idx]
+	}
+	copy(colors, sorted)
+}
+
+// sortOrder returns the indices of the colors in the order of the mode
+func sortOrder(colors []ColorItem, mode SortMode) []int {
+	order := make([]int, len(colors))
+	for i := range order {
+		order[i] = i
+	}
+
+	// keys holds the values compared for each color, the first one deciding unless equal
+	keys := make([][2]float64, len(colors))
+	for i := range colors {
+		c := &colors[i]
+		switch mode {
+		case SortHue:
+			v := c.toLCh()
+			// the grays come after all hues (0-360)
+			keys[i] = [2]float64{v.h, v.l}
+			if chroma(*c) < SortGrayChroma {
+				keys[i] = [2]float64{360, v.l}
+			}
+		case SortLightness:
+			keys[i] = [2]float64{c.LAB().L}
+		case SortChroma:
+			keys[i] = [2]float64{-chroma(*c)}
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if mode == SortCount {
+			return moreDominant(&colors[order[a]], &colors[order[b]])
+		}
+		ka, kb := keys[order[a]], keys[order[b]]
+		if ka[0] != kb[0] {
+			return ka[0] < kb[0]
+		}
+		return ka[1] < kb[1]
+	})
+	return order
+}
+
+// finish applies Options.WhiteBalance, Options.MergeDeltaE, Options.MinPercentage, Options.TopN, Options.Sort and
+// Options.ColorNames to the result
+func (o Options) finish(res *Result) {
+	o.whiteBalance(res)
+	if o.MergeDeltaE > 0 {
+		var groups [][]int
+		res.Colors, groups = mergeSimilar(res.Colors, o.MergeDeltaE)
+		regroupDetails(res, groups)
+	}
+	if o.MinPercentage > 0 {
+		var groups [][]int
+		colors := len(res.Colors)
+		res.Colors, groups = dropSmall(res.Colors, o.MinPercentage, o.RedistributeSmall)
+		res.DroppedColors = colors - len(res.Colors)
+		regroupDetails(res, groups)
+	}
+	o.trim(res)
+	// the colors are sorted by count already
+	if o.Sort != SortCount {
+		sortResult(res, o.Sort)
+	}
+	if o.ColorNames {
+		nameResult(res)
+	}
+}
+
+// sortResult orders the colors of the result, and their details with them
+func sortResult(res *Result, mode SortMode) {
+	order := sortOrder(res.Colors, mode)
+	colors := make([]ColorItem, len(order))
+	var details []clusterDetail
+	if len(res.details) >= len(order) {
+		details = make([]clusterDetail, len(order))
+	}
+	for i, idx := range order {
+		colors[i] = res.Colors[idx]
+		if details != nil {
+			details[i] = res.details[idx]
+		}
+	}
+	res.Colors = colors
+	if details != nil {
+		res.details = details
+	}
+}
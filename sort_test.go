@@ -0,0 +1,42 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestEqualCountsOrderedByHexDescending(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(img, image.Rect(0, 0, 16, 8), &image.Uniform{C: color.RGBA{R: 0xC8, G: 0x32, B: 0x14, A: 0xff}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 8, 16, 16), &image.Uniform{C: color.RGBA{R: 0x1E, G: 0x50, B: 0xB4, A: 0xff}}, image.Point{}, draw.Src)
+	for i := 0; i < 10; i++ {
+		colors, err := KmeansWithAll(2, img, ArgumentNoCropping, DefaultSize, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(colors) != 2 || colors[0].AsString() != "C83214" || colors[1].AsString() != "1E50B4" {
+			t.Fatalf("got %v, want C83214 before 1E50B4", colors)
+		}
+	}
+}
+
+func TestSortColorsEqualCounts(t *testing.T) {
+	colors := []ColorItem{
+		{Color: ColorRGB{R: 0x10}, Cnt: 5},
+		{Color: ColorRGB{R: 0x30}, Cnt: 5},
+		{Color: ColorRGB{R: 0x20}, Cnt: 7},
+	}
+	SortColors(colors, SortCount)
+	want := []string{"200000", "300000", "100000"}
+	for i, c := range colors {
+		if c.AsString() != want[i] {
+			t.Fatalf("color %d is %s, want the order %v", i, c.AsString(), want)
+		}
+	}
+}
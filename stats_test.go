@@ -0,0 +1,45 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestStatsCountedWhileMasking(t *testing.T) {
+	img := framedImage(60, color.RGBA{G: 0xb1, B: 0x40, A: 0xff}, color.White, color.RGBA{B: 0xff, A: 0xff})
+	for x := 0; x < 60; x++ {
+		img.Set(x, 30, color.Transparent)
+	}
+	for _, tc := range []struct {
+		name   string
+		modify func(o *Options)
+	}{
+		{"masks", func(o *Options) {}},
+		{"flood fill", func(o *Options) { o.BackgroundTolerance = DefaultBackgroundTolerance }},
+		{"border background", func(o *Options) { o.BorderBackground = &DefaultBorderBackground }},
+		{"chroma key", func(o *Options) { o.ChromaKey = &ChromaKeyGreen }},
+		{"edge foreground", func(o *Options) { o.Arguments |= ArgumentEdgeForeground }},
+		{"codes", func(o *Options) { o.Arguments |= ArgumentExcludeCodes }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := slowPath(DefaultOptions())
+			opts.Arguments = ArgumentDeterministic | ArgumentNoCropping
+			tc.modify(&opts)
+			prepared, prep := opts.prepare(img)
+			if opaque := countOpaque(prepared); prep.stats.ClusteredPixels != opaque {
+				t.Errorf("Expected %d clustered pixels, got %d", opaque, prep.stats.ClusteredPixels)
+			}
+			masked := prep.stats.maskedPixels()
+			if masked == 0 {
+				t.Errorf("Expected pixels to be masked")
+			}
+			if opaque := countOpaque(prep.beforeMasks); opaque-masked != prep.stats.ClusteredPixels {
+				t.Errorf("Expected %d masked pixels, got %d", opaque-prep.stats.ClusteredPixels, masked)
+			}
+		})
+	}
+}
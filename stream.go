@@ -0,0 +1,65 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image"
+)
+
+// FrameStream finds the colors of a sequence of frames (e.g. a video) added one at a time. The colors of the frames
+// are kept as a LAB histogram (Options.LabBinSize, DefaultLabBinSize if not set), so the memory used does not grow
+// with the number of frames. It is not safe for concurrent use.
+type FrameStream struct {
+	opts      Options
+	histogram []ColorItem
+	frames    int
+	skipped   int
+
+	// clustered and masked are the pixels of all frames that were clustered and removed by the masks
+	clustered, masked int
+}
+
+// NewFrameStream creates a stream using the options for every frame
+func NewFrameStream(opts Options) *FrameStream {
+	if opts.LabBinSize <= 0 {
+		opts.LabBinSize = DefaultLabBinSize
+	}
+	return &FrameStream{opts: opts}
+}
+
+// AddFrame adds the colors of the frame. Frames without any usable pixels are accepted, they add nothing.
+func (s *FrameStream) AddFrame(img image.Image) error {
+	if err := s.opts.validate(img); err != nil {
+		return err
+	}
+	prepared, prep := s.opts.prepare(img)
+	if err := s.opts.debug(prepared); err != nil {
+		return err
+	}
+	// the colors are binned already, binning them together with the histogram merges the bins
+	s.histogram = labHistogram(append(s.histogram, s.opts.colors(prepared)...), s.opts.LabBinSize)
+	s.frames++
+	s.skipped += prep.skipped
+	s.clustered += prep.stats.ClusteredPixels
+	s.masked += prep.stats.maskedPixels()
+	return nil
+}
+
+// Frames returns the number of frames added
+func (s *FrameStream) Frames() int {
+	return s.frames
+}
+
+// Finalize clusters the colors of all frames added so far. More frames can be added afterwards.
+func (s *FrameStream) Finalize() (Result, error) {
+	centroids, err := kmeansColors(s.opts.k(), append([]ColorItem{}, s.histogram...), s.opts.arguments())
+	if err != nil {
+		return Result{}, err
+	}
+	setShares(centroids, s.clustered, s.masked)
+	res := Result{Colors: centroids, SkippedPixels: s.skipped, Metadata: s.opts.Metadata, Fingerprint: s.opts.Fingerprint()}
+	s.opts.finish(&res)
+	return res, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+func init() {
+	registerBackend("swatch-images")
+}
+
+// SwatchLayout selects how RenderSwatch arranges the colors
+type SwatchLayout int
+
+const (
+	// SwatchBar is a horizontal bar, each color as wide as its share of the pixels
+	SwatchBar SwatchLayout = iota
+
+	// SwatchGrid is a grid of cells of the same size, in rows of SwatchOptions.Columns
+	SwatchGrid
+)
+
+const (
+	// DefaultSwatchWidth and DefaultSwatchHeight are the size of the swatch image if not set
+	DefaultSwatchWidth  = 600
+	DefaultSwatchHeight = 100
+
+	// swatchMaxLabelScale is the largest scale of the 5x7 pixel font of the labels
+	swatchMaxLabelScale = 3
+)
+
+// SwatchOptions contains the settings of RenderSwatch, the zero value is a bar of the default size without labels
+type SwatchOptions struct {
+	// Width and Height are the size of the image, DefaultSwatchWidth and DefaultSwatchHeight if not set
+	Width, Height int
+
+	Layout SwatchLayout
+
+	// Columns is the number of cells per row of SwatchGrid, all colors in one row if not set
+	Columns int
+
+	// Labels labels each color with its hex value and share of the pixels, in black or white, whichever has the
+	// higher contrast. Colors too small for the label are not labeled.
+	Labels bool
+}
+
+// RenderSwatch draws the colors (e.g. Result.Colors) as a bar or grid
+func RenderSwatch(items []ColorItem, opts SwatchOptions) image.Image {
+	w, h := opts.Width, opts.Height
+	if w <= 0 {
+		w = DefaultSwatchWidth
+	}
+	if h <= 0 {
+		h = DefaultSwatchHeight
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	if len(items) == 0 {
+		return img
+	}
+
+	percent := percentages(items)
+	cells := make([]image.Rectangle, len(items))
+	if opts.Layout == SwatchGrid {
+		cols := opts.Columns
+		if cols <= 0 || cols > len(items) {
+			cols = len(items)
+		}
+		rows := (len(items) + cols - 1) / cols
+		for i := range items {
+			x, y := i%cols, i/cols
+			cells[i] = image.Rect(x*w/cols, y*h/rows, (x+1)*w/cols, (y+1)*h/rows)
+		}
+	} else {
+		// the edges are rounded from the cumulative share, so the bar is filled without gaps
+		sum := 0.0
+		for i := range items {
+			x0 := int(math.Round(sum / 100 * float64(w)))
+			sum += percent[i]
+			x1 := int(math.Round(sum / 100 * float64(w)))
+			cells[i] = image.Rect(x0, 0, x1, h)
+		}
+		if sum == 0 {
+			// no counts, the colors share the bar equally
+			for i := range items {
+				cells[i] = image.Rect(i*w/len(items), 0, (i+1)*w/len(items), h)
+			}
+		}
+	}
+
+	for i, c := range items {
+		draw.Draw(img, cells[i], &image.Uniform{C: color.RGBA{R: uint8(c.Color.R), G: uint8(c.Color.G), B: uint8(c.Color.B), A: 0xff}}, image.Point{}, draw.Src)
+		if opts.Labels {
+			label := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+			if blackLabel(c) {
+				label = color.RGBA{A: 0xff}
+			}
+			drawLabel(img, cells[i], []string{"#" + c.AsString(), fmt.Sprintf("%.0f%%", percent[i])}, label)
+		}
+	}
+	return img
+}
+
+// drawLabel draws the lines centered in the cell, as large as they fit up to swatchMaxLabelScale
+func drawLabel(img draw.Image, cell image.Rectangle, lines []string, c color.Color) {
+	// the glyphs are 5x7 pixels, 1 pixel apart, the lines 3 pixels apart
+	longest := 0
+	for _, line := range lines {
+		longest = max(longest, len(line))
+	}
+	textW, textH := 6*longest-1, 10*len(lines)-3
+	const padding = 2
+	scale := min((cell.Dx()-2*padding)/textW, (cell.Dy()-2*padding)/textH, swatchMaxLabelScale)
+	if scale < 1 {
+		return
+	}
+
+	src := &image.Uniform{C: c}
+	y := cell.Min.Y + (cell.Dy()-textH*scale)/2
+	for _, line := range lines {
+		x := cell.Min.X + (cell.Dx()-(6*len(line)-1)*scale)/2
+		for _, ch := range line {
+			glyph := swatchFont[ch]
+			for row, bits := range glyph {
+				for col := 0; col < 5; col++ {
+					if bits&(1<<(4-col)) != 0 {
+						r := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+						draw.Draw(img, r, src, image.Point{}, draw.Src)
+					}
+				}
+			}
+			x += 6 * scale
+		}
+		y += 10 * scale
+	}
+}
+
+// swatchFont is a 5x7 pixel font of the characters of the labels, each row's bits from left to right
+var swatchFont = map[rune][7]uint8{
+	'0': {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1': {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2': {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3': {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4': {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5': {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6': {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7': {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8': {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9': {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'A': {0b01110, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'B': {0b11110, 0b10001, 0b10001, 0b11110, 0b10001, 0b10001, 0b11110},
+	'C': {0b01110, 0b10001, 0b10000, 0b10000, 0b10000, 0b10001, 0b01110},
+	'D': {0b11100, 0b10010, 0b10001, 0b10001, 0b10001, 0b10010, 0b11100},
+	'E': {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b11111},
+	'F': {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b10000},
+	'#': {0b01010, 0b01010, 0b11111, 0b01010, 0b11111, 0b01010, 0b01010},
+	'%': {0b11000, 0b11001, 0b00010, 0b00100, 0b01000, 0b10011, 0b00011},
+	'.': {0b00000, 0b00000, 0b00000, 0b00000, 0b00000, 0b01100, 0b01100},
+}
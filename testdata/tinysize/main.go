@@ -0,0 +1,32 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command tinysize is the program the size budget of the tiny build is measured with, see TestTinyBuildSize
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/cjkgg/prominentcolor"
+)
+
+func main() {
+	img, _, err := image.Decode(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	res, err := prominentcolor.KmeansWithOptions(img, prominentcolor.DefaultOptions())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, c := range res.Colors {
+		fmt.Println(c.AsString())
+	}
+}
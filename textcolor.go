@@ -0,0 +1,94 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import "math"
+
+const (
+	// ContrastAA is the WCAG 2 contrast ratio of level AA for normal text
+	ContrastAA = 4.5
+	// ContrastAAA is the WCAG 2 contrast ratio of level AAA for normal text
+	ContrastAAA = 7.0
+
+	// textMaxChroma is the largest chroma (LCh, 0-1 scale) of the tinted text colors
+	textMaxChroma = 0.15
+)
+
+// TextColor is a text color readable on a background color
+type TextColor struct {
+	// Color is a tint (on dark backgrounds) or shade (on light backgrounds) of the hue of the background, with the
+	// least change in lightness reaching the contrast. It is black or white if no tint or shade reaches it.
+	Color ColorItem
+
+	// BlackOrWhite is black or white, whichever has the larger contrast with the background
+	BlackOrWhite ColorItem
+
+	// Contrast is the WCAG 2 contrast ratio (1-21) of Color and the background
+	Contrast float64
+
+	// AA and AAA tell if Contrast meets the WCAG 2 levels AA (ContrastAA) and AAA (ContrastAAA) for normal text
+	AA, AAA bool
+}
+
+// ReadableTextColor returns a text color for the background color with at least the contrast ratio minContrast,
+// e.g. ContrastAA or ContrastAAA (ContrastAA if 0). Mid-tone backgrounds may not reach high contrasts with any
+// color, the text color is then BlackOrWhite and AA or AAA are false.
+func ReadableTextColor(background ColorItem, minContrast float64) TextColor {
+	if minContrast <= 0 {
+		minContrast = ContrastAA
+	}
+	black := ColorItem{}
+	white := ColorItem{Color: ColorRGB{R: 0xff, G: 0xff, B: 0xff}, Color16: ColorRGB{R: 0xffff, G: 0xffff, B: 0xffff}}
+	tc := TextColor{BlackOrWhite: white}
+	// the lightness goes towards black on light backgrounds, towards white on dark ones
+	target, step := 1.0, 0.01
+	if blackLabel(background) {
+		tc.BlackOrWhite = black
+		target, step = 0, -0.01
+	}
+
+	tc.Color = tc.BlackOrWhite
+	v := background.toLCh()
+	v.c = math.Min(v.c, textMaxChroma)
+	for l := v.l; (step > 0 && l < target) || (step < 0 && l > target); l += step {
+		v.l = l
+		if c := colorItemFromLCh(v, 0); contrastRatio(background, c) >= minContrast {
+			tc.Color = c
+			break
+		}
+	}
+
+	tc.Contrast = contrastRatio(background, tc.Color)
+	tc.AA, tc.AAA = tc.Contrast >= ContrastAA, tc.Contrast >= ContrastAAA
+	return tc
+}
+
+// TextColors returns a readable text color for each color of the result, see ReadableTextColor
+func (r Result) TextColors(minContrast float64) []TextColor {
+	colors := make([]TextColor, len(r.Colors))
+	for i, c := range r.Colors {
+		colors[i] = ReadableTextColor(c, minContrast)
+	}
+	return colors
+}
+
+// blackLabel checks if black has a higher contrast on the color than white
+func blackLabel(c ColorItem) bool {
+	black := ColorItem{}
+	white := ColorItem{Color: ColorRGB{R: 0xff, G: 0xff, B: 0xff}}
+	return contrastRatio(c, black) >= contrastRatio(c, white)
+}
+
+// contrastRatio returns the WCAG 2 contrast ratio (1-21) of the colors
+func contrastRatio(a, b ColorItem) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	return (math.Max(la, lb) + 0.05) / (math.Min(la, lb) + 0.05)
+}
+
+// relativeLuminance returns the WCAG 2 relative luminance (0-1) of the color
+func relativeLuminance(c ColorItem) float64 {
+	r, g, b := c.toColorful().LinearRgb()
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
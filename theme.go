@@ -0,0 +1,59 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !tiny
+
+package prominentcolor
+
+import (
+	"math"
+	"net/http"
+)
+
+func init() {
+	registerBackend("theme-color")
+}
+
+const (
+	// themeMinChroma is the LAB chroma (0-100 scale) a palette color needs to be preferred over the dominant color
+	themeMinChroma = 10.0
+	// themeMaxChroma is the largest chroma (LCh, 0-1 scale) of the theme color, so the browser UI is not garish
+	themeMaxChroma = 0.5
+	// themeMinContrast is the WCAG contrast ratio against white the theme color has at least, so white text and
+	// icons on the browser UI stay readable
+	themeMinContrast = 4.5
+)
+
+// ThemeColorForPage suggests the color for <meta name="theme-color"> of the page, as "#RRGGBB". It takes the most
+// prominent color of the primary image (see KmeansFromURL) with some chroma, limits its chroma and darkens it until
+// white text has a contrast ratio of at least 4.5. A nil client uses PublicHTTPClient, see KmeansFromURL.
+func ThemeColorForPage(client *http.Client, rawURL string) (string, error) {
+	res, err := KmeansFromURL(client, rawURL, DefaultOptions())
+	if err != nil {
+		return "", err
+	}
+	theme := themeColor(res.Colors)
+	return "#" + theme.AsString(), nil
+}
+
+// themeColor applies the theme color constraints to the palette
+func themeColor(palette []ColorItem) ColorItem {
+	base := palette[0]
+	for _, c := range palette {
+		if chroma(c) >= themeMinChroma {
+			base = c
+			break
+		}
+	}
+
+	v := base.toLCh()
+	v.c = math.Min(v.c, themeMaxChroma)
+	white := ColorItem{Color: ColorRGB{R: 0xff, G: 0xff, B: 0xff}}
+	c := colorItemFromLCh(v, base.Cnt)
+	for v.l > 0 && contrastRatio(c, white) < themeMinContrast {
+		v.l = math.Max(v.l-0.01, 0)
+		c = colorItemFromLCh(v, base.Cnt)
+	}
+	return c
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// tinySizeBudget is the size budget of the tiny build, see the README
+const tinySizeBudget = 3 << 20
+
+func TestTinyBuildSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	out := filepath.Join(t.TempDir(), "tinysize")
+	cmd := exec.Command(goTool, "build", "-tags", "tiny", "-trimpath", "-ldflags=-s -w", "-o", out, "./testdata/tinysize")
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed building the tiny program: %v\n%s", err, output)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > tinySizeBudget {
+		t.Errorf("Expected the tiny build to be at most %d bytes, got %d", tinySizeBudget, info.Size())
+	}
+	t.Logf("tiny build: %d bytes", info.Size())
+}
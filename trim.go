@@ -0,0 +1,43 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+// TopColors returns the n most dominant of the colors (sorted most dominant first, as in a result), and the others
+// combined into one: their counts and shares summed and their mean color weighted by count. The combined color
+// is nil if there are no others.
+func TopColors(colors []ColorItem, n int) ([]ColorItem, *ColorItem) {
+	if n < 0 || n >= len(colors) {
+		return colors, nil
+	}
+	rest := colors[n:]
+	weighted := false
+	for _, c := range rest {
+		weighted = weighted || c.Cnt > 0
+	}
+	other := mean(rest, weighted)
+	for _, c := range rest {
+		other.Percentage += c.Percentage
+		other.ShareOfTotal += c.ShareOfTotal
+	}
+	return colors[:n], &other
+}
+
+// trim keeps the Options.TopN most dominant colors of the result, combining the others into Result.Other
+func (o Options) trim(res *Result) {
+	if o.TopN <= 0 {
+		return
+	}
+	colors := res.Colors
+	res.Colors, res.Other = TopColors(colors, o.TopN)
+	res.OtherColors = len(colors) - len(res.Colors)
+}
+
+// Page returns the colors from offset on, at most limit of them, e.g. to page through the colors of a large K
+func (r Result) Page(offset, limit int) []ColorItem {
+	if offset < 0 || offset >= len(r.Colors) || limit <= 0 {
+		return nil
+	}
+	return r.Colors[offset:min(offset+limit, len(r.Colors))]
+}
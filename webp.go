@@ -0,0 +1,269 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// ErrNotWebP is returned when the data is not a WebP (RIFF) container
+var ErrNotWebP = fmt.Errorf("Failed, data is not a WebP image")
+
+const (
+	webpFlagAnimation = 0x02
+	webpFlagAlpha     = 0x10
+
+	anmfFlagDispose  = 0x01
+	anmfFlagNoBlend  = 0x02
+	anmfHeaderLength = 16
+)
+
+// webpChunk is a single chunk of a RIFF container
+type webpChunk struct {
+	fourCC string
+	data   []byte
+}
+
+// webpFrame is a single ANMF frame of an animated WebP
+type webpFrame struct {
+	rect image.Rectangle
+	// duration is how long the frame is shown, in milliseconds
+	duration int
+	dispose  bool
+	blend    bool
+	chunks   []webpChunk
+}
+
+// IsAnimatedWebP checks if data is a WebP image containing an animation
+func IsAnimatedWebP(data []byte) bool {
+	chunks, err := parseRIFFChunks(data)
+	if err != nil {
+		return false
+	}
+	for _, c := range chunks {
+		if c.fourCC == "VP8X" && len(c.data) > 0 && c.data[0]&webpFlagAnimation != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// KmeansWebP finds the colors of a (possibly animated) WebP image.
+// For animations the frames picked by sampling are analyzed one by one and combined into the aggregate weighted by
+// their duration, as KmeansGIF does, a still image is handled as an animation with a single frame.
+// A WebP decoder has to be registered with the image package, e.g. by importing golang.org/x/image/webp.
+func KmeansWebP(data []byte, sampling FrameSampling, opts Options) (FramesResult, error) {
+	if !IsAnimatedWebP(data) {
+		if _, err := parseRIFFChunks(data); err != nil {
+			return FramesResult{}, err
+		}
+		img, err := decodeWebP(data)
+		if err != nil {
+			return FramesResult{}, err
+		}
+		return kmeansFrames([]image.Image{img}, []int{0}, nil, opts)
+	}
+
+	frames, canvas, err := parseAnimatedWebP(data)
+	if err != nil {
+		return FramesResult{}, err
+	}
+
+	indices := sampling.indices(len(frames))
+	images, err := renderWebPFrames(frames, canvas, indices)
+	if err != nil {
+		return FramesResult{}, err
+	}
+	weights := make([]int, len(indices))
+	for i, idx := range indices {
+		// the duration in 1/100 s, as the delay of a GIF
+		weights[i] = frameWeight((frames[idx].duration + 5) / 10)
+	}
+	return kmeansFrames(images, indices, weights, opts)
+}
+
+// parseRIFFChunks splits a WebP RIFF container into its chunks
+func parseRIFFChunks(data []byte) ([]webpChunk, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrNotWebP
+	}
+
+	var chunks []webpChunk
+	rest := data[12:]
+	for len(rest) >= 8 {
+		size := int(binary.LittleEndian.Uint32(rest[4:8]))
+		// compared without adding, 8+size can overflow an int on 32 bit platforms
+		if size < 0 || size > len(rest)-8 {
+			return nil, fmt.Errorf("Failed, truncated WebP chunk %q", rest[0:4])
+		}
+		chunks = append(chunks, webpChunk{fourCC: string(rest[0:4]), data: rest[8 : 8+size]})
+
+		// chunks are padded to an even size
+		next := 8 + size + size&1
+		if next > len(rest) {
+			break
+		}
+		rest = rest[next:]
+	}
+	return chunks, nil
+}
+
+// parseAnimatedWebP extracts the frames and the canvas size of an animated WebP
+func parseAnimatedWebP(data []byte) ([]webpFrame, image.Rectangle, error) {
+	chunks, err := parseRIFFChunks(data)
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+
+	var canvas image.Rectangle
+	var frames []webpFrame
+	for _, c := range chunks {
+		switch c.fourCC {
+		case "VP8X":
+			if len(c.data) < 10 {
+				return nil, image.Rectangle{}, fmt.Errorf("Failed, invalid WebP VP8X chunk")
+			}
+			canvas = image.Rect(0, 0, int(uint24(c.data[4:7]))+1, int(uint24(c.data[7:10]))+1)
+		case "ANMF":
+			if len(c.data) < anmfHeaderLength {
+				return nil, image.Rectangle{}, fmt.Errorf("Failed, invalid WebP ANMF chunk")
+			}
+			x := int(uint24(c.data[0:3])) * 2
+			y := int(uint24(c.data[3:6])) * 2
+			w := int(uint24(c.data[6:9])) + 1
+			h := int(uint24(c.data[9:12])) + 1
+			flags := c.data[15]
+
+			sub, err := parseRIFFChunksRaw(c.data[anmfHeaderLength:])
+			if err != nil {
+				return nil, image.Rectangle{}, err
+			}
+			frames = append(frames, webpFrame{
+				rect:     image.Rect(x, y, x+w, y+h),
+				duration: int(uint24(c.data[12:15])),
+				dispose:  flags&anmfFlagDispose != 0,
+				blend:    flags&anmfFlagNoBlend == 0,
+				chunks:   sub,
+			})
+		}
+	}
+
+	if len(frames) == 0 {
+		return nil, image.Rectangle{}, fmt.Errorf("Failed, animated WebP without frames")
+	}
+	return frames, canvas, nil
+}
+
+// parseRIFFChunksRaw parses chunks that are not wrapped in a RIFF header (i.e. the frame data of an ANMF chunk)
+func parseRIFFChunksRaw(data []byte) ([]webpChunk, error) {
+	header := make([]byte, 12, 12+len(data))
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+len(data)))
+	copy(header[8:], "WEBP")
+	return parseRIFFChunks(append(header, data...))
+}
+
+// renderWebPFrames composites the frames onto the canvas and returns a copy of the canvas for each of the wanted indices
+func renderWebPFrames(frames []webpFrame, canvasRect image.Rectangle, indices []int) ([]image.Image, error) {
+	wanted := make(map[int]bool)
+	last := 0
+	for _, idx := range indices {
+		wanted[idx] = true
+		last = idx
+	}
+
+	canvas := image.NewNRGBA(canvasRect)
+	var images []image.Image
+
+	for i := 0; i <= last && i < len(frames); i++ {
+		f := frames[i]
+		img, err := decodeWebP(buildWebP(f.chunks, f.rect.Dx(), f.rect.Dy()))
+		if err != nil {
+			return nil, fmt.Errorf("Failed decoding WebP frame %d: %v", i, err)
+		}
+
+		op := draw.Src
+		if f.blend {
+			op = draw.Over
+		}
+		draw.Draw(canvas, f.rect, img, img.Bounds().Min, op)
+
+		if wanted[i] {
+			snapshot := image.NewNRGBA(canvasRect)
+			copy(snapshot.Pix, canvas.Pix)
+			images = append(images, snapshot)
+		}
+
+		if f.dispose {
+			draw.Draw(canvas, f.rect, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return images, nil
+}
+
+// buildWebP wraps the bitstream chunks of a frame into a standalone WebP file
+func buildWebP(chunks []webpChunk, width, height int) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+
+	for _, c := range chunks {
+		if c.fourCC == "ALPH" {
+			// the alpha chunk is only allowed in the extended format
+			vp8x := make([]byte, 10)
+			vp8x[0] = webpFlagAlpha
+			putUint24(vp8x[4:7], uint32(width-1))
+			putUint24(vp8x[7:10], uint32(height-1))
+			writeChunk(&body, "VP8X", vp8x)
+			break
+		}
+	}
+	for _, c := range chunks {
+		if c.fourCC == "ALPH" || c.fourCC == "VP8 " || c.fourCC == "VP8L" {
+			writeChunk(&body, c.fourCC, c.data)
+		}
+	}
+
+	out := make([]byte, 8, 8+body.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(body.Len()))
+	return append(out, body.Bytes()...)
+}
+
+// decodeWebP decodes a still WebP using the decoder registered with the image package
+func decodeWebP(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err == image.ErrFormat {
+		return nil, fmt.Errorf("Failed, no WebP decoder registered (import golang.org/x/image/webp): %v", err)
+	}
+	return img, err
+}
+
+// writeChunk writes a RIFF chunk, including padding
+func writeChunk(buf *bytes.Buffer, fourCC string, data []byte) {
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+	buf.WriteString(fourCC)
+	buf.Write(size[:])
+	buf.Write(data)
+	if len(data)&1 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+// uint24 reads a 24 bit little endian value
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// putUint24 writes a 24 bit little endian value
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
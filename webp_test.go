@@ -0,0 +1,149 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// The test WebP images carry a fake VP8L bitstream: width, height and the RGBA color of a uniform image. Its
+// decoder is registered for them only, real VP8L bitstreams start with 0x2f.
+func init() {
+	image.RegisterFormat("fakewebp", "RIFF????WEBPVP8L????\x00", decodeFakeWebP, func(r io.Reader) (image.Config, error) {
+		img, err := decodeFakeWebP(r)
+		if err != nil {
+			return image.Config{}, err
+		}
+		return image.Config{ColorModel: color.NRGBAModel, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}, nil
+	})
+}
+
+// fakeVP8L returns the fake bitstream of a uniform image
+func fakeVP8L(w, h int, c color.NRGBA) []byte {
+	return []byte{0, byte(w), byte(h), c.R, c.G, c.B, c.A}
+}
+
+func decodeFakeWebP(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 27 {
+		return nil, fmt.Errorf("truncated fake WebP")
+	}
+	p := data[20:]
+	img := image.NewNRGBA(image.Rect(0, 0, int(p[1]), int(p[2])))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:i+4], p[3:7])
+	}
+	return img, nil
+}
+
+// riffChunk returns a RIFF chunk, padded to an even size
+func riffChunk(fourCC string, data []byte) []byte {
+	var buf bytes.Buffer
+	writeChunk(&buf, fourCC, data)
+	return buf.Bytes()
+}
+
+// riffFile wraps the chunks into a WebP file
+func riffFile(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
+
+// animatedWebP returns a 4x2 animation of uniform frames shown for the durations (ms)
+func animatedWebP(colors []color.NRGBA, durations []int) []byte {
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation
+	putUint24(vp8x[4:7], 3)
+	putUint24(vp8x[7:10], 1)
+	chunks := [][]byte{riffChunk("VP8X", vp8x), riffChunk("ANIM", make([]byte, 6))}
+	for i, c := range colors {
+		anmf := make([]byte, anmfHeaderLength)
+		putUint24(anmf[6:9], 3)
+		putUint24(anmf[9:12], 1)
+		putUint24(anmf[12:15], uint32(durations[i]))
+		anmf[15] = anmfFlagNoBlend
+		anmf = append(anmf, riffChunk("VP8L", fakeVP8L(4, 2, c))...)
+		chunks = append(chunks, riffChunk("ANMF", anmf))
+	}
+	return riffFile(chunks...)
+}
+
+func TestParseRIFFChunks(t *testing.T) {
+	data := riffFile(riffChunk("ABCD", []byte{1, 2, 3}), riffChunk("EFGH", []byte{4, 5}))
+	chunks, err := parseRIFFChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].fourCC != "ABCD" || !bytes.Equal(chunks[0].data, []byte{1, 2, 3}) ||
+		chunks[1].fourCC != "EFGH" || !bytes.Equal(chunks[1].data, []byte{4, 5}) {
+		t.Errorf("Expected the two chunks skipping the padding, got %+v", chunks)
+	}
+
+	truncated := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(truncated[16:20], 0xffffff00)
+	for name, data := range map[string][]byte{
+		"not riff":    []byte("RIFX\x00\x00\x00\x00WEBP"),
+		"not webp":    []byte("RIFF\x00\x00\x00\x00WAVE"),
+		"short":       []byte("RIFF"),
+		"huge chunk":  truncated,
+		"chunk short": riffFile(riffChunk("ABCD", []byte{1, 2, 3}))[:22],
+	} {
+		if _, err := parseRIFFChunks(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if IsAnimatedWebP(data) {
+			t.Errorf("%s: expected no animation", name)
+		}
+	}
+}
+
+func TestKmeansWebP(t *testing.T) {
+	red, blue := color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{B: 0xff, A: 0xff}
+	opts := DefaultOptions()
+	opts.Masks = nil
+	opts.Arguments = ArgumentNoCropping | ArgumentDeterministic
+	opts.K = 2
+
+	still := riffFile(riffChunk("VP8L", fakeVP8L(4, 2, red)))
+	if IsAnimatedWebP(still) {
+		t.Error("Expected the still image not to be animated")
+	}
+	res, err := KmeansWebP(still, FrameSampling{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Frames) != 1 || res.Aggregate.Colors[0].Color != (ColorRGB{R: 0xff}) {
+		t.Errorf("Expected a single red frame, got %+v", res)
+	}
+
+	// blue is shown three times as long as red
+	anim := animatedWebP([]color.NRGBA{red, blue}, []int{100, 300})
+	if !IsAnimatedWebP(anim) {
+		t.Fatal("Expected an animation")
+	}
+	res, err = KmeansWebP(anim, FrameSampling{Mode: FrameSampleEveryNth}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Frames) != 2 || res.Frames[0].Colors[0].Color != (ColorRGB{R: 0xff}) || res.Frames[1].Colors[0].Color != (ColorRGB{B: 0xff}) {
+		t.Fatalf("Expected a red and a blue frame, got %+v", res.Frames)
+	}
+	top := res.Aggregate.Colors[0]
+	if top.Color != (ColorRGB{B: 0xff}) || top.Percentage < 74 || top.Percentage > 76 {
+		t.Errorf("Expected blue at 75%% of the aggregate, got %v at %.1f%%", top.Color, top.Percentage)
+	}
+}
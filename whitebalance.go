@@ -0,0 +1,121 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+// EXIF (TIFF) tags of the Exif IFD pointer and the white balance tags in the Exif IFD
+const (
+	exifIFDPointerTag   = 0x8769
+	exifLightSourceTag  = 0x9208
+	exifWhiteBalanceTag = 0xa403
+)
+
+// lightSource is the white point of an EXIF light source and how reliably it describes the light
+type lightSource struct {
+	white      [3]float64
+	confidence float64
+}
+
+// exifLightSources are the white points of the EXIF LightSource values. The standard illuminants are exact, the
+// weather and fluorescent light sources are approximated by the closest standard illuminant with a lower confidence.
+var exifLightSources = map[int]lightSource{
+	1:  {IlluminantD55, 0.75}, // daylight
+	2:  {IlluminantF2, 0.5},   // fluorescent
+	3:  {IlluminantA, 1},      // tungsten
+	4:  {IlluminantD55, 0.75}, // flash
+	9:  {IlluminantD55, 0.75}, // fine weather
+	10: {IlluminantD65, 0.75}, // cloudy weather
+	11: {IlluminantD75, 0.75}, // shade
+	12: {IlluminantF7, 0.5},   // daylight fluorescent
+	13: {IlluminantD50, 0.5},  // day white fluorescent
+	14: {IlluminantF2, 0.5},   // cool white fluorescent
+	15: {IlluminantF11, 0.5},  // white fluorescent
+	17: {IlluminantA, 1},      // standard light A
+	18: {IlluminantB, 1},      // standard light B
+	19: {IlluminantC, 1},      // standard light C
+	20: {IlluminantD55, 1},
+	21: {IlluminantD65, 1},
+	22: {IlluminantD75, 1},
+	23: {IlluminantD50, 1},
+	24: {IlluminantA, 0.75}, // ISO studio tungsten
+}
+
+// WhiteBalance describes the light an image was taken in, see ExifWhiteBalance
+type WhiteBalance struct {
+	// LightSource is the EXIF LightSource value, e.g. 3 for tungsten, 0 if unknown
+	LightSource int
+
+	// Auto is set if the camera balanced the white automatically, the colors of its JPEG are then already corrected
+	// for the light and are not adapted by Options.AdaptExifWhiteBalance
+	Auto bool
+
+	// Manual is set if the white balance was set manually instead of automatically by the camera, e.g. to a preset
+	// not matching the light, the colors are then adapted from the light source
+	Manual bool
+
+	// White is the XYZ (Y=1) white point of the light
+	White [3]float64
+
+	// Confidence (0-1) is how reliably White describes the light, the colors are adapted this much
+	Confidence float64
+
+	// Method is the chromatic adaptation transform, Bradford if not set
+	Method AdaptationMethod
+}
+
+// ExifWhiteBalance returns the white balance of the EXIF data of the encoded JPEG or PNG, false if it has no known
+// light source
+func ExifWhiteBalance(data []byte) (WhiteBalance, bool) {
+	tiff := exifTIFF(data)
+	order, ifd := tiffHeader(tiff)
+	pointer := tiffEntry(tiff, order, ifd, exifIFDPointerTag)
+	if pointer < 0 {
+		return WhiteBalance{}, false
+	}
+	// a LONG offset of the Exif IFD
+	exifIFD := int(order.Uint32(tiff[pointer+8 : pointer+12]))
+
+	var wb WhiteBalance
+	if entry := tiffEntry(tiff, order, exifIFD, exifLightSourceTag); entry >= 0 {
+		wb.LightSource = int(order.Uint16(tiff[entry+8 : entry+10]))
+	}
+	if entry := tiffEntry(tiff, order, exifIFD, exifWhiteBalanceTag); entry >= 0 {
+		mode := order.Uint16(tiff[entry+8 : entry+10])
+		wb.Auto, wb.Manual = mode == 0, mode == 1
+	}
+	source, ok := exifLightSources[wb.LightSource]
+	if !ok {
+		return WhiteBalance{}, false
+	}
+	wb.White, wb.Confidence = source.white, source.confidence
+	return wb, true
+}
+
+// adapt converts the color from the light of the white balance to D65, as if it was lit by daylight. The white
+// point is moved towards D65 by the confidence: a confidence of 0.5 adapts the color half way.
+func (wb WhiteBalance) adapt(c ColorItem) ColorItem {
+	var white [3]float64
+	for i := range white {
+		white[i] = wb.Confidence*wb.White[i] + (1-wb.Confidence)*whiteD65[i]
+	}
+	return AdaptColor(c, white, whiteD65, wb.Method)
+}
+
+// exifAdaptable reports if Options.AdaptExifWhiteBalance adapts the colors of the image, which it does unless the
+// camera balanced the white automatically
+func (wb WhiteBalance) exifAdaptable() bool {
+	return !wb.Auto
+}
+
+// whiteBalance adapts the colors of the result to D65 if Options.WhiteBalance is set
+func (o Options) whiteBalance(res *Result) {
+	if o.WhiteBalance == nil || o.WhiteBalance.Confidence <= 0 {
+		return
+	}
+	for i := range res.Colors {
+		res.Colors[i] = o.WhiteBalance.adapt(res.Colors[i])
+	}
+	wb := *o.WhiteBalance
+	res.WhiteBalance = &wb
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/jpeg"
+	"testing"
+)
+
+// tungstenJPEG returns a JPEG with EXIF taken in tungsten light (LightSource 3) with the EXIF white balance mode
+// (0 auto, 1 manual)
+func tungstenJPEG(t *testing.T, mode uint16) []byte {
+	t.Helper()
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = le.AppendUint32(tiff, 8)
+	// IFD0 with the pointer to the Exif IFD at 26
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = append(le.AppendUint16(le.AppendUint16(tiff, exifIFDPointerTag), 4), 1, 0, 0, 0)
+	tiff = le.AppendUint32(le.AppendUint32(tiff, 26), 0)
+	// Exif IFD with the light source and white balance
+	tiff = le.AppendUint16(tiff, 2)
+	for _, e := range [][2]uint16{{exifLightSourceTag, 3}, {exifWhiteBalanceTag, mode}} {
+		tiff = append(le.AppendUint16(le.AppendUint16(tiff, e[0]), 3), 1, 0, 0, 0)
+		tiff = le.AppendUint16(le.AppendUint16(tiff, e[1]), 0)
+	}
+	tiff = le.AppendUint32(tiff, 0)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, wideImage(), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xff, 0xe1, 0, 0}, app1...)
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(app1)+2))
+	return append(append([]byte{0xff, 0xd8}, segment...), buf.Bytes()[2:]...)
+}
+
+func TestAdaptExifWhiteBalanceSkipsAuto(t *testing.T) {
+	for _, test := range []struct {
+		mode        uint16
+		auto, adapt bool
+	}{
+		{0, true, false},
+		{1, false, true},
+	} {
+		data := tungstenJPEG(t, test.mode)
+		wb, ok := ExifWhiteBalance(data)
+		if !ok || wb.LightSource != 3 || wb.Auto != test.auto || wb.Manual == test.auto {
+			t.Fatalf("mode %d: unexpected white balance %+v %v", test.mode, wb, ok)
+		}
+		opts := DefaultOptions()
+		opts.AdaptExifWhiteBalance = true
+		res, err := KmeansFromBytes(data, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adapted := res.WhiteBalance != nil; adapted != test.adapt {
+			t.Errorf("mode %d: expected adapted %v, got %v", test.mode, test.adapt, adapted)
+		}
+	}
+}
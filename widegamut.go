@@ -0,0 +1,41 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// DisplayP3 returns the color as Display P3 coordinates (0-1), e.g. for native iOS colors
+func (c *ColorItem) DisplayP3() [3]float64 {
+	return ProfileDisplayP3.FromSRGB(c.nrgba64())
+}
+
+// Rec2020 returns the color as Rec. 2020 coordinates (0-1)
+func (c *ColorItem) Rec2020() [3]float64 {
+	return ProfileRec2020.FromSRGB(c.nrgba64())
+}
+
+// CSSDisplayP3 gives back the color as CSS, e.g. "color(display-p3 0.1234 0.5678 0.9012)"
+func (c *ColorItem) CSSDisplayP3() string {
+	return cssColor("display-p3", c.DisplayP3())
+}
+
+// CSSRec2020 gives back the color as CSS, e.g. "color(rec2020 0.1234 0.5678 0.9012)"
+func (c *ColorItem) CSSRec2020() string {
+	return cssColor("rec2020", c.Rec2020())
+}
+
+// nrgba64 returns the (16 bit) color as color.NRGBA64
+func (c *ColorItem) nrgba64() color.NRGBA64 {
+	c16 := c.color16()
+	return color.NRGBA64{R: uint16(c16.R), G: uint16(c16.G), B: uint16(c16.B), A: 0xffff}
+}
+
+// cssColor formats coordinates as a CSS color() function
+func cssColor(space string, v [3]float64) string {
+	return fmt.Sprintf("color(%s %.4f %.4f %.4f)", space, v[0], v[1], v[2])
+}
@@ -0,0 +1,48 @@
+// Copyright 2016 Carl Asman. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prominentcolor
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestWideGamut(t *testing.T) {
+	red := ColorItem{Color: ColorRGB{R: 0xff}}
+	white := ColorItem{Color: ColorRGB{R: 0xff, G: 0xff, B: 0xff}}
+	for name, tc := range map[string]struct {
+		got, want [3]float64
+	}{
+		"p3 red":      {red.DisplayP3(), [3]float64{0.9175, 0.2003, 0.1386}},
+		"p3 white":    {white.DisplayP3(), [3]float64{1, 1, 1}},
+		"rec2020 red": {red.Rec2020(), [3]float64{0.7920, 0.2310, 0.0738}},
+	} {
+		for i := range tc.want {
+			if math.Abs(tc.got[i]-tc.want[i]) > 0.002 {
+				t.Errorf("%s: expected %.4f, got %.4f", name, tc.want, tc.got)
+				break
+			}
+		}
+	}
+
+	// converting the coordinates back gives the sRGB color again
+	for _, c := range []ColorItem{red, white, {Color: ColorRGB{R: 0x12, G: 0x9a, B: 0x56}}} {
+		for _, p := range []*ICCProfile{ProfileDisplayP3, ProfileRec2020} {
+			v := p.FromSRGB(c.nrgba64())
+			back := p.Convert(color.NRGBA64{R: uint16(math.Round(v[0] * 0xffff)), G: uint16(math.Round(v[1] * 0xffff)), B: uint16(math.Round(v[2] * 0xffff)), A: 0xffff})
+			if got := (ColorRGB{R: uint32(back.R >> 8), G: uint32(back.G >> 8), B: uint32(back.B >> 8)}); got != c.Color {
+				t.Errorf("%s: expected %v back, got %v", p.Name, c.Color, got)
+			}
+		}
+	}
+
+	if got, want := white.CSSDisplayP3(), "color(display-p3 1.0000 1.0000 1.0000)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got, want := red.CSSRec2020(), "color(rec2020 0.7921 0.2312 0.0738)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}